@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"go-rest-api-template/internal/application"
+
+	gocli "github.com/budimanlai/go-cli"
+)
+
+// RegisterApiKeyCommands registers all API key management commands
+func RegisterApiKeyCommands(cli *gocli.Cli) {
+	// Register API key create command
+	cli.AddCommand("apikey-create", application.ApiKeyCreateService)
+
+	// Register API key rotate command
+	cli.AddCommand("apikey-rotate", application.ApiKeyRotateService)
+
+	// Register API key revoke command
+	cli.AddCommand("apikey-revoke", application.ApiKeyRevokeService)
+
+	// Register API key list command
+	cli.AddCommand("apikey-list", application.ApiKeyListService)
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"go-rest-api-template/internal/application"
+
+	gocli "github.com/budimanlai/go-cli"
+)
+
+// RegisterRoleCommands registers all role management commands
+func RegisterRoleCommands(cli *gocli.Cli) {
+	// Register role assign command
+	cli.AddCommand("role-assign", application.RoleAssignService)
+
+	// Register role revoke command
+	cli.AddCommand("role-revoke", application.RoleRevokeService)
+}
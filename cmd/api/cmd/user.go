@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"go-rest-api-template/internal/application"
+
+	gocli "github.com/budimanlai/go-cli"
+)
+
+// RegisterUserCommands registers all user management commands
+func RegisterUserCommands(cli *gocli.Cli) {
+	// Register user unlock command
+	cli.AddCommand("user-unlock", application.UserUnlockService)
+
+	// Register deleted user purge command
+	cli.AddCommand("user-purge", application.UserPurgeService)
+
+	// Register bulk import and export commands
+	cli.AddCommand("user-import", application.UserImportService)
+	cli.AddCommand("user-export", application.UserExportService)
+}
@@ -19,6 +19,15 @@ func main() {
 	// Register migration commands
 	cmd.RegisterMigrationCommands(cli)
 
+	// Register API key management commands
+	cmd.RegisterApiKeyCommands(cli)
+
+	// Register role management commands
+	cmd.RegisterRoleCommands(cli)
+
+	// Register user management commands
+	cmd.RegisterUserCommands(cli)
+
 	// Register service commands
 	cli.StartService("run", "start", application.RestApi)
 	cli.StopService("stop")
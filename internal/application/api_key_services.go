@@ -0,0 +1,177 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"go-rest-api-template/internal/domain/entity"
+	repositoryImpl "go-rest-api-template/internal/repository"
+	"go-rest-api-template/internal/service"
+	"strings"
+	"time"
+
+	gocli "github.com/budimanlai/go-cli"
+)
+
+// ApiKeyCreateService creates a new API key and prints its secrets once
+func ApiKeyCreateService(c *gocli.Cli) {
+	name := c.Args.GetString("name")
+	if name == "" {
+		c.Log("API key name is required. Example: --name=mobile-app [--description=...] [--h2h=Y] [--scopes=users:read,users:write] [--ip_whitelist=10.0.0.1,10.1.0.0/16] [--ip_denylist=10.1.2.3] [--rate_limit=5] [--burst=10] [--daily_quota=10000]")
+		return
+	}
+
+	h2h := c.Args.GetStringOr("h2h", "N")
+	if h2h != "Y" && h2h != "N" {
+		c.Log(fmt.Sprintf("Invalid h2h value: %s (use Y or N)", h2h))
+		return
+	}
+
+	apiKeyService, err := createApiKeyService(c)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to create API key service: %v", err))
+		return
+	}
+
+	apiKey := &entity.ApiKey{
+		Name: name,
+		H2H:  h2h,
+		// 0 uses the configured default rate limit and no daily quota
+		RateLimitPerSecond: c.Args.GetIntOr("rate_limit", 0),
+		RateLimitBurst:     c.Args.GetIntOr("burst", 0),
+		DailyQuota:         c.Args.GetIntOr("daily_quota", 0),
+	}
+	if description := c.Args.GetString("description"); description != "" {
+		apiKey.Description = &description
+	}
+	if apiKey.RateLimitPerSecond < 0 || apiKey.RateLimitBurst < 0 || apiKey.DailyQuota < 0 {
+		c.Log("Invalid rate limit: --rate_limit, --burst and --daily_quota cannot be negative")
+		return
+	}
+
+	// Without --scopes the key is granted every scope
+	if scopes := c.Args.GetString("scopes"); scopes != "" {
+		apiKey.Scopes = strings.Split(scopes, ",")
+	}
+	if ipWhitelist := c.Args.GetString("ip_whitelist"); ipWhitelist != "" {
+		apiKey.IPWhitelist = &ipWhitelist
+	}
+	if ipDenylist := c.Args.GetString("ip_denylist"); ipDenylist != "" {
+		apiKey.IPDenylist = &ipDenylist
+	}
+
+	if err := apiKeyService.CreateApiKey(context.Background(), apiKey); err != nil {
+		c.Log(fmt.Sprintf("API key create failed: %v", err))
+		return
+	}
+
+	printApiKeySecrets(apiKey)
+	c.Log("API key created successfully!")
+}
+
+// ApiKeyRotateService issues new secrets for an API key
+func ApiKeyRotateService(c *gocli.Cli) {
+	id := c.Args.GetInt("id")
+	if id <= 0 {
+		c.Log("API key id is required. Example: --id=3 [--grace_hours=24]")
+		return
+	}
+
+	// Hours the old secrets keep working, so clients can switch over
+	graceHours := c.Args.GetIntOr("grace_hours", 24)
+	if graceHours < 0 {
+		c.Log(fmt.Sprintf("Invalid grace_hours value: %d", graceHours))
+		return
+	}
+
+	apiKeyService, err := createApiKeyService(c)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to create API key service: %v", err))
+		return
+	}
+
+	apiKey, err := apiKeyService.RotateApiKey(context.Background(), id, time.Duration(graceHours)*time.Hour, nil)
+	if err != nil {
+		c.Log(fmt.Sprintf("API key rotate failed: %v", err))
+		return
+	}
+
+	printApiKeySecrets(apiKey)
+	if apiKey.PreviousKeyExpiresAt != nil {
+		fmt.Printf("⏳ Previous key valid until: %s\n", apiKey.PreviousKeyExpiresAt.Format("2006-01-02 15:04:05"))
+	}
+	c.Log("API key rotated successfully!")
+}
+
+// ApiKeyRevokeService permanently disables an API key
+func ApiKeyRevokeService(c *gocli.Cli) {
+	id := c.Args.GetInt("id")
+	if id <= 0 {
+		c.Log("API key id is required. Example: --id=3")
+		return
+	}
+
+	apiKeyService, err := createApiKeyService(c)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to create API key service: %v", err))
+		return
+	}
+
+	if err := apiKeyService.RevokeApiKey(context.Background(), id, nil); err != nil {
+		c.Log(fmt.Sprintf("API key revoke failed: %v", err))
+		return
+	}
+
+	c.Log("API key revoked successfully!")
+}
+
+// ApiKeyListService lists API keys without their secrets
+func ApiKeyListService(c *gocli.Cli) {
+	apiKeyService, err := createApiKeyService(c)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to create API key service: %v", err))
+		return
+	}
+
+	limit := c.Args.GetIntOr("limit", 100)
+	offset := c.Args.GetIntOr("offset", 0)
+	apiKeys, err := apiKeyService.GetAllApiKeys(context.Background(), limit, offset)
+	if err != nil {
+		c.Log(fmt.Sprintf("API key list failed: %v", err))
+		return
+	}
+
+	fmt.Printf("%-6s %-30s %-10s %-9s %-4s %-20s %s\n", "ID", "NAME", "PREFIX", "STATUS", "H2H", "LAST ACCESS", "SCOPES")
+	for _, apiKey := range apiKeys {
+		lastAccess := "-"
+		if apiKey.LastAccess != nil {
+			lastAccess = apiKey.LastAccess.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%-6d %-30s %-10s %-9s %-4s %-20s %s\n", apiKey.ID, apiKey.Name, apiKey.ApiKeyPrefix, apiKey.Status, apiKey.H2H, lastAccess,
+			strings.Join(apiKey.Scopes, ","))
+	}
+}
+
+// createApiKeyService creates an API key service connected to the configured database
+func createApiKeyService(c *gocli.Cli) (service.ApiKeyService, error) {
+	db, err := connectDatabase(c)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := service.NewApiKeySecretManager(apiKeySecret(c))
+	if err != nil {
+		return nil, err
+	}
+
+	// Changes made here are audited without an actor, i.e. as the system
+	auditService := service.NewAuditService(repositoryImpl.NewAuditLogRepository(db))
+	return service.NewApiKeyService(repositoryImpl.NewApiKeyRepository(db), secrets, auditService), nil
+}
+
+// printApiKeySecrets prints the plaintext secrets, which cannot be retrieved later
+func printApiKeySecrets(apiKey *entity.ApiKey) {
+	fmt.Printf("🔑 ID:       %d\n", apiKey.ID)
+	fmt.Printf("🔑 API key:  %s\n", apiKey.ApiKey)
+	fmt.Printf("🔑 Auth key: %s\n", apiKey.AuthKey)
+	fmt.Println("⚠️  Store these secrets now, they cannot be shown again")
+}
@@ -1,6 +1,8 @@
 package application
 
 import (
+	"context"
+	"fmt"
 	"go-rest-api-template/internal/domain/repository"
 	"go-rest-api-template/internal/domain/usecase"
 	"go-rest-api-template/internal/handler"
@@ -8,7 +10,12 @@ import (
 	repositoryImpl "go-rest-api-template/internal/repository"
 	"go-rest-api-template/internal/service"
 	"go-rest-api-template/pkg/i18n"
+	"go-rest-api-template/pkg/logger"
+	"go-rest-api-template/pkg/mailer"
+	"go-rest-api-template/pkg/password"
 	"go-rest-api-template/pkg/response"
+	"strconv"
+	"time"
 
 	gocli "github.com/budimanlai/go-cli"
 	"github.com/jmoiron/sqlx"
@@ -23,22 +30,100 @@ type Container struct {
 	jwtSecret          string
 	publicTokenExpiry  int
 	privateTokenExpiry int
+	refreshTokenExpiry int
+
+	// JWT signing keys: HS256 uses jwtSecret, asymmetric methods use PEM key files
+	jwtSigningMethod string
+	jwtSigningKeyID  string
+	jwtKeys          []service.JWTKeyConfig
+
+	// Token revocation configuration
+	revocationStore         string
+	revocationPurgeInterval int
+
+	// H2H request signing configuration
+	nonceStore   string
+	maxClockSkew int
+
+	// Rate limiting configuration
+	rateLimitStore  string
+	rateLimitConfig service.RateLimitConfig
+
+	// Brute-force protection of login
+	loginAttemptConfig service.LoginAttemptConfig
+
+	// Retention of soft-deleted users, purged every userPurgeInterval minutes
+	userRetentionConfig service.UserRetentionConfig
+	userPurgeInterval   int
+
+	// Bulk user imports and exports
+	userBulkConfig service.UserBulkConfig
+
+	// Lifetime of the tokens admins obtain to impersonate users
+	impersonationTokenExpiry int
+
+	// Password reset and email verification configuration
+	userConfig  service.UserServiceConfig
+	emailConfig service.EmailConfig
+
+	// Secret API keys are hashed and auth keys encrypted with
+	apiKeySecret string
+
+	// Two-factor authentication: TOTP secrets are encrypted with mfaSecret
+	mfaSecret       string
+	mfaIssuer       string
+	requireAdminMFA bool
+
+	// Users allowed to call the admin endpoints (admin.user_ids)
+	AdminUserIDs []int
 
 	// I18n
 	I18nManager *i18n.Manager
 
+	// Mailer sends the emails of the user flows
+	Mailer mailer.Mailer
+
 	// Repositories
-	UserRepo   repository.UserRepository
-	ApiKeyRepo repository.ApiKeyRepository
+	UserRepo         repository.UserRepository
+	ApiKeyRepo       repository.ApiKeyRepository
+	RefreshTokenRepo repository.RefreshTokenRepository
+	RevocationRepo   repository.TokenRevocationRepository
+	SessionRepo      repository.SessionRepository
+	NonceRepo        repository.RequestNonceRepository
+	RateLimitRepo    repository.RateLimitRepository
+	RoleRepo         repository.RoleRepository
+	MFARepo          repository.MFARepository
+	AuditLogRepo     repository.AuditLogRepository
 
 	// Services (Business Logic)
-	JWTService    service.JWTService
-	UserService   usecase.UserUsecase
-	ApiKeyService service.ApiKeyService
+	JWTService           service.JWTService
+	UserService          usecase.UserUsecase
+	ApiKeyService        service.ApiKeyService
+	RefreshTokenService  service.RefreshTokenService
+	SessionService       service.SessionService
+	SignatureService     service.RequestSignatureService
+	RateLimitService     service.RateLimitService
+	RoleService          service.RoleService
+	EmailService         service.EmailService
+	LoginAttemptService  service.LoginAttemptService
+	MFAService           service.MFAService
+	UserRetentionService service.UserRetentionService
+	PersonalDataService  service.PersonalDataService
+	UserBulkService      service.UserBulkService
+	ImpersonationService service.ImpersonationService
+	AuditService         service.AuditService
 
 	// Handlers (HTTP Controllers)
-	UserHandler *handler.UserHandler
-	AuthHandler *handler.AuthHandler
+	UserHandler          *handler.UserHandler
+	AuthHandler          *handler.AuthHandler
+	SessionHandler       *handler.SessionHandler
+	ApiKeyHandler        *handler.ApiKeyHandler
+	RoleHandler          *handler.RoleHandler
+	MFAHandler           *handler.MFAHandler
+	AuditLogHandler      *handler.AuditLogHandler
+	PersonalDataHandler  *handler.PersonalDataHandler
+	UserBulkHandler      *handler.UserBulkHandler
+	ImpersonationHandler *handler.ImpersonationHandler
 }
 
 // NewContainer creates and initializes all dependencies
@@ -49,6 +134,99 @@ func NewContainer(db *sqlx.DB, config *gocli.Cli) *Container {
 		jwtSecret:          config.Config.GetString("jwt.secret"),
 		publicTokenExpiry:  config.Config.GetInt("jwt.public_token_expiry_hours"),
 		privateTokenExpiry: config.Config.GetInt("jwt.private_token_expiry_hours"),
+		refreshTokenExpiry: config.Config.GetIntOr("jwt.refresh_token_expiry_hours", 720),
+		jwtSigningMethod:   config.Config.GetStringOr("jwt.signing_method", "HS256"),
+		jwtSigningKeyID:    config.Config.GetString("jwt.signing_key_id"),
+		// Token revocation store: "mysql" (shared) or "memory" (single node)
+		revocationStore:         config.Config.GetStringOr("jwt.revocation_store", "mysql"),
+		revocationPurgeInterval: config.Config.GetIntOr("jwt.revocation_purge_interval_minutes", 10),
+		// Nonce store for signed H2H requests: "mysql" (shared) or "memory" (single node)
+		nonceStore:   config.Config.GetStringOr("h2h.nonce_store", "mysql"),
+		maxClockSkew: config.Config.GetIntOr("h2h.max_clock_skew_seconds", 300),
+		apiKeySecret: apiKeySecret(config),
+		// TOTP secrets fall back to the API key secret, which falls back to jwt.secret
+		mfaSecret:       config.Config.GetStringOr("mfa.secret", apiKeySecret(config)),
+		mfaIssuer:       config.Config.GetStringOr("mfa.issuer", "go-rest-api"),
+		requireAdminMFA: config.Config.GetBoolOr("mfa.required_for_admins", false),
+		// Rate limiter state: only "memory" (single node) ships, shared stores implement RateLimitRepository
+		rateLimitStore: config.Config.GetStringOr("rate_limit.store", "memory"),
+		rateLimitConfig: service.RateLimitConfig{
+			DefaultPerSecond: config.Config.GetIntOr("rate_limit.default_per_second", 10),
+			DefaultBurst:     config.Config.GetIntOr("rate_limit.default_burst", 20),
+			IPPerMinute:      config.Config.GetIntOr("rate_limit.ip_per_minute", 10),
+			IPBurst:          config.Config.GetIntOr("rate_limit.ip_burst", 5),
+			IPActions: map[string]service.IPLimit{
+				"resend_verification": {
+					PerHour: config.Config.GetIntOr("rate_limit.resend_verification_per_hour", 5),
+					Burst:   config.Config.GetIntOr("rate_limit.resend_verification_burst", 2),
+				},
+				// Six digit codes must not be guessable within the lifetime of an MFA token
+				"mfa_verify": {
+					PerHour: config.Config.GetIntOr("rate_limit.mfa_verify_per_hour", 30),
+					Burst:   config.Config.GetIntOr("rate_limit.mfa_verify_burst", 5),
+				},
+			},
+		},
+		// Failed login limits, shared with the user-unlock command
+		loginAttemptConfig: loginAttemptConfig(config),
+		userPurgeInterval:  config.Config.GetIntOr("user_retention.purge_interval_minutes", 60),
+		// Shared with the user-import command
+		userBulkConfig: userBulkConfig(config),
+		// Impersonation tokens cannot be refreshed, so they are kept short
+		impersonationTokenExpiry: config.Config.GetIntOr("impersonation.token_expiry_minutes", 15),
+		userConfig: service.UserServiceConfig{
+			ResetTokenExpiry:         time.Duration(config.Config.GetIntOr("password_reset.token_expiry_minutes", 60)) * time.Minute,
+			RequireEmailVerification: config.Config.GetBoolOr("auth.require_email_verification", false),
+			VerificationSecret:       config.Config.GetStringOr("email_verification.secret", apiKeySecret(config)), // like mfa.secret, jwt.secret is empty with asymmetric keys
+			VerificationTokenExpiry:  time.Duration(config.Config.GetIntOr("email_verification.token_expiry_hours", 24)) * time.Hour,
+		},
+		emailConfig: emailConfig(config),
+	}
+
+	for _, key := range config.Config.GetArrayObject("jwt.keys", []string{"kid", "algorithm", "private_key_file", "public_key_file"}) {
+		container.jwtKeys = append(container.jwtKeys, service.JWTKeyConfig{
+			KeyID:          key["kid"],
+			Algorithm:      key["algorithm"],
+			PrivateKeyFile: key["private_key_file"],
+			PublicKeyFile:  key["public_key_file"],
+		})
+	}
+
+	for _, id := range config.Config.GetArrayString("admin.user_ids") {
+		if userID, err := strconv.Atoi(id); err == nil {
+			container.AdminUserIDs = append(container.AdminUserIDs, userID)
+		}
+	}
+
+	// One password policy for the validator tag, the user entity and the user service
+	policy, err := passwordPolicy(config)
+	if err != nil {
+		panic("Failed to load password policy: " + err.Error())
+	}
+	password.SetPolicy(policy)
+
+	// Existing hashes of other algorithms keep working and are upgraded on login
+	hasher, err := passwordHasher(config)
+	if err != nil {
+		panic("Failed to configure password hasher: " + err.Error())
+	}
+	password.SetHasher(hasher)
+
+	// Verification tokens signed with an empty secret could be forged by anyone
+	if container.userConfig.VerificationSecret == "" {
+		panic("email_verification.secret is required when neither api_key.secret nor jwt.secret is set")
+	}
+
+	// Shared with the user-purge command
+	container.userRetentionConfig, err = userRetentionConfig(config)
+	if err != nil {
+		panic("Invalid user retention config: " + err.Error())
+	}
+
+	// Shared with the user-import command, which mails invitations
+	container.Mailer, err = newMailer(config)
+	if err != nil {
+		panic("Failed to configure mailer: " + err.Error())
 	}
 
 	// Initialize dependencies in order
@@ -56,19 +234,13 @@ func NewContainer(db *sqlx.DB, config *gocli.Cli) *Container {
 	container.initRepositories()
 	container.initServices()
 	container.initHandlers()
+	container.startBackgroundJobs()
 
 	return container
 }
 
 // initI18n initializes internationalization
 func (c *Container) initI18n() {
-	i18nConfig := i18n.Config{
-		DefaultLanguage: "en",
-		LocalesPath:     "./locales",
-		SupportedLangs:  []string{"en", "id", "es"},         // Added Spanish support
-		Modules:         []string{"common", "user", "auth"}, // Modular translation files
-	}
-
 	manager, err := i18n.NewManager(i18nConfig)
 	if err != nil {
 		// Log error but don't fail startup
@@ -87,23 +259,186 @@ func (c *Container) initI18n() {
 	response.GlobalI18nResponseHelper = responseHelper
 }
 
+// i18nConfig selects the languages and translation files of the application
+var i18nConfig = i18n.Config{
+	DefaultLanguage: "en",
+	LocalesPath:     "./locales",
+	SupportedLangs:  []string{"en", "id", "es"},                 // Added Spanish support
+	Modules:         []string{"common", "user", "auth", "mail"}, // Modular translation files
+}
+
+// newMailer selects the mail delivery configured in mail.driver:
+// "smtp", "file" (writes .eml files) or "log"
+func newMailer(config *gocli.Cli) (mailer.Mailer, error) {
+	smtpConfig := mailer.SMTPConfig{
+		Host:     config.Config.GetString("mail.smtp.host"),
+		Port:     config.Config.GetIntOr("mail.smtp.port", 587),
+		Username: config.Config.GetString("mail.smtp.username"),
+		Password: config.Config.GetString("mail.smtp.password"),
+		From:     config.Config.GetString("mail.from"),
+	}
+
+	switch driver := config.Config.GetStringOr("mail.driver", "log"); driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(smtpConfig), nil
+	case "file":
+		return mailer.NewFileMailer(config.Config.GetStringOr("mail.file_dir", "./storage/mail"), smtpConfig.From), nil
+	case "log":
+		return mailer.NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail.driver %q", driver)
+	}
+}
+
+// emailConfig reads the links of the client app put into emails
+func emailConfig(config *gocli.Cli) service.EmailConfig {
+	return service.EmailConfig{
+		PasswordResetURL:     config.Config.GetString("password_reset.url"),
+		EmailVerificationURL: config.Config.GetString("email_verification.url"),
+	}
+}
+
 // initRepositories initializes all repository implementations
 func (c *Container) initRepositories() {
 	c.UserRepo = repositoryImpl.NewUserRepository(c.DB)
+	c.RoleRepo = repositoryImpl.NewRoleRepository(c.DB)
+	c.MFARepo = repositoryImpl.NewMFARepository(c.DB)
+	c.AuditLogRepo = repositoryImpl.NewAuditLogRepository(c.DB)
 	c.ApiKeyRepo = repositoryImpl.NewApiKeyRepository(c.DB)
+	c.RefreshTokenRepo = repositoryImpl.NewRefreshTokenRepository(c.DB)
+	c.SessionRepo = repositoryImpl.NewSessionRepository(c.DB)
+
+	if c.revocationStore == "memory" {
+		c.RevocationRepo = repositoryImpl.NewInMemoryTokenRevocationRepository()
+	} else {
+		c.RevocationRepo = repositoryImpl.NewTokenRevocationRepository(c.DB)
+	}
+
+	if c.nonceStore == "memory" {
+		c.NonceRepo = repositoryImpl.NewInMemoryRequestNonceRepository()
+	} else {
+		c.NonceRepo = repositoryImpl.NewRequestNonceRepository(c.DB)
+	}
+
+	switch c.rateLimitStore {
+	case "memory":
+		c.RateLimitRepo = repositoryImpl.NewInMemoryRateLimitRepository()
+	default:
+		panic("Unsupported rate_limit.store: " + c.rateLimitStore)
+	}
 }
 
 // initServices initializes all service implementations
 func (c *Container) initServices() {
-	c.ApiKeyService = service.NewApiKeyService(c.ApiKeyRepo)
-	c.JWTService = service.NewJWTService(c.jwtSecret, c.publicTokenExpiry, c.privateTokenExpiry, c.ApiKeyService)
-	c.UserService = service.NewUserService(c.UserRepo, c.JWTService)
+	apiKeySecrets, err := service.NewApiKeySecretManager(c.apiKeySecret)
+	if err != nil {
+		panic("Failed to initialize API key secrets: " + err.Error())
+	}
+	c.AuditService = service.NewAuditService(c.AuditLogRepo)
+	c.ApiKeyService = service.NewApiKeyService(c.ApiKeyRepo, apiKeySecrets, c.AuditService)
+	c.JWTService = service.NewJWTService(c.initJWTKeySet(), c.publicTokenExpiry, c.privateTokenExpiry, c.ApiKeyService, c.RevocationRepo)
+	c.RefreshTokenService = service.NewRefreshTokenService(c.RefreshTokenRepo, c.refreshTokenExpiry)
+	// Sessions live as long as the refresh tokens that keep them alive
+	c.SessionService = service.NewSessionService(c.SessionRepo, c.RefreshTokenRepo, c.refreshTokenExpiry)
+	c.SignatureService = service.NewRequestSignatureService(c.NonceRepo, c.maxClockSkew)
+	c.RateLimitService = service.NewRateLimitService(c.RateLimitRepo, c.rateLimitConfig)
+	c.EmailService = service.NewEmailService(c.Mailer, c.I18nManager, c.emailConfig)
+	// Failed logins per IP are counted in the rate limit store
+	c.LoginAttemptService = service.NewLoginAttemptService(c.UserRepo, c.RateLimitRepo, c.loginAttemptConfig)
+	c.RoleService = service.NewRoleService(c.RoleRepo, c.UserRepo)
+	c.UserService = service.NewUserService(c.UserRepo, c.JWTService, c.RefreshTokenService, c.SessionService, c.RoleService, c.EmailService, c.LoginAttemptService, c.AuditService, c.userConfig)
+	c.UserRetentionService = service.NewUserRetentionService(c.UserRepo, c.AuditService, c.userRetentionConfig)
+	c.UserBulkService = service.NewUserBulkService(c.UserRepo, c.RoleService, c.EmailService, c.AuditService, c.userBulkConfig)
+	c.ImpersonationService = service.NewImpersonationService(c.UserRepo, c.RoleService, c.JWTService, c.AuditService, service.ImpersonationConfig{
+		TokenExpiry:  time.Duration(c.impersonationTokenExpiry) * time.Minute,
+		AdminUserIDs: c.AdminUserIDs,
+	})
+	// New modules storing personal data add their contributor here
+	c.PersonalDataService = service.NewPersonalDataService(c.UserRepo, c.AuditService,
+		service.NewProfileDataContributor(c.UserRepo),
+		service.NewRoleDataContributor(c.RoleRepo),
+		service.NewMFADataContributor(c.MFARepo),
+		service.NewSessionDataContributor(c.SessionRepo),
+		service.NewApiKeyActivityContributor(c.SessionRepo, c.ApiKeyRepo),
+		service.NewAuditDataContributor(c.AuditLogRepo),
+	)
+
+	c.MFAService, err = service.NewMFAService(c.MFARepo, c.mfaSecret, c.mfaIssuer)
+	if err != nil {
+		panic("Failed to initialize MFA: " + err.Error())
+	}
+}
+
+// initJWTKeySet loads the keys tokens are signed and verified with
+func (c *Container) initJWTKeySet() *service.JWTKeySet {
+	if c.jwtSigningMethod == "HS256" {
+		return service.NewHMACKeySet(c.jwtSecret)
+	}
+
+	keySet, err := service.LoadJWTKeySet(c.jwtSigningMethod, c.jwtSigningKeyID, c.jwtKeys)
+	if err != nil {
+		panic("Failed to load JWT keys: " + err.Error())
+	}
+	return keySet
 }
 
 // initHandlers initializes all HTTP handlers
 func (c *Container) initHandlers() {
-	c.UserHandler = handler.NewUserHandler(c.UserRepo)
-	c.AuthHandler = handler.NewAuthHandler(c.UserService, c.JWTService, c.ApiKeyService)
+	c.UserHandler = handler.NewUserHandler(c.UserService)
+	c.AuthHandler = handler.NewAuthHandler(c.UserService, c.JWTService, c.ApiKeyService, c.RefreshTokenService, c.SessionService, c.RoleService, c.MFAService, c.LoginAttemptService)
+	c.SessionHandler = handler.NewSessionHandler(c.SessionService)
+	c.ApiKeyHandler = handler.NewApiKeyHandler(c.ApiKeyService)
+	c.RoleHandler = handler.NewRoleHandler(c.RoleService)
+	c.MFAHandler = handler.NewMFAHandler(c.MFAService)
+	c.AuditLogHandler = handler.NewAuditLogHandler(c.AuditService)
+	c.PersonalDataHandler = handler.NewPersonalDataHandler(c.PersonalDataService)
+	c.UserBulkHandler = handler.NewUserBulkHandler(c.UserBulkService)
+	c.ImpersonationHandler = handler.NewImpersonationHandler(c.ImpersonationService)
+}
+
+// startBackgroundJobs starts periodic maintenance tasks
+func (c *Container) startBackgroundJobs() {
+	// Purge expired entries from the token revocation, request nonce and rate limit stores
+	if c.revocationPurgeInterval <= 0 {
+		c.revocationPurgeInterval = 10
+	}
+	go func() {
+		ticker := time.NewTicker(time.Duration(c.revocationPurgeInterval) * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := c.RevocationRepo.PurgeExpired(ctx); err != nil {
+				logger.Error("Failed to purge revoked tokens: %v", err)
+			}
+			if err := c.NonceRepo.PurgeExpired(ctx); err != nil {
+				logger.Error("Failed to purge request nonces: %v", err)
+			}
+			if err := c.RateLimitRepo.PurgeExpired(ctx); err != nil {
+				logger.Error("Failed to purge rate limit state: %v", err)
+			}
+			cancel()
+		}
+	}()
+
+	// Purge users that stayed deleted longer than the retention period
+	if c.userRetentionConfig.PurgeAfter > 0 {
+		if c.userPurgeInterval <= 0 {
+			c.userPurgeInterval = 60
+		}
+		go func() {
+			ticker := time.NewTicker(time.Duration(c.userPurgeInterval) * time.Minute)
+			defer ticker.Stop()
+			for range ticker.C {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+				if purged, err := c.UserRetentionService.PurgeDeleted(ctx); err != nil {
+					logger.Error("Failed to purge deleted users: %v", err)
+				} else if purged > 0 {
+					logger.Info("Purged %d deleted users", purged)
+				}
+				cancel()
+			}
+		}()
+	}
 }
 
 // Future: Add more dependencies here
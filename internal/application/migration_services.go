@@ -1,12 +1,16 @@
 package application
 
 import (
+	"context"
 	"fmt"
+	repositoryImpl "go-rest-api-template/internal/repository"
+	"go-rest-api-template/internal/service"
 	"go-rest-api-template/pkg/database"
 	"go-rest-api-template/pkg/migration"
 	"strconv"
 
 	gocli "github.com/budimanlai/go-cli"
+	"github.com/jmoiron/sqlx"
 )
 
 // MigrateUpService applies all pending migrations
@@ -24,6 +28,11 @@ func MigrateUpService(c *gocli.Cli) {
 		return
 	}
 
+	if err := hashPlaintextApiKeys(c, migrator); err != nil {
+		c.Log(fmt.Sprintf("API key conversion failed: %v", err))
+		return
+	}
+
 	c.Log("Migration up completed successfully!")
 }
 
@@ -129,6 +138,11 @@ func MigrateResetService(c *gocli.Cli) {
 		return
 	}
 
+	if err := hashPlaintextApiKeys(c, migrator); err != nil {
+		c.Log(fmt.Sprintf("API key conversion failed: %v", err))
+		return
+	}
+
 	c.Log("Migration reset completed successfully!")
 }
 
@@ -206,6 +220,11 @@ func MigrateFreshService(c *gocli.Cli) {
 		return
 	}
 
+	if err := hashPlaintextApiKeys(c, migrator); err != nil {
+		c.Log(fmt.Sprintf("API key conversion failed: %v", err))
+		return
+	}
+
 	c.Log("Migration fresh completed successfully!")
 }
 
@@ -241,6 +260,22 @@ func MigrateForceService(c *gocli.Cli) {
 
 // createMigrator creates a new migrator instance with database connection
 func createMigrator(c *gocli.Cli) (*migration.Migrator, error) {
+	db, err := connectDatabase(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create migrator
+	migrator, err := migration.NewMigrator(db, "./migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrator: %w", err)
+	}
+
+	return migrator, nil
+}
+
+// connectDatabase loads the configuration and connects to the configured database
+func connectDatabase(c *gocli.Cli) (*sqlx.DB, error) {
 	// Load configuration
 	c.LoadConfig()
 
@@ -256,11 +291,32 @@ func createMigrator(c *gocli.Cli) (*migration.Migrator, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Create migrator
-	migrator, err := migration.NewMigrator(db, "./migrations")
+	return db, nil
+}
+
+// hashPlaintextApiKeys converts API keys stored in plaintext (sample data, rows created
+// before hashing was introduced). It needs the configured secret, so it runs in Go after the SQL migrations.
+func hashPlaintextApiKeys(c *gocli.Cli, migrator *migration.Migrator) error {
+	secrets, err := service.NewApiKeySecretManager(apiKeySecret(c))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create migrator: %w", err)
+		return err
 	}
 
-	return migrator, nil
+	db := migrator.GetDB()
+	apiKeyService := service.NewApiKeyService(repositoryImpl.NewApiKeyRepository(db), secrets, service.NewAuditService(repositoryImpl.NewAuditLogRepository(db)))
+	converted, err := apiKeyService.HashPlaintextKeys(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if converted > 0 {
+		fmt.Printf("🔐 Hashed %d plaintext API key(s)\n", converted)
+	}
+	return nil
+}
+
+// apiKeySecret returns the secret API keys are hashed and encrypted with
+func apiKeySecret(c *gocli.Cli) string {
+	// Falls back to the JWT secret so existing configs keep working
+	return c.Config.GetStringOr("api_key.secret", c.Config.GetString("jwt.secret"))
 }
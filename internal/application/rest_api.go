@@ -6,6 +6,8 @@ import (
 	"go-rest-api-template/internal/routes"
 
 	"go-rest-api-template/pkg/database"
+	"go-rest-api-template/pkg/ipfilter"
+	"strings"
 
 	gocli "github.com/budimanlai/go-cli"
 
@@ -43,6 +45,13 @@ func RestApi(c *gocli.Cli) {
 	// Set database to app context
 	AppContext.Db = db
 
+	// Proxies allowed to report the client address in X-Forwarded-For
+	trustedProxies, err := ipfilter.Parse(strings.Join(c.Config.GetArrayString("server.trusted_proxies"), ","))
+	if err != nil {
+		c.Log(fmt.Sprintf("Invalid server.trusted_proxies: %v", err))
+		return
+	}
+
 	app := fiber.New()
 	defer func() {
 		if err := AppContext.Db.Close(); err != nil {
@@ -57,6 +66,9 @@ func RestApi(c *gocli.Cli) {
 		Format:     "${time} | :" + port + " | ${status} | ${latency} | ${ip} | ${method} | ${path} | ${error}\n${body}\n${resBody}\n\n",
 	}))
 
+	// Resolve the client address before anything checks it
+	app.Use(middleware.ClientIPMiddleware(trustedProxies))
+
 	// Add i18n middleware
 	app.Use(middleware.I18nMiddleware(middleware.I18nConfig{
 		DefaultLanguage: "en",
@@ -68,10 +80,26 @@ func RestApi(c *gocli.Cli) {
 
 	// Setup all routes using route manager
 	routeConfig := &routes.RouteConfig{
-		UserHandler:   container.UserHandler,
-		AuthHandler:   container.AuthHandler,
-		JWTService:    container.JWTService,
-		ApiKeyService: container.ApiKeyService,
+		UserHandler:             container.UserHandler,
+		AuthHandler:             container.AuthHandler,
+		SessionHandler:          container.SessionHandler,
+		ApiKeyHandler:           container.ApiKeyHandler,
+		RoleHandler:             container.RoleHandler,
+		MFAHandler:              container.MFAHandler,
+		AuditLogHandler:         container.AuditLogHandler,
+		PersonalDataHandler:     container.PersonalDataHandler,
+		UserBulkHandler:         container.UserBulkHandler,
+		ImpersonationHandler:    container.ImpersonationHandler,
+		JWTService:              container.JWTService,
+		ApiKeyService:           container.ApiKeyService,
+		SessionService:          container.SessionService,
+		RequestSignatureService: container.SignatureService,
+		RateLimitService:        container.RateLimitService,
+		RoleService:             container.RoleService,
+		MFAService:              container.MFAService,
+		AuditService:            container.AuditService,
+		AdminUserIDs:            container.AdminUserIDs,
+		RequireAdminMFA:         container.requireAdminMFA,
 		// Future: Add more handlers here
 		// ProductHandler: container.ProductHandler,
 		// OrderHandler:   container.OrderHandler,
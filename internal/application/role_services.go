@@ -0,0 +1,79 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	repositoryImpl "go-rest-api-template/internal/repository"
+	"go-rest-api-template/internal/service"
+	"strings"
+
+	gocli "github.com/budimanlai/go-cli"
+)
+
+// RoleAssignService assigns a role to a user, e.g. to bootstrap the first admin
+func RoleAssignService(c *gocli.Cli) {
+	userID := c.Args.GetInt("user_id")
+	role := c.Args.GetString("role")
+	if userID <= 0 || role == "" {
+		c.Log("User id and role are required. Example: --user_id=1 --role=admin")
+		return
+	}
+
+	roleService, err := createRoleService(c)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to create role service: %v", err))
+		return
+	}
+
+	if err := roleService.AssignRole(context.Background(), userID, role, nil); err != nil {
+		c.Log(fmt.Sprintf("Role assign failed: %v", err))
+		return
+	}
+
+	printUserRoles(c, roleService, userID)
+	c.Log("Role assigned successfully! It applies from the user's next login or token refresh.")
+}
+
+// RoleRevokeService revokes a role from a user
+func RoleRevokeService(c *gocli.Cli) {
+	userID := c.Args.GetInt("user_id")
+	role := c.Args.GetString("role")
+	if userID <= 0 || role == "" {
+		c.Log("User id and role are required. Example: --user_id=1 --role=admin")
+		return
+	}
+
+	roleService, err := createRoleService(c)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to create role service: %v", err))
+		return
+	}
+
+	if err := roleService.RevokeRole(context.Background(), userID, role); err != nil {
+		c.Log(fmt.Sprintf("Role revoke failed: %v", err))
+		return
+	}
+
+	printUserRoles(c, roleService, userID)
+	c.Log("Role revoked successfully! It applies from the user's next request.")
+}
+
+// createRoleService connects to the database and builds the role service
+func createRoleService(c *gocli.Cli) (service.RoleService, error) {
+	db, err := connectDatabase(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.NewRoleService(repositoryImpl.NewRoleRepository(db), repositoryImpl.NewUserRepository(db)), nil
+}
+
+// printUserRoles prints the roles the user holds now
+func printUserRoles(c *gocli.Cli, roleService service.RoleService, userID int) {
+	roles, err := roleService.GetUserRoles(context.Background(), userID)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to load roles: %v", err))
+		return
+	}
+	fmt.Printf("User %d roles: %s\n", userID, strings.Join(roles, ", "))
+}
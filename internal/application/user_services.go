@@ -0,0 +1,282 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/repository"
+	repositoryImpl "go-rest-api-template/internal/repository"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/i18n"
+	"go-rest-api-template/pkg/password"
+	"go-rest-api-template/pkg/query"
+	"os"
+	"strings"
+	"time"
+
+	gocli "github.com/budimanlai/go-cli"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// UserUnlockService lifts the lockout of an account after too many failed logins
+func UserUnlockService(c *gocli.Cli) {
+	userID := c.Args.GetInt("user_id")
+	if userID <= 0 {
+		c.Log("User id is required. Example: --user_id=1")
+		return
+	}
+
+	db, err := connectDatabase(c)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to connect to database: %v", err))
+		return
+	}
+
+	// Per IP counts live in the running server, only the account lock is lifted here
+	loginAttempts := service.NewLoginAttemptService(repositoryImpl.NewUserRepository(db), repositoryImpl.NewInMemoryRateLimitRepository(), loginAttemptConfig(c))
+	if err := loginAttempts.Unlock(context.Background(), userID); err != nil {
+		c.Log(fmt.Sprintf("User unlock failed: %v", err))
+		return
+	}
+
+	c.Log(fmt.Sprintf("User %d unlocked successfully!", userID))
+}
+
+// UserPurgeService purges users deleted longer ago than user_retention.purge_after_days,
+// or --days when given, like the server does periodically
+func UserPurgeService(c *gocli.Cli) {
+	config, err := userRetentionConfig(c)
+	if err != nil {
+		c.Log(fmt.Sprintf("Invalid user retention config: %v", err))
+		return
+	}
+	if days := c.Args.GetInt("days"); days > 0 {
+		config.PurgeAfter = time.Duration(days) * 24 * time.Hour
+	}
+	if config.PurgeAfter <= 0 {
+		c.Log("No retention period configured. Set user_retention.purge_after_days or pass --days=30")
+		return
+	}
+
+	db, err := connectDatabase(c)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to connect to database: %v", err))
+		return
+	}
+
+	auditService := service.NewAuditService(repositoryImpl.NewAuditLogRepository(db))
+	retention := service.NewUserRetentionService(repositoryImpl.NewUserRepository(db), auditService, config)
+	purged, err := retention.PurgeDeleted(context.Background())
+	if err != nil {
+		c.Log(fmt.Sprintf("User purge failed after %d users: %v", purged, err))
+		return
+	}
+
+	c.Log(fmt.Sprintf("%d deleted users purged (%s)", purged, config.Mode))
+}
+
+// UserImportService imports the users of a CSV or NDJSON file like POST /admin/users/import,
+// in the foreground, and prints the rows that were not imported
+func UserImportService(c *gocli.Cli) {
+	file := c.Args.GetString("file")
+	passwords := c.Args.GetString("passwords")
+	if file == "" || (passwords != constant.UserImportPasswordsHashed && passwords != constant.UserImportPasswordsInvite) {
+		c.Log("File and passwords are required. Example: --file=users.csv --passwords=hashed|invite [--format=csv|ndjson] [--dry_run=Y] [--lang=en]")
+		return
+	}
+
+	dryRun := c.Args.GetStringOr("dry_run", "N")
+	if dryRun != "Y" && dryRun != "N" {
+		c.Log(fmt.Sprintf("Invalid dry_run value: %s (use Y or N)", dryRun))
+		return
+	}
+
+	in, err := os.Open(file)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to open import file: %v", err))
+		return
+	}
+	defer in.Close()
+
+	bulkService, err := createUserBulkService(c)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to create user bulk service: %v", err))
+		return
+	}
+
+	report, err := bulkService.Import(context.Background(), in, service.UserImportOptions{
+		Format:    c.Args.GetStringOr("format", service.UserFileFormat(file)),
+		Passwords: passwords,
+		DryRun:    dryRun == "Y",
+		Lang:      c.Args.GetStringOr("lang", "en"),
+	})
+	if report != nil {
+		printUserImportReport(report)
+	}
+	if err != nil {
+		c.Log(fmt.Sprintf("User import failed: %v", err))
+		return
+	}
+
+	if report.DryRun {
+		c.Log(fmt.Sprintf("Dry run: %d of %d users can be imported", report.Created, report.Rows))
+		return
+	}
+	c.Log(fmt.Sprintf("%d of %d users imported", report.Created, report.Rows))
+}
+
+// UserExportService writes the users to a CSV or NDJSON file, filtered like GET /users
+func UserExportService(c *gocli.Cli) {
+	file := c.Args.GetString("file")
+	if file == "" {
+		c.Log("File is required. Example: --file=users.csv [--format=csv|ndjson] [--status=active] [--search=...] [--created_from=2024-01-01] [--created_to=2024-12-31]")
+		return
+	}
+
+	format := c.Args.GetStringOr("format", service.UserFileFormat(file))
+	if format != constant.UserFileFormatCSV && format != constant.UserFileFormatNDJSON {
+		c.Log(fmt.Sprintf("Unknown export format %q, use --format=csv or --format=ndjson", format))
+		return
+	}
+
+	filter := repository.UserFilter{
+		Status: c.Args.GetString("status"),
+		Search: c.Args.GetString("search"),
+	}
+	var err error
+	if filter.CreatedFrom, err = query.ParseTime(c.Args.GetString("created_from"), false); err != nil {
+		c.Log(fmt.Sprintf("Invalid created_from: %v", err))
+		return
+	}
+	if filter.CreatedTo, err = query.ParseTime(c.Args.GetString("created_to"), true); err != nil {
+		c.Log(fmt.Sprintf("Invalid created_to: %v", err))
+		return
+	}
+
+	bulkService, err := createUserBulkService(c)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to create user bulk service: %v", err))
+		return
+	}
+
+	out, err := os.Create(file)
+	if err != nil {
+		c.Log(fmt.Sprintf("Failed to create export file: %v", err))
+		return
+	}
+	defer out.Close()
+
+	exported, err := bulkService.Export(context.Background(), out, format, filter)
+	if err != nil {
+		c.Log(fmt.Sprintf("User export failed after %d users: %v", exported, err))
+		return
+	}
+
+	c.Log(fmt.Sprintf("%d users exported to %s", exported, file))
+}
+
+// createUserBulkService creates a bulk user service connected to the configured database and mailer
+func createUserBulkService(c *gocli.Cli) (service.UserBulkService, error) {
+	db, err := connectDatabase(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Invitations are mailed like by the server, translated and through mail.driver
+	i18nManager, err := i18n.NewManager(i18nConfig)
+	if err != nil {
+		return nil, err
+	}
+	mail, err := newMailer(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Imported users are audited without an actor, i.e. as created by the system
+	auditService := service.NewAuditService(repositoryImpl.NewAuditLogRepository(db))
+	emailService := service.NewEmailService(mail, i18nManager, emailConfig(c))
+	userRepo := repositoryImpl.NewUserRepository(db)
+	roleService := service.NewRoleService(repositoryImpl.NewRoleRepository(db), userRepo)
+	return service.NewUserBulkService(userRepo, roleService, emailService, auditService, userBulkConfig(c)), nil
+}
+
+// printUserImportReport prints why rows were not imported
+func printUserImportReport(report *service.UserImportReport) {
+	for _, rowErr := range report.Errors {
+		messages := make([]string, len(rowErr.Errors))
+		for i, e := range rowErr.Errors {
+			messages[i] = e.Message
+		}
+		fmt.Printf("❌ Line %d %s: %s\n", rowErr.Line, rowErr.Username, strings.Join(messages, "; "))
+	}
+}
+
+// userBulkConfig reads the settings of bulk user imports and exports (user_bulk.*)
+func userBulkConfig(c *gocli.Cli) service.UserBulkConfig {
+	return service.UserBulkConfig{
+		InvitationExpiry: time.Duration(c.Config.GetIntOr("user_bulk.invitation_expiry_hours", 72)) * time.Hour,
+		JobRetention:     time.Duration(c.Config.GetIntOr("user_bulk.job_retention_hours", 24)) * time.Hour,
+	}
+}
+
+// userRetentionConfig reads how long soft-deleted users are kept and how they are purged (user_retention.*)
+func userRetentionConfig(c *gocli.Cli) (service.UserRetentionConfig, error) {
+	mode := c.Config.GetStringOr("user_retention.purge_mode", constant.UserPurgeModeAnonymize)
+	if mode != constant.UserPurgeModeAnonymize && mode != constant.UserPurgeModeDelete {
+		return service.UserRetentionConfig{}, fmt.Errorf("unknown purge mode %q", mode)
+	}
+
+	return service.UserRetentionConfig{
+		PurgeAfter: time.Duration(c.Config.GetIntOr("user_retention.purge_after_days", 0)) * 24 * time.Hour,
+		Mode:       mode,
+	}, nil
+}
+
+// loginAttemptConfig reads the brute-force protection settings of login
+func loginAttemptConfig(c *gocli.Cli) service.LoginAttemptConfig {
+	return service.LoginAttemptConfig{
+		MaxAttempts:     c.Config.GetIntOr("login.max_attempts", 5),
+		LockoutDuration: time.Duration(c.Config.GetIntOr("login.lockout_minutes", 15)) * time.Minute,
+		MaxIPAttempts:   c.Config.GetIntOr("login.max_ip_attempts", 50),
+		IPWindow:        time.Duration(c.Config.GetIntOr("login.ip_window_minutes", 15)) * time.Minute,
+		BaseDelay:       time.Duration(c.Config.GetIntOr("login.base_delay_ms", 250)) * time.Millisecond,
+		MaxDelay:        time.Duration(c.Config.GetIntOr("login.max_delay_ms", 4000)) * time.Millisecond,
+	}
+}
+
+// passwordPolicy reads the password rules (password.*), including the denylist file
+func passwordPolicy(c *gocli.Cli) (*password.Policy, error) {
+	defaults := password.DefaultPolicy()
+	policy := &password.Policy{
+		MinLength:        c.Config.GetIntOr("password.min_length", defaults.MinLength),
+		MaxLength:        c.Config.GetIntOr("password.max_length", defaults.MaxLength),
+		RequireUppercase: c.Config.GetBoolOr("password.require_uppercase", false),
+		RequireLowercase: c.Config.GetBoolOr("password.require_lowercase", false),
+		RequireDigit:     c.Config.GetBoolOr("password.require_digit", false),
+		RequireSymbol:    c.Config.GetBoolOr("password.require_symbol", false),
+		HistorySize:      c.Config.GetIntOr("password.history_size", 5),
+	}
+
+	if file := c.Config.GetString("password.denylist_file"); file != "" {
+		if err := policy.LoadDenylist(file); err != nil {
+			return nil, err
+		}
+	}
+	return policy, nil
+}
+
+// passwordHasher reads the algorithm and cost of new password hashes (password.hasher)
+func passwordHasher(c *gocli.Cli) (password.Hasher, error) {
+	switch algorithm := c.Config.GetStringOr("password.hasher", password.AlgorithmBcrypt); algorithm {
+	case password.AlgorithmBcrypt:
+		return password.NewBcryptHasher(c.Config.GetIntOr("password.bcrypt_cost", bcrypt.DefaultCost))
+	case password.AlgorithmArgon2id:
+		return password.NewArgon2idHasher(
+			uint32(c.Config.GetIntOr("password.argon2id.memory_kib", 64*1024)),
+			uint32(c.Config.GetIntOr("password.argon2id.iterations", 3)),
+			uint8(c.Config.GetIntOr("password.argon2id.parallelism", 2)),
+		)
+	default:
+		return nil, fmt.Errorf("unknown password hasher %q", algorithm)
+	}
+}
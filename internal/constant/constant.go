@@ -2,7 +2,89 @@ package constant
 
 // User Status Constants
 const (
-	UserStatusActive = "active"
+	UserStatusActive  = "active"
+	UserStatusPending = "pending" // registered, email not verified yet
+)
+
+// API Key Scopes
+const (
+	ApiKeyScopeAll          = "*" // grants every scope
+	ApiKeyScopeAuthRegister = "auth:register"
+	ApiKeyScopeAuthLogin    = "auth:login"
+	ApiKeyScopeUsersRead    = "users:read"
+	ApiKeyScopeUsersWrite   = "users:write"
+)
+
+// Purge modes of soft-deleted users (user_retention.purge_mode)
+const (
+	UserPurgeModeAnonymize = "anonymize" // keep the row without personal data
+	UserPurgeModeDelete    = "delete"
+)
+
+// Formats of bulk user imports and exports
+const (
+	UserFileFormatCSV    = "csv"
+	UserFileFormatNDJSON = "ndjson" // one JSON object per line
+)
+
+// Passwords of imported users
+const (
+	UserImportPasswordsHashed = "hashed" // bcrypt or argon2id hashes in the file
+	UserImportPasswordsInvite = "invite" // an invitation email lets the user choose one
+)
+
+// Bulk user jobs
+const (
+	UserJobTypeImport = "import"
+	UserJobTypeExport = "export"
+
+	UserJobStatusRunning   = "running"
+	UserJobStatusCompleted = "completed"
+	UserJobStatusFailed    = "failed"
+)
+
+// Roles
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user" // assigned to every registered user
+)
+
+// Permissions granted through roles
+const (
+	PermissionUsersRead   = "users:read"
+	PermissionUsersUpdate = "users:update"
+	PermissionUsersDelete = "users:delete"
+	PermissionUsersManage = "users:manage" // act on users other than yourself
+)
+
+// Audit log actions
+const (
+	AuditActionLoginSucceeded  = "auth.login_succeeded"
+	AuditActionLoginFailed     = "auth.login_failed"
+	AuditActionUserCreated     = "user.created"
+	AuditActionUserUpdated     = "user.updated"
+	AuditActionUserDeleted     = "user.deleted"
+	AuditActionUserRestored    = "user.restored"
+	AuditActionUserPurged      = "user.purged"
+	AuditActionUserErased      = "user.erased"
+	AuditActionImpersonated    = "user.impersonated"         // an admin obtained a token of the user
+	AuditActionImpersonatedReq = "user.impersonated_request" // a request made with that token
+	AuditActionProfileUpdated  = "user.profile_updated"
+	AuditActionEmailVerified   = "user.email_verified"
+	AuditActionEmailChanged    = "user.email_changed"
+	AuditActionPasswordChanged = "user.password_changed"
+	AuditActionPasswordReset   = "user.password_reset"
+	AuditActionApiKeyCreated   = "api_key.created"
+	AuditActionApiKeyUpdated   = "api_key.updated"
+	AuditActionApiKeyRotated   = "api_key.rotated"
+	AuditActionApiKeyRevoked   = "api_key.revoked"
+	AuditActionApiKeyUsed      = "api_key.used"
+)
+
+// Audit log entity types
+const (
+	AuditEntityUser   = "user"
+	AuditEntityApiKey = "api_key"
 )
 
 // Default Values
@@ -1,20 +1,40 @@
 package entity
 
 import (
+	"go-rest-api-template/pkg/ipfilter"
+	"strings"
 	"time"
 )
 
 // ApiKey represents an API key entity (read-only for JWT middleware integration)
 type ApiKey struct {
-	ID          int        `json:"id"`
-	Name        string     `json:"name"`
-	Description *string    `json:"description"`
-	ApiKey      string     `json:"api_key"`
-	AuthKey     string     `json:"auth_key"`
-	Status      string     `json:"status"`
-	H2H         string     `json:"h2h"`
+	ID               int     `json:"id"`
+	Name             string  `json:"name"`
+	Description      *string `json:"description"`
+	ApiKey           string  `json:"-"` // plaintext, only set right after creation
+	ApiKeyPrefix     string  `json:"api_key_prefix"`
+	ApiKeyHash       string  `json:"-"`
+	AuthKey          string  `json:"-"` // plaintext, set after creation or decryption
+	AuthKeyEncrypted string  `json:"-"`
+
+	// Secrets replaced by the last rotation, valid until PreviousKeyExpiresAt
+	PreviousApiKeyPrefix     string     `json:"-"`
+	PreviousApiKeyHash       string     `json:"-"`
+	PreviousAuthKeyEncrypted string     `json:"-"`
+	PreviousKeyExpiresAt     *time.Time `json:"previous_key_expires_at"`
+
+	Status string   `json:"status"`
+	H2H    string   `json:"h2h"`
+	Scopes []string `json:"scopes"`
+
+	// Rate limits, 0 falls back to the configured defaults (DailyQuota 0 means unlimited)
+	RateLimitPerSecond int `json:"rate_limit_per_second"`
+	RateLimitBurst     int `json:"rate_limit_burst"`
+	DailyQuota         int `json:"daily_quota"`
+
 	LastAccess  *time.Time `json:"last_access"`
-	IPWhitelist *string    `json:"ip_whitelist"`
+	IPWhitelist *string    `json:"ip_whitelist"` // comma separated addresses and CIDR ranges
+	IPDenylist  *string    `json:"ip_denylist"`
 	CreatedAt   *time.Time `json:"created_at"`
 	CreatedBy   int        `json:"created_by"`
 	UpdatedAt   *time.Time `json:"updated_at"`
@@ -26,18 +46,58 @@ func (a *ApiKey) IsActive() bool {
 	return a.Status == "active"
 }
 
+// IsRevoked checks if the API key has been revoked for good
+func (a *ApiKey) IsRevoked() bool {
+	return a.Status == "revoked"
+}
+
+// IsPreviousKeyValid checks if the secrets replaced by the last rotation are still accepted
+func (a *ApiKey) IsPreviousKeyValid() bool {
+	return a.PreviousApiKeyHash != "" && a.PreviousKeyExpiresAt != nil && time.Now().Before(*a.PreviousKeyExpiresAt)
+}
+
+// HasScope checks if the API key was granted the given scope
+func (a *ApiKey) HasScope(scope string) bool {
+	for _, granted := range a.Scopes {
+		if granted == "*" || granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
 // IsH2HEnabled checks if H2H (Host-to-Host) is enabled
 func (a *ApiKey) IsH2HEnabled() bool {
 	return a.H2H == "Y"
 }
 
-// IsIPWhitelisted checks if the given IP is whitelisted
+// IsIPWhitelisted checks if the given IP matches an address or CIDR range of the whitelist
 func (a *ApiKey) IsIPWhitelisted(ip string) bool {
-	if a.IPWhitelist == nil || *a.IPWhitelist == "" {
+	if a.IPWhitelist == nil || strings.TrimSpace(*a.IPWhitelist) == "" {
 		return true // No whitelist means all IPs are allowed
 	}
-	// Simple implementation - in production you might want more sophisticated IP matching
-	return *a.IPWhitelist == ip
+	whitelist, err := ipfilter.ParseCached(*a.IPWhitelist)
+	if err != nil {
+		return false // A broken whitelist must not open the key to everyone
+	}
+	return whitelist.Contains(ip)
+}
+
+// IsIPDenied checks if the given IP matches an address or CIDR range of the denylist
+func (a *ApiKey) IsIPDenied(ip string) bool {
+	if a.IPDenylist == nil || strings.TrimSpace(*a.IPDenylist) == "" {
+		return false
+	}
+	denylist, err := ipfilter.ParseCached(*a.IPDenylist)
+	if err != nil {
+		return true
+	}
+	return denylist.Contains(ip)
+}
+
+// IsIPAllowed checks the IP against both lists, the denylist wins
+func (a *ApiKey) IsIPAllowed(ip string) bool {
+	return !a.IsIPDenied(ip) && a.IsIPWhitelisted(ip)
 }
 
 // UpdateLastAccess updates the last access time (for logging purposes)
@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+)
+
+// AuditLog records who did what to which entity. Before and After hold
+// only the fields that changed, Details extra context like a failure reason.
+type AuditLog struct {
+	ID          int                    `json:"id"`
+	ActorUserID *int                   `json:"actor_user_id"` // nil for anonymous requests and the system
+	ApiKeyID    *int                   `json:"api_key_id"`
+	IP          string                 `json:"ip"`
+	Action      string                 `json:"action"`
+	EntityType  string                 `json:"entity_type"`
+	EntityID    *int                   `json:"entity_id"`
+	Before      map[string]interface{} `json:"before,omitempty"`
+	After       map[string]interface{} `json:"after,omitempty"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
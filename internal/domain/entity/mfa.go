@@ -0,0 +1,21 @@
+package entity
+
+import (
+	"time"
+)
+
+// UserMFA holds the TOTP second factor of a user.
+// The secret is stored encrypted, the service decrypts it to check codes.
+type UserMFA struct {
+	UserID       int        `json:"user_id"`
+	Secret       string     `json:"-"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty"`
+	LastUsedStep int64      `json:"-"` // time step of the last accepted code, codes are single use
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// IsEnabled checks if the enrollment was confirmed with a valid code
+func (m *UserMFA) IsEnabled() bool {
+	return m.EnabledAt != nil
+}
@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+// RateLimitStatus is the state of a token bucket after a request tried to take a token
+type RateLimitStatus struct {
+	Allowed    bool
+	Remaining  int           // tokens left in the bucket
+	RetryAfter time.Duration // until the next token is available, only set when not allowed
+	ResetAfter time.Duration // until the bucket is full again
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"time"
+)
+
+// RefreshToken represents a server-stored, opaque refresh token.
+// Only the SHA-256 hash of the token is persisted; the plain value is
+// returned to the client once when the token is issued.
+type RefreshToken struct {
+	ID           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	ApiKeyID     int        `json:"api_key_id"`
+	FamilyID     string     `json:"family_id"`
+	SessionToken string     `json:"-"`
+	TokenHash    string     `json:"-"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RotatedAt    *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy   *int       `json:"replaced_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// IsExpired checks if the refresh token has passed its expiry time
+func (r *RefreshToken) IsExpired() bool {
+	return time.Now().After(r.ExpiresAt)
+}
+
+// IsRevoked checks if the refresh token (or its family) has been revoked
+func (r *RefreshToken) IsRevoked() bool {
+	return r.RevokedAt != nil
+}
+
+// IsRotated checks if the refresh token has already been exchanged for a new one
+func (r *RefreshToken) IsRotated() bool {
+	return r.RotatedAt != nil
+}
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+)
+
+// Role is a named set of permissions that can be assigned to users
+type Role struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description,omitempty"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PermissionSet holds the permissions granted to a user through their roles
+type PermissionSet map[string]bool
+
+// NewPermissionSet builds a permission set from a list of permission names
+func NewPermissionSet(permissions []string) PermissionSet {
+	set := make(PermissionSet, len(permissions))
+	for _, permission := range permissions {
+		set[permission] = true
+	}
+	return set
+}
+
+// Has checks if the permission was granted
+func (p PermissionSet) Has(permission string) bool {
+	return p[permission]
+}
@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+)
+
+// Session represents a login session of a user on one device/API key
+type Session struct {
+	ID           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	ApiKeyID     int        `json:"api_key_id"`
+	SessionToken string     `json:"-"`
+	IPAddress    string     `json:"ip_address"`
+	UserAgent    string     `json:"user_agent"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	LastSeenAt   *time.Time `json:"last_seen_at,omitempty"`
+	TerminatedAt *time.Time `json:"terminated_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+// IsActive checks if the session is neither terminated nor expired
+func (s *Session) IsActive() bool {
+	return s.TerminatedAt == nil && time.Now().Before(s.ExpiresAt)
+}
@@ -2,29 +2,40 @@ package entity
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/hex"
 	"errors"
+	"go-rest-api-template/pkg/password"
 	"time"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 // User represents a user entity
 type User struct {
-	ID                 int        `json:"id"`
-	Username           string     `json:"username"`
-	AuthKey            string     `json:"-"`
-	Email              string     `json:"email"`
-	PasswordHash       string     `json:"-"`
-	PasswordResetToken *string    `json:"-"`
-	Status             string     `json:"status"`
-	VerificationToken  *string    `json:"-"`
-	CreatedAt          time.Time  `json:"created_at"`
-	CreatedBy          *int       `json:"created_by,omitempty"`
-	UpdatedAt          *time.Time `json:"updated_at,omitempty"`
-	UpdatedBy          *int       `json:"updated_by,omitempty"`
-	DeletedAt          *time.Time `json:"deleted_at,omitempty"`
-	DeletedBy          *int       `json:"deleted_by,omitempty"`
+	ID                     int        `json:"id"`
+	Username               string     `json:"username"`
+	AuthKey                string     `json:"-"`
+	Email                  string     `json:"email"`
+	PendingEmail           *string    `json:"pending_email,omitempty"` // new address, until it is verified
+	FullName               string     `json:"full_name"`
+	Phone                  string     `json:"phone"`
+	Locale                 string     `json:"locale"`
+	Timezone               string     `json:"timezone"`
+	PasswordHash           string     `json:"-"`
+	PasswordResetToken     *string    `json:"-"` // SHA-256 of the reset token
+	PasswordResetExpiresAt *time.Time `json:"-"`
+	Status                 string     `json:"status"`
+	FailedLoginAttempts    int        `json:"-"`
+	LockedUntil            *time.Time `json:"locked_until,omitempty"`
+	VerificationToken      *string    `json:"-"`
+	CreatedAt              time.Time  `json:"created_at"`
+	CreatedBy              *int       `json:"created_by,omitempty"`
+	UpdatedAt              *time.Time `json:"updated_at,omitempty"`
+	UpdatedBy              *int       `json:"updated_by,omitempty"`
+	DeletedAt              *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy              *int       `json:"deleted_by,omitempty"`
+
+	// Roles are loaded separately and carried in the private token
+	Roles []string `json:"roles,omitempty"`
 }
 
 // Business validation rules
@@ -35,31 +46,33 @@ func (u *User) ValidateForCreate() error {
 	return nil
 }
 
-// ValidatePassword validates password before hashing
-func (u *User) ValidatePassword(password string) error {
-	if len(password) < 6 {
-		return errors.New("password must be at least 6 characters")
-	}
-	return nil
+// ValidatePassword checks a password against the password policy of the application,
+// returning a *password.PolicyError with the broken rules
+func (u *User) ValidatePassword(plain string) error {
+	return password.Current().Check(plain)
 }
 
-// Hash password using bcrypt
-func (u *User) HashPassword(password string) error {
-	if err := u.ValidatePassword(password); err != nil {
+// Hash password with the configured hasher (bcrypt or argon2id)
+func (u *User) HashPassword(plain string) error {
+	if err := u.ValidatePassword(plain); err != nil {
 		return err
 	}
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := password.CurrentHasher().Hash(plain)
 	if err != nil {
 		return err
 	}
-	u.PasswordHash = string(hashedPassword)
+	u.PasswordHash = hashedPassword
 	return nil
 }
 
-// Check if password matches
-func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password))
-	return err == nil
+// Check if password matches, whichever supported algorithm made the hash
+func (u *User) CheckPassword(plain string) bool {
+	return password.Verify(u.PasswordHash, plain)
+}
+
+// PasswordNeedsRehash checks if the hash was made with another algorithm or cost than configured
+func (u *User) PasswordNeedsRehash() bool {
+	return password.CurrentHasher().NeedsRehash(u.PasswordHash)
 }
 
 // Check if user is active
@@ -67,6 +80,45 @@ func (u *User) IsActive() bool {
 	return u.Status == "active"
 }
 
+// IsPending checks if the user still has to verify their email address
+func (u *User) IsPending() bool {
+	return u.Status == "pending"
+}
+
+// IsBanned checks if the user was banned by an admin
+func (u *User) IsBanned() bool {
+	return u.Status == "banned"
+}
+
+// IsLocked checks if the user is locked out after too many failed logins.
+// The lock ends by itself once LockedUntil has passed.
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && time.Now().Before(*u.LockedUntil)
+}
+
+// RegisterFailedLogin counts a failed login and locks the user for lockout once
+// maxAttempts is reached. It reports whether the user is locked now.
+func (u *User) RegisterFailedLogin(maxAttempts int, lockout time.Duration) bool {
+	// Counting starts over once a lock has ended
+	if u.LockedUntil != nil && !u.IsLocked() {
+		u.ResetFailedLogins()
+	}
+
+	u.FailedLoginAttempts++
+	if maxAttempts > 0 && u.FailedLoginAttempts >= maxAttempts {
+		lockedUntil := time.Now().Add(lockout)
+		u.LockedUntil = &lockedUntil
+		return true
+	}
+	return false
+}
+
+// ResetFailedLogins clears the failed login count and lock, after a successful login or an admin unlock
+func (u *User) ResetFailedLogins() {
+	u.FailedLoginAttempts = 0
+	u.LockedUntil = nil
+}
+
 // GenerateVerificationToken creates a new verification token
 func (u *User) GenerateVerificationToken() error {
 	// Generate 32 random bytes
@@ -96,6 +148,39 @@ func (u *User) ClearVerificationToken() {
 	u.VerificationToken = nil
 }
 
+// SetPasswordResetToken stores the hash of a new reset token and when it expires
+func (u *User) SetPasswordResetToken(tokenHash string, expiresAt time.Time) {
+	u.PasswordResetToken = &tokenHash
+	u.PasswordResetExpiresAt = &expiresAt
+}
+
+// IsPasswordResetTokenValid checks the hash of a reset token against the stored one and its expiry
+func (u *User) IsPasswordResetTokenValid(tokenHash string) bool {
+	if u.PasswordResetToken == nil || u.PasswordResetExpiresAt == nil {
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(*u.PasswordResetToken), []byte(tokenHash)) != 1 {
+		return false
+	}
+	return time.Now().Before(*u.PasswordResetExpiresAt)
+}
+
+// ClearPasswordResetToken invalidates the reset token, after use or a password change
+func (u *User) ClearPasswordResetToken() {
+	u.PasswordResetToken = nil
+	u.PasswordResetExpiresAt = nil
+}
+
+// HasRole checks if the user was assigned the role
+func (u *User) HasRole(role string) bool {
+	for _, r := range u.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 // SetAuditFields sets the audit fields for create/update operations
 func (u *User) SetCreatedBy(userID int) {
 	u.CreatedBy = &userID
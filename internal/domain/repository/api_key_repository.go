@@ -3,15 +3,27 @@ package repository
 import (
 	"context"
 	"go-rest-api-template/internal/domain/entity"
+	"time"
 )
 
-// ApiKeyRepository defines the interface for API key data operations (read-only)
+// ApiKeyRepository defines the interface for API key data operations
 type ApiKeyRepository interface {
-	// Core read operations for JWT middleware
-	GetByApiKey(ctx context.Context, apiKey string) (*entity.ApiKey, error)
-	GetByAuthKey(ctx context.Context, authKey string) (*entity.ApiKey, error)
+	// Core read operations for JWT middleware.
+	// Keys are stored hashed, so lookup returns every candidate sharing the prefix.
+	GetByPrefix(ctx context.Context, prefix string) ([]*entity.ApiKey, error)
 	GetByID(ctx context.Context, id int) (*entity.ApiKey, error)
 
+	// Write operations for key management
+	Create(ctx context.Context, apiKey *entity.ApiKey) error
+	Update(ctx context.Context, apiKey *entity.ApiKey) error
+	// Rotate stores new secrets and keeps the current ones valid until previousExpiresAt
+	Rotate(ctx context.Context, apiKey *entity.ApiKey, previousExpiresAt time.Time) error
+	Revoke(ctx context.Context, id int, updatedBy *int) error
+
+	// Conversion of keys stored before hashing was introduced
+	GetWithPlaintextSecrets(ctx context.Context) ([]*entity.ApiKey, error)
+	UpdateSecrets(ctx context.Context, apiKey *entity.ApiKey) error
+
 	// List operations for admin purposes
 	GetAll(ctx context.Context, limit, offset int) ([]*entity.ApiKey, error)
 	GetByStatus(ctx context.Context, status string, limit, offset int) ([]*entity.ApiKey, error)
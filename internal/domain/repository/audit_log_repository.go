@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/pkg/query"
+	"time"
+)
+
+// AuditLogFilter narrows down audit log lists, empty fields match every entry
+type AuditLogFilter struct {
+	ActorUserID int
+	ApiKeyID    int
+	Action      string
+	EntityType  string
+	EntityID    int
+	IP          string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// AuditLogRepository defines the interface for audit log data operations.
+// Entries are only ever added, and only changed to erase personal data.
+type AuditLogRepository interface {
+	Create(ctx context.Context, log *entity.AuditLog) error
+	GetAll(ctx context.Context, filter AuditLogFilter, opts query.Options) ([]*entity.AuditLog, error)
+	GetCount(ctx context.Context, filter AuditLogFilter) (int, error)
+
+	// GetByUser returns the entries of actions by or on the user, oldest first
+	GetByUser(ctx context.Context, userID int) ([]*entity.AuditLog, error)
+	// EraseUserData blanks the IP of actions by the user and the states and details of
+	// actions on the user. The entries and their user ids stay.
+	EraseUserData(ctx context.Context, userID int) error
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+)
+
+// MFARepository defines the interface for two-factor authentication data operations
+type MFARepository interface {
+	// GetByUserID returns nil when the user never enrolled
+	GetByUserID(ctx context.Context, userID int) (*entity.UserMFA, error)
+
+	// Save creates or replaces the enrollment of a user
+	Save(ctx context.Context, mfa *entity.UserMFA) error
+
+	// UpdateLastUsedStep records the time step of an accepted code. It returns false
+	// when that step or a later one was already used, e.g. by a concurrent request.
+	UpdateLastUsedStep(ctx context.Context, userID int, step int64) (bool, error)
+
+	// Delete removes the enrollment and the recovery codes of a user
+	Delete(ctx context.Context, userID int) error
+
+	// ReplaceRecoveryCodes drops the recovery codes of a user and stores the given hashes
+	ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error
+
+	// UseRecoveryCode marks an unused recovery code as used. It returns false for
+	// unknown and already used codes.
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"time"
+)
+
+// RateLimitRepository holds rate limiter state. The in-memory implementation serves a single
+// node; deployments with several nodes need a shared implementation (e.g. Redis).
+type RateLimitRepository interface {
+	// Take takes one token from the bucket identified by key.
+	// The bucket holds up to burst tokens and is refilled with rate tokens per second.
+	Take(ctx context.Context, key string, rate float64, burst int) (*entity.RateLimitStatus, error)
+
+	// Increment counts a request in the fixed window identified by key and returns the new count.
+	// The count is dropped once windowEnd has passed.
+	Increment(ctx context.Context, key string, windowEnd time.Time) (int, error)
+
+	// Count returns the count of the fixed window identified by key, 0 once the window has ended
+	Count(ctx context.Context, key string) (int, error)
+
+	// PurgeExpired removes full buckets and ended windows
+	PurgeExpired(ctx context.Context) error
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+)
+
+// RefreshTokenRepository defines the interface for refresh token data operations
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *entity.RefreshToken) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+
+	// MarkRotated marks the token as exchanged. It returns false when the token
+	// was already rotated or revoked by a concurrent request.
+	MarkRotated(ctx context.Context, id int, replacedBy int) (bool, error)
+
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeByUserID(ctx context.Context, userID int) error
+	RevokeBySessionToken(ctx context.Context, sessionToken string) error
+}
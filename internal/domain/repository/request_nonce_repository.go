@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// RequestNonceRepository remembers nonces of signed H2H requests to detect replays
+type RequestNonceRepository interface {
+	// Use records the nonce for the API key. It returns false when the nonce was already used.
+	Use(ctx context.Context, apiKeyID int, nonce string, expiresAt time.Time) (bool, error)
+
+	// PurgeExpired removes nonces older than the accepted timestamp window
+	PurgeExpired(ctx context.Context) error
+}
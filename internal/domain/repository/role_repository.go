@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+)
+
+// RoleRepository defines the interface for roles, their permissions and user assignments
+type RoleRepository interface {
+	GetAll(ctx context.Context) ([]*entity.Role, error)
+	GetByName(ctx context.Context, name string) (*entity.Role, error)
+
+	// GetUserRoles returns the names of the roles assigned to the user
+	GetUserRoles(ctx context.Context, userID int) ([]string, error)
+	AssignRole(ctx context.Context, userID, roleID int, createdBy *int) error
+	RevokeRole(ctx context.Context, userID, roleID int) error
+
+	// GetPermissions returns the permissions granted by the given roles
+	GetPermissions(ctx context.Context, roles []string) ([]string, error)
+}
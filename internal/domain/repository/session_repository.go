@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"time"
+)
+
+// SessionRepository defines the interface for user session data operations
+type SessionRepository interface {
+	Create(ctx context.Context, session *entity.Session) error
+	GetByID(ctx context.Context, id int) (*entity.Session, error)
+	GetBySessionToken(ctx context.Context, sessionToken string) (*entity.Session, error)
+	GetActiveByUserID(ctx context.Context, userID int) ([]*entity.Session, error)
+	// GetByUserID returns every session of the user, terminated and expired ones included, newest first
+	GetByUserID(ctx context.Context, userID int) ([]*entity.Session, error)
+
+	// Touch updates last seen time (throttled by the implementation)
+	Touch(ctx context.Context, id int) error
+	Extend(ctx context.Context, id int, expiresAt time.Time) error
+
+	Terminate(ctx context.Context, id int) error
+	TerminateByUserID(ctx context.Context, userID int) error
+}
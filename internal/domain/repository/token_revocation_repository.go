@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// TokenRevocationRepository stores revoked JWTs until they would have expired anyway
+type TokenRevocationRepository interface {
+	// RevokeToken revokes a single token by its jti claim
+	RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error
+
+	// RevokeUserTokens revokes every token issued to the user before revokedBefore.
+	// The entry is kept until expiresAt, when all those tokens have expired.
+	RevokeUserTokens(ctx context.Context, userID int, revokedBefore, expiresAt time.Time) error
+
+	// IsRevoked checks the token's jti and the user's revocation cut-off
+	IsRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error)
+
+	// PurgeExpired removes entries whose tokens can no longer be used
+	PurgeExpired(ctx context.Context) error
+}
@@ -3,8 +3,19 @@ package repository
 import (
 	"context"
 	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/pkg/query"
+	"time"
 )
 
+// UserFilter narrows down user lists, empty fields match every user
+type UserFilter struct {
+	Status      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Search      string // substring of the username or email
+	Deleted     bool   // soft-deleted users that can still be restored, instead of the live ones
+}
+
 // UserRepository defines the interface for user data operations
 type UserRepository interface {
 	Create(ctx context.Context, user *entity.User) error
@@ -12,9 +23,41 @@ type UserRepository interface {
 	GetByEmail(ctx context.Context, email string) (*entity.User, error)
 	GetByUsername(ctx context.Context, username string) (*entity.User, error)
 	Update(ctx context.Context, user *entity.User) error
-	Delete(ctx context.Context, id int) error
-	GetAll(ctx context.Context, limit, offset int) ([]*entity.User, error)
-	GetCount(ctx context.Context) (int, error)
+	Delete(ctx context.Context, id int, deletedBy int) error // deletedBy is constant.DefaultUpdatedBy for the system
+	GetAll(ctx context.Context, filter UserFilter, opts query.Options) ([]*entity.User, error)
+	GetCount(ctx context.Context, filter UserFilter) (int, error)
 	GetByVerificationToken(ctx context.Context, token string) (*entity.User, error)
 	UpdateVerificationToken(ctx context.Context, user *entity.User) error
+
+	// Password reset tokens are looked up by their SHA-256 hash
+	GetByPasswordResetToken(ctx context.Context, tokenHash string) (*entity.User, error)
+	UpdatePasswordResetToken(ctx context.Context, user *entity.User) error
+
+	// UpdateLoginAttempts stores the failed login count and lock of the user
+	UpdateLoginAttempts(ctx context.Context, user *entity.User) error
+
+	// IncrementFailedLogins counts a failed login in a single update, so concurrent failures
+	// are all counted, and locks the user once maxAttempts is reached. The stored count and
+	// lock are read back into user.
+	IncrementFailedLogins(ctx context.Context, user *entity.User, maxAttempts int, lockout time.Duration) error
+
+	// UpdatePasswordHash stores a new hash of the same password, after a rehash on login
+	UpdatePasswordHash(ctx context.Context, user *entity.User) error
+
+	// Password history: GetPasswordHistory returns the newest hashes first,
+	// AddPasswordHistory stores a hash and drops all but the newest keep hashes
+	GetPasswordHistory(ctx context.Context, userID, limit int) ([]string, error)
+	AddPasswordHistory(ctx context.Context, userID int, passwordHash string, keep int) error
+
+	// GetDeletedByID returns a soft-deleted user that was not anonymized by a purge yet
+	GetDeletedByID(ctx context.Context, id int) (*entity.User, error)
+	// Restore undoes the soft delete of a user, restoredBy becomes its updated_by
+	Restore(ctx context.Context, id int, restoredBy int) error
+
+	// GetPurgeable returns the ids of users deleted before deletedBefore and not purged yet, oldest first
+	GetPurgeable(ctx context.Context, deletedBefore time.Time, limit int) ([]int, error)
+	// Purge removes a soft-deleted user with its sessions, tokens, roles, MFA and password history.
+	// With anonymize the row stays, without its personal data, for the records pointing at it.
+	// It returns sql.ErrNoRows when the user is not deleted (anymore).
+	Purge(ctx context.Context, id int, anonymize bool) error
 }
@@ -3,26 +3,51 @@ package usecase
 import (
 	"context"
 	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/pkg/query"
 )
 
+// ProfileUpdate holds the fields users change on their own profile, nil fields stay unchanged
+type ProfileUpdate struct {
+	FullName *string
+	Phone    *string
+	Locale   *string
+	Timezone *string
+	Email    *string // takes effect once the new address is verified
+}
+
 // UserUsecase defines business logic interface for user operations
 type UserUsecase interface {
 	// Authentication methods
-	Login(ctx context.Context, username, password string) (*entity.User, string, error) // returns user, token, error
-	RefreshToken(ctx context.Context, tokenString string) (string, error)
+	Login(ctx context.Context, username, password, ip string) (*entity.User, string, error)                                  // returns user, token, error
+	RefreshToken(ctx context.Context, refreshToken string, apiKeyID int) (*entity.User, *entity.RefreshToken, string, error) // returns user, rotated token, new refresh token, error
+
+	// Registration and email verification
+	Register(ctx context.Context, user *entity.User, lang string) error // creates the user as active or pending, depending on the config
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, email, lang string) error
 
 	// User management
 	CreateUser(ctx context.Context, user *entity.User) error
 	GetUserByID(ctx context.Context, id int) (*entity.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*entity.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
-	UpdateUser(ctx context.Context, user *entity.User) error
+	UpdateUser(ctx context.Context, user *entity.User, newPassword string) error // a non-empty newPassword is set along with the other fields
 	DeleteUser(ctx context.Context, id int) error
-	GetAllUsers(ctx context.Context, limit, offset int) ([]*entity.User, error)
-	GetUserCount(ctx context.Context) (int, error)
+	UnlockUser(ctx context.Context, id int) error // lifts a lockout after failed logins
+	GetAllUsers(ctx context.Context, filter repository.UserFilter, opts query.Options) ([]*entity.User, error)
+	GetUserCount(ctx context.Context, filter repository.UserFilter) (int, error)
+
+	// Soft-deleted users are listed with filter.Deleted and can be restored until they are purged
+	RestoreUser(ctx context.Context, id int) (*entity.User, error)
+
+	// Self-service profile of the current user. An email change is kept as pending and
+	// a verification link is mailed to the new address, VerifyEmail applies it.
+	UpdateProfile(ctx context.Context, userID int, update ProfileUpdate, lang string) (*entity.User, error)
 
-	// Password management
-	ForgotPassword(ctx context.Context, email string) error
+	// Password management. Every password change checks the password policy and history
+	// and logs the user out everywhere.
+	ForgotPassword(ctx context.Context, email, lang string) error // mails a reset token in the given language
 	ResetPassword(ctx context.Context, token, newPassword string) error
 	ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) error
 }
@@ -0,0 +1,279 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/model"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ApiKeyHandler handles API key management endpoints for admins
+type ApiKeyHandler struct {
+	apiKeyService service.ApiKeyService
+}
+
+// NewApiKeyHandler creates a new API key handler
+func NewApiKeyHandler(apiKeyService service.ApiKeyService) *ApiKeyHandler {
+	return &ApiKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// GetApiKeys handles GET /admin/api-keys
+func (h *ApiKeyHandler) GetApiKeys(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := c.QueryInt("offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+
+	apiKeys, err := h.apiKeyService.GetAllApiKeys(c.Context(), limit, offset)
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	apiKeyResponses := make([]*model.ApiKeyResponse, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		apiKeyResponses = append(apiKeyResponses, toApiKeyResponse(apiKey))
+	}
+
+	return response.SuccessWithI18n(c, "api_keys_retrieved", apiKeyResponses, nil)
+}
+
+// GetApiKey handles GET /admin/api-keys/:id
+func (h *ApiKeyHandler) GetApiKey(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "api_key_not_found", nil)
+	}
+
+	apiKey, err := h.apiKeyService.GetApiKeyByID(c.Context(), id)
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	if apiKey == nil {
+		return response.ErrorWithI18n(c, fiber.StatusNotFound, "api_key_not_found", nil)
+	}
+
+	return response.SuccessWithI18n(c, "api_key_retrieved", toApiKeyResponse(apiKey), nil)
+}
+
+// CreateApiKey handles POST /admin/api-keys.
+// The generated secrets are part of this response only.
+func (h *ApiKeyHandler) CreateApiKey(c *fiber.Ctx) error {
+	var req model.ApiKeyCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponse(c, "Validation failed. Please check the following fields", err)
+	}
+
+	userID, _ := c.Locals("user_id").(int)
+	apiKey := &entity.ApiKey{
+		Name:        req.Name,
+		Description: req.Description,
+		H2H:         req.H2H,
+		Scopes:      req.Scopes,
+		IPWhitelist: req.IPWhitelist,
+		IPDenylist:  req.IPDenylist,
+		CreatedBy:   userID,
+
+		RateLimitPerSecond: req.RateLimitPerSecond,
+		RateLimitBurst:     req.RateLimitBurst,
+		DailyQuota:         req.DailyQuota,
+	}
+	if err := h.apiKeyService.CreateApiKey(c.Context(), apiKey); err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.CreatedWithI18n(c, "api_key_created", toApiKeyCreatedResponse(apiKey), nil)
+}
+
+// UpdateApiKey handles PUT /admin/api-keys/:id
+func (h *ApiKeyHandler) UpdateApiKey(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "api_key_not_found", nil)
+	}
+
+	var req model.ApiKeyUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponse(c, "Validation failed. Please check the following fields", err)
+	}
+
+	apiKey, err := h.apiKeyService.GetApiKeyByID(c.Context(), id)
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	if apiKey == nil {
+		return response.ErrorWithI18n(c, fiber.StatusNotFound, "api_key_not_found", nil)
+	}
+
+	// Only the fields sent are changed
+	if req.Name != "" {
+		apiKey.Name = req.Name
+	}
+	if req.Description != nil {
+		apiKey.Description = req.Description
+	}
+	if req.Status != "" {
+		apiKey.Status = req.Status
+	}
+	if req.H2H != "" {
+		apiKey.H2H = req.H2H
+	}
+	if req.Scopes != nil {
+		apiKey.Scopes = req.Scopes
+	}
+	if req.IPWhitelist != nil {
+		apiKey.IPWhitelist = req.IPWhitelist
+	}
+	if req.IPDenylist != nil {
+		apiKey.IPDenylist = req.IPDenylist
+	}
+	if req.RateLimitPerSecond != nil {
+		apiKey.RateLimitPerSecond = *req.RateLimitPerSecond
+	}
+	if req.RateLimitBurst != nil {
+		apiKey.RateLimitBurst = *req.RateLimitBurst
+	}
+	if req.DailyQuota != nil {
+		apiKey.DailyQuota = *req.DailyQuota
+	}
+	if userID, ok := c.Locals("user_id").(int); ok {
+		apiKey.UpdatedBy = &userID
+	}
+
+	if err := h.apiKeyService.UpdateApiKey(c.Context(), apiKey); err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "api_key_updated", toApiKeyResponse(apiKey), nil)
+}
+
+// RotateApiKey handles POST /admin/api-keys/:id/rotate.
+// The new secrets are part of this response only, the old ones keep working for the grace period.
+func (h *ApiKeyHandler) RotateApiKey(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "api_key_not_found", nil)
+	}
+
+	var req model.ApiKeyRotateRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponse(c, "Validation failed. Please check the following fields", err)
+	}
+
+	var updatedBy *int
+	if userID, ok := c.Locals("user_id").(int); ok {
+		updatedBy = &userID
+	}
+
+	gracePeriod := time.Duration(req.GracePeriodHours) * time.Hour
+	apiKey, err := h.apiKeyService.RotateApiKey(c.Context(), id, gracePeriod, updatedBy)
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "api_key_rotated", toApiKeyCreatedResponse(apiKey), nil)
+}
+
+// RevokeApiKey handles DELETE /admin/api-keys/:id
+func (h *ApiKeyHandler) RevokeApiKey(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "api_key_not_found", nil)
+	}
+
+	var updatedBy *int
+	if userID, ok := c.Locals("user_id").(int); ok {
+		updatedBy = &userID
+	}
+
+	if err := h.apiKeyService.RevokeApiKey(c.Context(), id, updatedBy); err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "api_key_revoked", nil, nil)
+}
+
+// handleServiceError maps API key management errors to responses
+func (h *ApiKeyHandler) handleServiceError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrApiKeyNotFound):
+		return response.ErrorWithI18n(c, fiber.StatusNotFound, "api_key_not_found", nil)
+	case errors.Is(err, service.ErrApiKeyRevoked):
+		return response.ErrorWithI18n(c, fiber.StatusConflict, "api_key_revoked", nil)
+	case errors.Is(err, service.ErrInvalidIPList):
+		return response.ErrorWithI18n(c, fiber.StatusUnprocessableEntity, "invalid_ip_list", map[string]interface{}{
+			"Error": err.Error(),
+		})
+	}
+	return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+		"error": err.Error(),
+	})
+}
+
+func toApiKeyResponse(apiKey *entity.ApiKey) *model.ApiKeyResponse {
+	return &model.ApiKeyResponse{
+		ID:                   apiKey.ID,
+		Name:                 apiKey.Name,
+		Description:          apiKey.Description,
+		ApiKeyPrefix:         apiKey.ApiKeyPrefix,
+		PreviousKeyExpiresAt: apiKey.PreviousKeyExpiresAt,
+		Status:               apiKey.Status,
+		H2H:                  apiKey.H2H,
+		Scopes:               apiKey.Scopes,
+		RateLimitPerSecond:   apiKey.RateLimitPerSecond,
+		RateLimitBurst:       apiKey.RateLimitBurst,
+		DailyQuota:           apiKey.DailyQuota,
+		LastAccess:           apiKey.LastAccess,
+		IPWhitelist:          apiKey.IPWhitelist,
+		IPDenylist:           apiKey.IPDenylist,
+		CreatedAt:            apiKey.CreatedAt,
+		CreatedBy:            apiKey.CreatedBy,
+		UpdatedAt:            apiKey.UpdatedAt,
+		UpdatedBy:            apiKey.UpdatedBy,
+	}
+}
+
+func toApiKeyCreatedResponse(apiKey *entity.ApiKey) *model.ApiKeyCreatedResponse {
+	return &model.ApiKeyCreatedResponse{
+		ApiKeyResponse: *toApiKeyResponse(apiKey),
+		ApiKey:         apiKey.ApiKey,
+		AuthKey:        apiKey.AuthKey,
+	}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"strings"
+
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/internal/model"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/query"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// defaultAuditLogListLimit is the page size of GET /admin/audit-logs without a limit parameter
+const defaultAuditLogListLimit = 50
+
+// auditLogSortFields are the fields GET /admin/audit-logs can be sorted by
+var auditLogSortFields = []string{"id", "created_at"}
+
+// AuditLogHandler handles the audit log endpoints for admins
+type AuditLogHandler struct {
+	auditService service.AuditService
+}
+
+// NewAuditLogHandler creates a new audit log handler
+func NewAuditLogHandler(auditService service.AuditService) *AuditLogHandler {
+	return &AuditLogHandler{
+		auditService: auditService,
+	}
+}
+
+// GetAuditLogs handles GET /admin/audit-logs
+func (h *AuditLogHandler) GetAuditLogs(c *fiber.Ctx) error {
+	var req model.AuditLogListQuery
+	if err := c.QueryParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	filter, opts, param, err := parseAuditLogListQuery(&req)
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusUnprocessableEntity, "invalid_query_parameter", map[string]interface{}{
+			"Parameter": param,
+		})
+	}
+
+	logs, err := h.auditService.GetAll(c.Context(), filter, opts)
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "failed_to_get_audit_logs", nil)
+	}
+
+	total, err := h.auditService.GetCount(c.Context(), filter)
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "failed_to_get_audit_logs", nil)
+	}
+
+	logResponses := make([]*model.AuditLogResponse, len(logs))
+	for i, log := range logs {
+		logResponses[i] = toAuditLogResponse(log)
+	}
+
+	pagination := response.Pagination{
+		Page:       opts.Page,
+		Limit:      opts.Limit,
+		Total:      total,
+		TotalPages: opts.TotalPages(total),
+	}
+	if len(logs) == opts.Limit {
+		last := logs[len(logs)-1]
+		pagination.NextCursor = query.NewCursor(opts.Sort, auditLogSortValue(last, opts.Sort.Field), last.ID).Encode()
+	}
+
+	return response.PaginatedWithI18n(c, "audit_logs_retrieved", logResponses, pagination, nil)
+}
+
+// parseAuditLogListQuery converts the query parameters to a filter and options,
+// returning the name of the invalid parameter on error
+func parseAuditLogListQuery(req *model.AuditLogListQuery) (repository.AuditLogFilter, query.Options, string, error) {
+	filter := repository.AuditLogFilter{
+		ActorUserID: req.ActorUserID,
+		ApiKeyID:    req.ApiKeyID,
+		Action:      strings.TrimSpace(req.Action),
+		EntityType:  strings.TrimSpace(req.EntityType),
+		EntityID:    req.EntityID,
+		IP:          req.IP,
+	}
+	opts := query.Options{
+		Page:  req.Page,
+		Limit: req.Limit,
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	if opts.Limit < 1 {
+		opts.Limit = defaultAuditLogListLimit
+	}
+
+	var err error
+	if filter.CreatedFrom, err = query.ParseTime(req.CreatedFrom, false); err != nil {
+		return filter, opts, "created_from", err
+	}
+	if filter.CreatedTo, err = query.ParseTime(req.CreatedTo, true); err != nil {
+		return filter, opts, "created_to", err
+	}
+	if opts.Sort, err = query.ParseSort(req.Sort, auditLogSortFields, query.Sort{Field: "id", Desc: true}); err != nil {
+		return filter, opts, "sort", err
+	}
+	if req.After != "" {
+		if opts.After, err = query.DecodeCursor(req.After, opts.Sort); err != nil {
+			return filter, opts, "after", err
+		}
+	}
+
+	return filter, opts, "", nil
+}
+
+// auditLogSortValue returns the value of the sort field of an audit log, for the next page cursor
+func auditLogSortValue(log *entity.AuditLog, field string) interface{} {
+	if field == "created_at" {
+		return log.CreatedAt
+	}
+	return log.ID
+}
+
+// toAuditLogResponse converts an audit log entity to its response model
+func toAuditLogResponse(log *entity.AuditLog) *model.AuditLogResponse {
+	return &model.AuditLogResponse{
+		ID:          log.ID,
+		ActorUserID: log.ActorUserID,
+		ApiKeyID:    log.ApiKeyID,
+		IP:          log.IP,
+		Action:      log.Action,
+		EntityType:  log.EntityType,
+		EntityID:    log.EntityID,
+		Before:      log.Before,
+		After:       log.After,
+		Details:     log.Details,
+		CreatedAt:   log.CreatedAt,
+	}
+}
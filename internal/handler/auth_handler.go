@@ -1,9 +1,10 @@
 package handler
 
 import (
-	"go-rest-api-template/internal/constant"
+	"errors"
 	"go-rest-api-template/internal/domain/entity"
 	"go-rest-api-template/internal/domain/usecase"
+	"go-rest-api-template/internal/middleware"
 	"go-rest-api-template/internal/model"
 	"go-rest-api-template/internal/service"
 	"go-rest-api-template/pkg/response"
@@ -14,17 +15,27 @@ import (
 
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
-	userService   usecase.UserUsecase
-	jwtService    service.JWTService
-	apiKeyService service.ApiKeyService
+	userService         usecase.UserUsecase
+	jwtService          service.JWTService
+	apiKeyService       service.ApiKeyService
+	refreshTokenService service.RefreshTokenService
+	sessionService      service.SessionService
+	roleService         service.RoleService
+	mfaService          service.MFAService
+	loginAttempts       service.LoginAttemptService
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(userService usecase.UserUsecase, jwtService service.JWTService, apiKeyService service.ApiKeyService) *AuthHandler {
+func NewAuthHandler(userService usecase.UserUsecase, jwtService service.JWTService, apiKeyService service.ApiKeyService, refreshTokenService service.RefreshTokenService, sessionService service.SessionService, roleService service.RoleService, mfaService service.MFAService, loginAttempts service.LoginAttemptService) *AuthHandler {
 	return &AuthHandler{
-		userService:   userService,
-		jwtService:    jwtService,
-		apiKeyService: apiKeyService,
+		userService:         userService,
+		jwtService:          jwtService,
+		apiKeyService:       apiKeyService,
+		refreshTokenService: refreshTokenService,
+		sessionService:      sessionService,
+		roleService:         roleService,
+		mfaService:          mfaService,
+		loginAttempts:       loginAttempts,
 	}
 }
 
@@ -38,24 +49,61 @@ type LoginRequest struct {
 type RegisterRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8,max=100"`
+	Password string `json:"password" validate:"required,password"`
 	FullName string `json:"full_name" validate:"required,min=2,max=100"`
 }
 
+// VerifyEmailRequest represents the email verification request payload
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required,max=255"`
+}
+
+// ResendVerificationRequest represents the resend verification request payload
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// PendingRegistrationResponse is returned by register while the email address still has to be verified
+type PendingRegistrationResponse struct {
+	User *model.UserResponse `json:"user"`
+}
+
 // LoginResponse represents the login response
 type LoginResponse struct {
-	User  *model.UserResponse `json:"user"`
-	Token string              `json:"token"`
+	User             *model.UserResponse `json:"user"`
+	Token            string              `json:"token"`
+	RefreshToken     string              `json:"refresh_token"`
+	RefreshExpiresIn int                 `json:"refresh_expires_in"` // seconds
+}
+
+// MFAChallengeResponse is returned by login when the user has to enter a second factor
+type MFAChallengeResponse struct {
+	MFAToken  string `json:"mfa_token"`
+	ExpiresIn int    `json:"expires_in"` // seconds
+}
+
+// VerifyMFARequest represents the second login step, exchanging the MFA token for a private token
+type VerifyMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required,max=2048"`
+	Code     string `json:"code" validate:"required,max=32"` // TOTP code or recovery code
 }
 
 // RefreshTokenRequest represents the refresh token request
 type RefreshTokenRequest struct {
-	Token string `json:"token" validate:"required"`
+	RefreshToken string `json:"refresh_token" validate:"required,max=128"`
 }
 
 // RefreshTokenResponse represents the refresh token response
 type RefreshTokenResponse struct {
-	Token string `json:"token"`
+	Token            string `json:"token"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresIn int    `json:"refresh_expires_in"` // seconds
+}
+
+// LogoutRequest represents the optional logout request payload
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"` // revoke this refresh token family as well
+	AllDevices   bool   `json:"all_devices"`   // revoke every token issued to the user
 }
 
 // PublicTokenResponse represents the public token response
@@ -131,8 +179,17 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	}
 
 	// Authenticate user (note: user service now returns empty token)
-	user, _, err := h.userService.Login(c.Context(), req.Username, req.Password)
-	if err != nil {
+	user, _, err := h.userService.Login(c.Context(), req.Username, req.Password, middleware.GetClientIP(c))
+	switch {
+	case errors.Is(err, service.ErrTooManyLoginAttempts):
+		return response.ErrorWithI18n(c, fiber.StatusTooManyRequests, "too_many_login_attempts", nil)
+	case errors.Is(err, service.ErrAccountLocked):
+		return response.ErrorWithI18n(c, fiber.StatusLocked, "account_locked", nil)
+	case errors.Is(err, service.ErrAccountBanned):
+		return response.ErrorWithI18n(c, fiber.StatusForbidden, "account_banned", nil)
+	case errors.Is(err, service.ErrEmailNotVerified):
+		return response.ErrorWithI18n(c, fiber.StatusForbidden, "email_not_verified", nil)
+	case err != nil:
 		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "login_failed", map[string]interface{}{
 			"error": err.Error(),
 		})
@@ -144,8 +201,29 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		Name: apiKeyName,
 	}
 
-	// Generate private token (contains API key + user info)
-	privateToken, err := h.jwtService.GeneratePrivateToken(apiKey, user)
+	// With 2FA enabled the password only earns a short-lived token for the second step
+	mfaEnabled, err := h.mfaService.IsEnabled(c.Context(), user.ID)
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	if mfaEnabled {
+		mfaToken, err := h.jwtService.GenerateMFAPendingToken(apiKey, user)
+		if err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "token_generation_failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+
+		return response.SuccessWithI18n(c, "mfa_required", MFAChallengeResponse{
+			MFAToken:  mfaToken,
+			ExpiresIn: int(service.MFAPendingTokenExpiry.Seconds()),
+		}, nil)
+	}
+
+	// Start a login session and generate private + refresh tokens for it
+	privateToken, refreshToken, err := h.startSession(c, apiKey, user)
 	if err != nil {
 		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "token_generation_failed", map[string]interface{}{
 			"error": err.Error(),
@@ -157,19 +235,129 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		ID:        user.ID,
 		Username:  user.Username,
 		Email:     user.Email,
+		FullName:  user.FullName,
 		Status:    user.Status,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}
 
 	loginResponse := LoginResponse{
-		User:  userResponse,
-		Token: privateToken, // This is now a private token
+		User:             userResponse,
+		Token:            privateToken, // This is now a private token
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(h.refreshTokenService.Expiry().Seconds()),
 	}
 
 	return response.SuccessWithI18n(c, "login_success", loginResponse, nil)
 }
 
+// VerifyMFA completes a two-step login: it checks the code of the user behind an MFA token
+// and issues the private token
+func (h *AuthHandler) VerifyMFA(c *fiber.Ctx) error {
+	var req VerifyMFARequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request_body", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(&req); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	// Get API key from context (should be set by middleware)
+	apiKeyID, ok := c.Locals("api_key_id").(int)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "api_key_required", nil)
+	}
+
+	apiKeyName, ok := c.Locals("api_key_name").(string)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "api_key_invalid", nil)
+	}
+
+	// The second step must come from the API key the password was sent with
+	claims, err := h.jwtService.ValidateMFAPendingToken(req.MFAToken)
+	if err != nil || claims.ApiKeyID != apiKeyID {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "invalid_mfa_token", nil)
+	}
+
+	ip := middleware.GetClientIP(c)
+	if err := h.loginAttempts.CheckIP(c.Context(), ip); err != nil {
+		if errors.Is(err, service.ErrTooManyLoginAttempts) {
+			return response.ErrorWithI18n(c, fiber.StatusTooManyRequests, "too_many_login_attempts", nil)
+		}
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	// The account may have been banned, deleted or locked since the password step
+	user, err := h.userService.GetUserByID(c.Context(), claims.UserID)
+	if err != nil || user == nil {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "invalid_mfa_token", nil)
+	}
+	if user.IsLocked() {
+		return response.ErrorWithI18n(c, fiber.StatusLocked, "account_locked", nil)
+	}
+	if user.IsBanned() {
+		return response.ErrorWithI18n(c, fiber.StatusForbidden, "account_banned", nil)
+	}
+	if !user.IsActive() {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "login_failed", nil)
+	}
+
+	if err := h.mfaService.Verify(c.Context(), claims.UserID, req.Code); err != nil {
+		if errors.Is(err, service.ErrInvalidMFACode) || errors.Is(err, service.ErrMFANotEnabled) {
+			// Wrong codes count like wrong passwords, so guessing codes locks the account
+			if _, err := h.loginAttempts.RecordFailure(c.Context(), user, ip); err != nil {
+				return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+					"error": err.Error(),
+				})
+			}
+			if user.IsLocked() {
+				_ = h.jwtService.RevokeMFAPendingToken(c.Context(), claims)
+				return response.ErrorWithI18n(c, fiber.StatusLocked, "account_locked", nil)
+			}
+			return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "invalid_mfa_code", nil)
+		}
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	// The token is exchanged once, a second private token needs a new login
+	if err := h.jwtService.RevokeMFAPendingToken(c.Context(), claims); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	if err := h.loginAttempts.RecordSuccess(c.Context(), user); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	apiKey := &entity.ApiKey{
+		ID:   apiKeyID,
+		Name: apiKeyName,
+	}
+
+	privateToken, refreshToken, err := h.startSession(c, apiKey, user)
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "token_generation_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	return response.SuccessWithI18n(c, "login_success", LoginResponse{
+		User:             toUserResponse(user),
+		Token:            privateToken,
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(h.refreshTokenService.Expiry().Seconds()),
+	}, nil)
+}
+
 // Register handles user registration
 func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	var req RegisterRequest
@@ -196,11 +384,11 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "api_key_invalid", nil)
 	}
 
-	// Create user entity
+	// Create user entity, the service decides whether it starts active or pending
 	user := &entity.User{
 		Username: req.Username,
 		Email:    req.Email,
-		Status:   constant.UserStatusActive,
+		FullName: req.FullName,
 	}
 
 	// Set password (will be hashed)
@@ -211,20 +399,33 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	}
 
 	// Create user through usecase
-	if err := h.userService.CreateUser(c.Context(), user); err != nil {
+	if err := h.userService.Register(c.Context(), user, middleware.GetLanguage(c)); err != nil {
+		if errors.Is(err, service.ErrUsernameExists) {
+			return response.ErrorWithI18n(c, fiber.StatusConflict, "username_exists", nil)
+		}
+		if errors.Is(err, service.ErrEmailExists) {
+			return response.ErrorWithI18n(c, fiber.StatusConflict, "email_exists", nil)
+		}
 		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
 			"error": err.Error(),
 		})
 	}
 
+	// Pending users get no tokens until they verified their email address
+	if user.IsPending() {
+		return response.CreatedWithI18n(c, "registration_pending_verification", PendingRegistrationResponse{
+			User: toUserResponse(user),
+		}, nil)
+	}
+
 	// Create API key entity for token generation
 	apiKey := &entity.ApiKey{
 		ID:   apiKeyID,
 		Name: apiKeyName,
 	}
 
-	// Generate private token for new user
-	privateToken, err := h.jwtService.GeneratePrivateToken(apiKey, user)
+	// Start a login session for the new user
+	privateToken, refreshToken, err := h.startSession(c, apiKey, user)
 	if err != nil {
 		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
 			"error": err.Error(),
@@ -236,20 +437,75 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		ID:        user.ID,
 		Username:  user.Username,
 		Email:     user.Email,
+		FullName:  user.FullName,
 		Status:    user.Status,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}
 
 	loginResponse := LoginResponse{
-		User:  userResponse,
-		Token: privateToken,
+		User:             userResponse,
+		Token:            privateToken,
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: int(h.refreshTokenService.Expiry().Seconds()),
 	}
 
 	return response.SuccessWithI18n(c, "registration_success", loginResponse, nil)
 }
 
-// RefreshToken handles token refresh
+// VerifyEmail activates the account a verification token was sent to
+func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req VerifyEmailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request_body", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(&req); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	if err := h.userService.VerifyEmail(c.Context(), req.Token); err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_verification_token", nil)
+		}
+		// The new address of an email change was registered by someone else meanwhile
+		if errors.Is(err, service.ErrEmailExists) {
+			return response.ErrorWithI18n(c, fiber.StatusConflict, "email_exists", nil)
+		}
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	return response.SuccessWithI18n(c, "email_verified", nil, nil)
+}
+
+// ResendVerification mails a new verification link to a pending account
+func (h *AuthHandler) ResendVerification(c *fiber.Ctx) error {
+	var req ResendVerificationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request_body", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(&req); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	// Unknown and verified addresses get the same answer so accounts cannot be enumerated
+	if err := h.userService.ResendVerification(c.Context(), req.Email, middleware.GetLanguage(c)); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	return response.SuccessWithI18n(c, "verification_email_sent", nil, nil)
+}
+
+// RefreshToken rotates the refresh token and issues a new private token
 func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	var req RefreshTokenRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -258,24 +514,168 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 		})
 	}
 
-	// Refresh token
-	newToken, err := h.userService.RefreshToken(c.Context(), req.Token)
+	// Validate request
+	if err := validator.ValidateStruct(&req); err != nil {
+		return response.ValidationErrorResponse(c, "Validation failed. Please check the following fields", err)
+	}
+
+	// Get API key from context (should be set by middleware)
+	apiKeyID, ok := c.Locals("api_key_id").(int)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "api_key_required", nil)
+	}
+
+	apiKeyName, ok := c.Locals("api_key_name").(string)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "api_key_invalid", nil)
+	}
+
+	// Rotate refresh token
+	user, token, newRefreshToken, err := h.userService.RefreshToken(c.Context(), req.RefreshToken, apiKeyID)
 	if err != nil {
-		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "token_refresh_failed", map[string]interface{}{
+		switch {
+		case errors.Is(err, service.ErrSessionTerminated), errors.Is(err, service.ErrSessionNotFound):
+			return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "session_terminated", nil)
+		case errors.Is(err, service.ErrRefreshTokenReused):
+			return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "refresh_token_reused", nil)
+		case errors.Is(err, service.ErrRefreshTokenExpired):
+			return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "refresh_token_expired", nil)
+		default:
+			return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "invalid_refresh_token", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	// Create API key entity for token generation
+	apiKey := &entity.ApiKey{
+		ID:   apiKeyID,
+		Name: apiKeyName,
+	}
+
+	// Pick up role changes made since the previous token was issued
+	if err := h.loadRoles(c, user); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "token_generation_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	// Generate new private token for the same login session
+	privateToken, err := h.jwtService.GeneratePrivateToken(apiKey, user, token.SessionToken)
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "token_generation_failed", map[string]interface{}{
 			"error": err.Error(),
 		})
 	}
 
 	refreshResponse := RefreshTokenResponse{
-		Token: newToken,
+		Token:            privateToken,
+		RefreshToken:     newRefreshToken,
+		RefreshExpiresIn: int(h.refreshTokenService.Expiry().Seconds()),
 	}
 
-	return response.SuccessWithI18n(c, "token_refresh_success", refreshResponse, nil)
+	return response.SuccessWithI18n(c, "token_refreshed", refreshResponse, nil)
 }
 
-// Logout handles user logout (optional - for token blacklist in the future)
+// Logout revokes the current private token and, optionally, every token of the user
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
-	// For JWT, logout is typically handled client-side by removing the token
-	// In the future, you could implement token blacklisting here
+	var req LogoutRequest
+	// Body is optional for logout
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	// Get token claims from context (should be set by private middleware)
+	claims, ok := c.Locals("jwt_claims").(*service.PrivateJWTClaims)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+	}
+
+	// An admin impersonating the user may end the own token, not the user's real sessions
+	if _, impersonating := c.Locals("impersonator_id").(int); impersonating && req.AllDevices {
+		return response.ErrorWithI18n(c, fiber.StatusForbidden, "impersonation_forbidden", nil)
+	}
+
+	ctx := c.Context()
+	if req.AllDevices {
+		// Logout everywhere: revoke all private and refresh tokens of the user
+		if err := h.jwtService.RevokeAllUserTokens(ctx, claims.UserID); err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "logout_failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		// Ends every session, which revokes their refresh tokens too
+		if err := h.sessionService.TerminateAll(ctx, claims.UserID); err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "logout_failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		return response.SuccessWithI18n(c, "logout_success", nil, nil)
+	}
+
+	if err := h.jwtService.RevokeToken(ctx, claims); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "logout_failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if req.RefreshToken != "" {
+		if err := h.refreshTokenService.Revoke(ctx, req.RefreshToken, claims.UserID); err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "logout_failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	// End the login session of this token (set by private middleware)
+	if sessionID, ok := c.Locals("session_id").(int); ok {
+		if err := h.sessionService.Terminate(ctx, claims.UserID, sessionID); err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "logout_failed", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
 	return response.SuccessWithI18n(c, "logout_success", nil, nil)
 }
+
+// startSession records a login session and returns a private token and refresh token bound to it
+func (h *AuthHandler) startSession(c *fiber.Ctx, apiKey *entity.ApiKey, user *entity.User) (string, string, error) {
+	// Roles go into the roles claim of the private token
+	if err := h.loadRoles(c, user); err != nil {
+		return "", "", err
+	}
+
+	session, err := h.sessionService.Start(c.Context(), user.ID, apiKey.ID, middleware.GetClientIP(c), c.Get("User-Agent"))
+	if err != nil {
+		return "", "", err
+	}
+
+	// Generate private token (contains API key + user info + session id)
+	privateToken, err := h.jwtService.GeneratePrivateToken(apiKey, user, session.SessionToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Issue refresh token bound to the same API key and session
+	refreshToken, err := h.refreshTokenService.Issue(c.Context(), user.ID, apiKey.ID, session.SessionToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	return privateToken, refreshToken, nil
+}
+
+// loadRoles fills in the roles of the user for the private token
+func (h *AuthHandler) loadRoles(c *fiber.Ctx, user *entity.User) error {
+	roles, err := h.roleService.GetUserRoles(c.Context(), user.ID)
+	if err != nil {
+		return err
+	}
+	user.Roles = roles
+	return nil
+}
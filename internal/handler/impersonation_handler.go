@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/model"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImpersonationHandler handles admins acting as other users to reproduce their issues
+type ImpersonationHandler struct {
+	impersonationService service.ImpersonationService
+}
+
+// NewImpersonationHandler creates a new impersonation handler
+func NewImpersonationHandler(impersonationService service.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+	}
+}
+
+// ImpersonationResponse represents the impersonation response, the token is used like the private token of a login
+type ImpersonationResponse struct {
+	User      *model.UserResponse `json:"user"`
+	Token     string              `json:"token"`
+	ExpiresIn int                 `json:"expires_in"` // seconds
+}
+
+// Impersonate handles POST /admin/users/:id/impersonate
+func (h *ImpersonationHandler) Impersonate(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_user_id", nil)
+	}
+
+	var req model.ImpersonationRequest
+	// Body is optional, it only carries the reason
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	adminID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+	}
+
+	// The token works with the API key the admin is using
+	apiKeyID, ok := c.Locals("api_key_id").(int)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "api_key_required", nil)
+	}
+	apiKeyName, _ := c.Locals("api_key_name").(string)
+
+	token, err := h.impersonationService.Impersonate(c.Context(), adminID, userID, &entity.ApiKey{ID: apiKeyID, Name: apiKeyName}, req.Reason)
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "impersonation_started", ImpersonationResponse{
+		User:      toUserResponse(token.User),
+		Token:     token.Token,
+		ExpiresIn: token.ExpiresIn,
+	}, nil)
+}
+
+// handleServiceError maps impersonation service errors to HTTP responses
+func (h *ImpersonationHandler) handleServiceError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return response.ErrorWithI18n(c, fiber.StatusNotFound, "user_not_found", nil)
+	case errors.Is(err, service.ErrImpersonationNotAllowed):
+		return response.ErrorWithI18n(c, fiber.StatusForbidden, "impersonation_not_allowed", nil)
+	}
+	return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+		"error": err.Error(),
+	})
+}
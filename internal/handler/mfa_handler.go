@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/response"
+	"go-rest-api-template/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MFAHandler handles the two-factor authentication settings of the current user
+type MFAHandler struct {
+	mfaService service.MFAService
+}
+
+// NewMFAHandler creates a new MFA handler
+func NewMFAHandler(mfaService service.MFAService) *MFAHandler {
+	return &MFAHandler{
+		mfaService: mfaService,
+	}
+}
+
+// MFACodeRequest carries a TOTP code, or a recovery code where one is accepted
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+// MFAEnrollmentResponse holds what an authenticator app needs to add the account
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// MFARecoveryCodesResponse holds the one-time recovery codes, shown only once
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// Enroll handles POST /auth/mfa/enroll
+func (h *MFAHandler) Enroll(c *fiber.Ctx) error {
+	user, ok := c.Locals("user").(*entity.User)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+	}
+
+	enrollment, err := h.mfaService.Enroll(c.Context(), user)
+	if err != nil {
+		return handleMFAError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "mfa_enrolled", MFAEnrollmentResponse{
+		Secret:     enrollment.Secret,
+		OtpauthURI: enrollment.URI,
+	}, nil)
+}
+
+// Confirm handles POST /auth/mfa/confirm
+func (h *MFAHandler) Confirm(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+	}
+
+	var req MFACodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request_body", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(&req); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	codes, err := h.mfaService.Confirm(c.Context(), userID, req.Code)
+	if err != nil {
+		return handleMFAError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "mfa_enabled", MFARecoveryCodesResponse{RecoveryCodes: codes}, nil)
+}
+
+// Disable handles POST /auth/mfa/disable
+func (h *MFAHandler) Disable(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+	}
+
+	var req MFACodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request_body", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := validator.ValidateStruct(&req); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	if err := h.mfaService.Disable(c.Context(), userID, req.Code); err != nil {
+		return handleMFAError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "mfa_disabled", nil, nil)
+}
+
+// handleMFAError maps MFA service errors to HTTP responses
+func handleMFAError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		return response.ErrorWithI18n(c, fiber.StatusConflict, "mfa_already_enabled", nil)
+	case errors.Is(err, service.ErrMFANotEnrolled):
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "mfa_not_enrolled", nil)
+	case errors.Is(err, service.ErrMFANotEnabled):
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "mfa_not_enabled", nil)
+	case errors.Is(err, service.ErrInvalidMFACode):
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_mfa_code", nil)
+	default:
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
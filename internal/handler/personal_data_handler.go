@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go-rest-api-template/internal/model"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PersonalDataHandler handles data subject requests: exports and erasure of personal data
+type PersonalDataHandler struct {
+	personalDataService service.PersonalDataService
+}
+
+// NewPersonalDataHandler creates a new personal data handler
+func NewPersonalDataHandler(personalDataService service.PersonalDataService) *PersonalDataHandler {
+	return &PersonalDataHandler{
+		personalDataService: personalDataService,
+	}
+}
+
+// ExportMyData handles GET /me/export. The archive is sent as a JSON file download,
+// without the response envelope, so it can be kept and read on its own.
+func (h *PersonalDataHandler) ExportMyData(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+	}
+
+	export, err := h.personalDataService.Export(c.Context(), userID)
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="personal-data-%d.json"`, userID))
+	return c.JSON(export)
+}
+
+// EraseMyAccount handles POST /me/erase
+func (h *PersonalDataHandler) EraseMyAccount(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+	}
+
+	var req model.AccountErasureRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request_body", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	if err := h.personalDataService.EraseAccount(c.Context(), userID, req.Password); err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "account_erased", nil, nil)
+}
+
+// EraseUser handles POST /admin/users/:id/erase
+func (h *PersonalDataHandler) EraseUser(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_user_id", nil)
+	}
+
+	if err := h.personalDataService.Erase(c.Context(), id); err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "user_erased", map[string]interface{}{
+		"id": id,
+	}, nil)
+}
+
+// handleServiceError maps personal data service errors to HTTP responses
+func (h *PersonalDataHandler) handleServiceError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return response.ErrorWithI18n(c, fiber.StatusNotFound, "user_not_found", nil)
+	case errors.Is(err, service.ErrInvalidCurrentPassword):
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_current_password", nil)
+	}
+	return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+		"error": err.Error(),
+	})
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"go-rest-api-template/internal/model"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RoleHandler handles role assignment endpoints for admins
+type RoleHandler struct {
+	roleService service.RoleService
+}
+
+// NewRoleHandler creates a new role handler
+func NewRoleHandler(roleService service.RoleService) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+	}
+}
+
+// GetRoles handles GET /admin/roles
+func (h *RoleHandler) GetRoles(c *fiber.Ctx) error {
+	roles, err := h.roleService.GetRoles(c.Context())
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "roles_retrieved", roles, nil)
+}
+
+// GetUserRoles handles GET /admin/users/:id/roles
+func (h *RoleHandler) GetUserRoles(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_user_id", nil)
+	}
+
+	return h.sendUserRoles(c, userID, "user_roles_retrieved")
+}
+
+// AssignRole handles POST /admin/users/:id/roles
+func (h *RoleHandler) AssignRole(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_user_id", nil)
+	}
+
+	var req model.AssignRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	var createdBy *int
+	if adminID, ok := c.Locals("user_id").(int); ok {
+		createdBy = &adminID
+	}
+
+	if err := h.roleService.AssignRole(c.Context(), userID, req.Role, createdBy); err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return h.sendUserRoles(c, userID, "role_assigned")
+}
+
+// RevokeRole handles DELETE /admin/users/:id/roles/:role
+func (h *RoleHandler) RevokeRole(c *fiber.Ctx) error {
+	userID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_user_id", nil)
+	}
+
+	if err := h.roleService.RevokeRole(c.Context(), userID, c.Params("role")); err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return h.sendUserRoles(c, userID, "role_revoked")
+}
+
+// sendUserRoles responds with the current roles of the user
+func (h *RoleHandler) sendUserRoles(c *fiber.Ctx, userID int, messageKey string) error {
+	roles, err := h.roleService.GetUserRoles(c.Context(), userID)
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, messageKey, &model.UserRolesResponse{
+		UserID: userID,
+		Roles:  roles,
+	}, nil)
+}
+
+// handleServiceError maps role service errors to HTTP responses
+func (h *RoleHandler) handleServiceError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return response.ErrorWithI18n(c, fiber.StatusNotFound, "user_not_found", nil)
+	case errors.Is(err, service.ErrRoleNotFound):
+		return response.ErrorWithI18n(c, fiber.StatusNotFound, "role_not_found", nil)
+	}
+	return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+		"error": err.Error(),
+	})
+}
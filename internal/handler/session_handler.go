@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"go-rest-api-template/internal/model"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionHandler handles login session ("active devices") endpoints of the current user
+type SessionHandler struct {
+	sessionService service.SessionService
+}
+
+// NewSessionHandler creates a new session handler
+func NewSessionHandler(sessionService service.SessionService) *SessionHandler {
+	return &SessionHandler{
+		sessionService: sessionService,
+	}
+}
+
+// GetSessions handles GET /users/me/sessions
+func (h *SessionHandler) GetSessions(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+	}
+
+	sessions, err := h.sessionService.GetActiveSessions(c.Context(), userID)
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	// Mark the session the request was made from
+	currentSessionID, _ := c.Locals("session_id").(int)
+
+	sessionResponses := make([]*model.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		sessionResponses = append(sessionResponses, &model.SessionResponse{
+			ID:         session.ID,
+			ApiKeyID:   session.ApiKeyID,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+
+	return response.SuccessWithI18n(c, "sessions_retrieved", sessionResponses, nil)
+}
+
+// TerminateSession handles DELETE /users/me/sessions/:id
+func (h *SessionHandler) TerminateSession(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+	}
+
+	sessionID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "session_not_found", nil)
+	}
+
+	if err := h.sessionService.Terminate(c.Context(), userID, sessionID); err != nil {
+		if errors.Is(err, service.ErrSessionNotFound) {
+			return response.ErrorWithI18n(c, fiber.StatusNotFound, "session_not_found", nil)
+		}
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	return response.SuccessWithI18n(c, "session_terminated", nil, nil)
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/middleware"
+	"go-rest-api-template/internal/model"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UserBulkHandler handles bulk imports and exports of users, which run as background jobs
+type UserBulkHandler struct {
+	userBulkService service.UserBulkService
+}
+
+// NewUserBulkHandler creates a new bulk user handler
+func NewUserBulkHandler(userBulkService service.UserBulkService) *UserBulkHandler {
+	return &UserBulkHandler{
+		userBulkService: userBulkService,
+	}
+}
+
+// ImportUsers handles POST /admin/users/import, a multipart form with the CSV or NDJSON file in "file"
+func (h *UserBulkHandler) ImportUsers(c *fiber.Ctx) error {
+	var req model.UserImportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request_body", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "import_file_required", nil)
+	}
+	format := req.Format
+	if format == "" {
+		format = service.UserFileFormat(fileHeader.Filename)
+	}
+	if format == "" {
+		return response.ErrorWithI18n(c, fiber.StatusUnprocessableEntity, "unsupported_file_format", nil)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "import_file_required", nil)
+	}
+	defer file.Close()
+
+	job, err := h.userBulkService.StartImport(c.Context(), file, service.UserImportOptions{
+		Format:    format,
+		Passwords: req.Passwords,
+		DryRun:    req.DryRun,
+		Lang:      middleware.GetLanguage(c),
+	})
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.AcceptedWithI18n(c, "user_import_started", job, nil)
+}
+
+// ExportUsers handles POST /admin/users/export, with the filters of GET /users
+func (h *UserBulkHandler) ExportUsers(c *fiber.Ctx) error {
+	var req model.UserExportQuery
+	if err := c.QueryParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	filter, _, param, err := parseUserListQuery(&model.UserListQuery{
+		Status:      req.Status,
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+		Search:      req.Search,
+	})
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusUnprocessableEntity, "invalid_query_parameter", map[string]interface{}{
+			"Parameter": param,
+		})
+	}
+
+	format := req.Format
+	if format == "" {
+		format = constant.UserFileFormatCSV
+	}
+	job, err := h.userBulkService.StartExport(c.Context(), format, filter)
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.AcceptedWithI18n(c, "user_export_started", job, nil)
+}
+
+// GetJob handles GET /admin/users/jobs/:id, the state of an import or export and the import report
+func (h *UserBulkHandler) GetJob(c *fiber.Ctx) error {
+	job, err := h.userBulkService.GetJob(c.Params("id"))
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "user_job_retrieved", job, nil)
+}
+
+// DownloadExport handles GET /admin/users/jobs/:id/download, the file of a completed export
+func (h *UserBulkHandler) DownloadExport(c *fiber.Ctx) error {
+	path, job, err := h.userBulkService.ExportFile(c.Params("id"))
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return c.Download(path, fmt.Sprintf("users-%s.%s", job.CreatedAt.Format("20060102-150405"), job.Format))
+}
+
+// handleServiceError maps bulk user service errors to HTTP responses
+func (h *UserBulkHandler) handleServiceError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserJobNotFound):
+		return response.ErrorWithI18n(c, fiber.StatusNotFound, "user_job_not_found", nil)
+	case errors.Is(err, service.ErrUserJobNotReady):
+		return response.ErrorWithI18n(c, fiber.StatusConflict, "user_job_not_ready", nil)
+	case errors.Is(err, service.ErrUnsupportedFileFormat):
+		return response.ErrorWithI18n(c, fiber.StatusUnprocessableEntity, "unsupported_file_format", nil)
+	}
+	return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+		"error": err.Error(),
+	})
+}
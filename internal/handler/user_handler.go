@@ -1,24 +1,41 @@
 package handler
 
 import (
-	"context"
+	"errors"
 	"strconv"
+	"strings"
 
 	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
 	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/internal/domain/usecase"
+	"go-rest-api-template/internal/middleware"
 	"go-rest-api-template/internal/model"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/password"
+	"go-rest-api-template/pkg/query"
 	"go-rest-api-template/pkg/response"
+	"go-rest-api-template/pkg/validator"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// validationFailedMessage is the message sent with 422 validation responses
+const validationFailedMessage = "Validation failed. Please check the following fields"
+
+// defaultUserListLimit is the page size of GET /users without a limit parameter
+const defaultUserListLimit = 20
+
+// userSortFields are the fields GET /users can be sorted by
+var userSortFields = []string{"id", "username", "email", "status", "created_at"}
+
 type UserHandler struct {
-	userRepo repository.UserRepository
+	userService usecase.UserUsecase
 }
 
-func NewUserHandler(userRepo repository.UserRepository) *UserHandler {
+func NewUserHandler(userService usecase.UserUsecase) *UserHandler {
 	return &UserHandler{
-		userRepo: userRepo,
+		userService: userService,
 	}
 }
 
@@ -29,22 +46,12 @@ func (h *UserHandler) GetUserByID(c *fiber.Ctx) error {
 		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_user_id", nil)
 	}
 
-	// Get user from database
-	ctx := context.Background()
-	user, err := h.userRepo.GetByID(ctx, id)
+	user, err := h.userService.GetUserByID(c.Context(), id)
 	if err != nil {
-		return response.ErrorWithI18n(c, fiber.StatusNotFound, "user_not_found", nil)
+		return h.handleServiceError(c, err)
 	}
 
-	// Convert entity to response model
-	userResponse := &model.UserResponse{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
-		Status:   user.Status,
-	}
-
-	return response.SuccessWithI18n(c, "user_retrieved", userResponse, nil)
+	return response.SuccessWithI18n(c, "user_retrieved", toUserResponse(user), nil)
 }
 
 // UpdateUser handles PUT /users/:id
@@ -54,11 +61,54 @@ func (h *UserHandler) UpdateUser(c *fiber.Ctx) error {
 		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_user_id", nil)
 	}
 
-	return response.SuccessWithI18n(c, "user_updated", map[string]interface{}{
-		"id":      id,
-		"status":  constant.UserStatusActive,
-		"message": "User updated successfully",
-	}, nil)
+	var req model.UserUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request_body", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	// Owners only edit their profile fields, the account status and setting a password
+	// without the current one are left to those managing users. Owners change their email
+	// with PATCH /me, which only applies it once the new address is verified.
+	if (req.Status != "" || req.Password != "" || req.Email != "") && !middleware.HasPermission(c, constant.PermissionUsersManage) {
+		return response.ErrorWithI18n(c, fiber.StatusForbidden, "permission_denied", map[string]interface{}{
+			"Permission": constant.PermissionUsersManage,
+		})
+	}
+
+	existing, err := h.userService.GetUserByID(c.Context(), id)
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	// Work on a copy so the service can still compare against the stored user
+	user := *existing
+
+	// Only the fields sent are changed
+	if req.Username != "" {
+		user.Username = req.Username
+	}
+	if req.Email != "" {
+		user.Email = req.Email
+	}
+	if req.Status != "" {
+		user.Status = req.Status
+	}
+	if userID, ok := c.Locals("user_id").(int); ok {
+		user.SetUpdatedBy(userID)
+	}
+
+	// The password is only set when every other change is accepted
+	if err := h.userService.UpdateUser(c.Context(), &user, req.Password); err != nil {
+		return h.handlePasswordError(c, "password", err)
+	}
+
+	return response.SuccessWithI18n(c, "user_updated", toUserResponse(&user), nil)
 }
 
 // DeleteUser handles DELETE /users/:id
@@ -68,48 +118,117 @@ func (h *UserHandler) DeleteUser(c *fiber.Ctx) error {
 		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_user_id", nil)
 	}
 
+	if err := h.userService.DeleteUser(c.Context(), id); err != nil {
+		return h.handleServiceError(c, err)
+	}
+
 	return response.SuccessWithI18n(c, "user_deleted", map[string]interface{}{
-		"id":      id,
-		"message": "User deleted successfully",
+		"id": id,
 	}, nil)
 }
 
 // GetAllUsers handles GET /users
 func (h *UserHandler) GetAllUsers(c *fiber.Ctx) error {
-	ctx := context.Background()
+	return h.listUsers(c, false)
+}
+
+// GetDeletedUsers handles GET /admin/users/deleted, the users that can still be restored
+func (h *UserHandler) GetDeletedUsers(c *fiber.Ctx) error {
+	return h.listUsers(c, true)
+}
+
+// listUsers answers a user list query, of the live or of the deleted users
+func (h *UserHandler) listUsers(c *fiber.Ctx, deleted bool) error {
+	var req model.UserListQuery
+	if err := c.QueryParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	filter, opts, param, err := parseUserListQuery(&req)
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusUnprocessableEntity, "invalid_query_parameter", map[string]interface{}{
+			"Parameter": param,
+		})
+	}
+	filter.Deleted = deleted
 
-	// Get users from database
-	users, err := h.userRepo.GetAll(ctx, 0, 0) // 0 means no limit/offset for now
+	users, err := h.userService.GetAllUsers(c.Context(), filter, opts)
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "failed_to_get_users", nil)
+	}
+
+	total, err := h.userService.GetUserCount(c.Context(), filter)
 	if err != nil {
 		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "failed_to_get_users", nil)
 	}
 
 	// Convert entities to response models
-	userResponses := make([]model.UserResponse, len(users))
+	userResponses := make([]*model.UserResponse, len(users))
 	for i, user := range users {
-		userResponses[i] = model.UserResponse{
-			ID:       user.ID,
-			Username: user.Username,
-			Email:    user.Email,
-			Status:   user.Status,
-		}
+		userResponses[i] = toUserResponse(user)
 	}
 
-	return response.SuccessWithI18n(c, "users_retrieved", userResponses, nil)
+	pagination := response.Pagination{
+		Page:       opts.Page,
+		Limit:      opts.Limit,
+		Total:      total,
+		TotalPages: opts.TotalPages(total),
+	}
+	if len(users) == opts.Limit {
+		last := users[len(users)-1]
+		pagination.NextCursor = query.NewCursor(opts.Sort, userSortValue(last, opts.Sort.Field), last.ID).Encode()
+	}
+
+	return response.PaginatedWithI18n(c, "users_retrieved", userResponses, pagination, nil)
 }
 
-// ForgotPassword handles POST /users/forgot-password
+// ForgotPassword handles POST /public/auth/forgot-password
 func (h *UserHandler) ForgotPassword(c *fiber.Ctx) error {
-	return response.SuccessWithI18n(c, "password_reset_email_sent", map[string]interface{}{
-		"message": "Password reset email sent successfully",
-	}, nil)
+	var req model.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request_body", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	// An unknown email gets the same answer so accounts cannot be enumerated
+	if err := h.userService.ForgotPassword(c.Context(), req.Email, middleware.GetLanguage(c)); err != nil && !errors.Is(err, service.ErrUserNotFound) {
+		return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	return response.SuccessWithI18n(c, "password_reset_email_sent", nil, nil)
 }
 
-// ResetPassword handles POST /users/reset-password
+// ResetPassword handles POST /public/auth/reset-password
 func (h *UserHandler) ResetPassword(c *fiber.Ctx) error {
-	return response.SuccessWithI18n(c, "password_reset_success", map[string]interface{}{
-		"message": "Password reset successfully",
-	}, nil)
+	var req model.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request_body", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	if err := h.userService.ResetPassword(c.Context(), req.Token, req.NewPassword); err != nil {
+		return h.handlePasswordError(c, "new_password", err)
+	}
+
+	return response.SuccessWithI18n(c, "password_reset_success", nil, nil)
 }
 
 // ChangePassword handles PUT /users/:id/password
@@ -119,8 +238,226 @@ func (h *UserHandler) ChangePassword(c *fiber.Ctx) error {
 		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_user_id", nil)
 	}
 
+	var req model.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request_body", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	if err := h.userService.ChangePassword(c.Context(), id, req.CurrentPassword, req.NewPassword); err != nil {
+		return h.handlePasswordError(c, "new_password", err)
+	}
+
 	return response.SuccessWithI18n(c, "password_changed", map[string]interface{}{
-		"id":      id,
-		"message": "Password changed successfully",
+		"id": id,
 	}, nil)
 }
+
+// UnlockUser handles POST /admin/users/:id/unlock
+func (h *UserHandler) UnlockUser(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_user_id", nil)
+	}
+
+	if err := h.userService.UnlockUser(c.Context(), id); err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "user_unlocked", map[string]interface{}{
+		"id": id,
+	}, nil)
+}
+
+// RestoreUser handles POST /admin/users/:id/restore
+func (h *UserHandler) RestoreUser(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_user_id", nil)
+	}
+
+	user, err := h.userService.RestoreUser(c.Context(), id)
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "user_restored", toUserResponse(user), nil)
+}
+
+// GetProfile handles GET /me
+func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+	}
+
+	user, err := h.userService.GetUserByID(c.Context(), userID)
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	return response.SuccessWithI18n(c, "profile_retrieved", toProfileResponse(user), nil)
+}
+
+// UpdateProfile handles PATCH /me
+func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int)
+	if !ok {
+		return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+	}
+
+	var req model.ProfileUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_request_body", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	if err := req.Validate(); err != nil {
+		return response.ValidationErrorResponseWithStatus(c, fiber.StatusUnprocessableEntity, validationFailedMessage, err)
+	}
+
+	user, err := h.userService.UpdateProfile(c.Context(), userID, usecase.ProfileUpdate{
+		FullName: req.FullName,
+		Phone:    req.Phone,
+		Locale:   req.Locale,
+		Timezone: req.Timezone,
+		Email:    req.Email,
+	}, middleware.GetLanguage(c))
+	if err != nil {
+		return h.handleServiceError(c, err)
+	}
+
+	// A new email address only replaces the current one after it was verified
+	message := "profile_updated"
+	if req.Email != nil && user.PendingEmail != nil {
+		message = "profile_updated_email_pending"
+	}
+	return response.SuccessWithI18n(c, message, toProfileResponse(user), nil)
+}
+
+// handleServiceError maps user service errors to HTTP responses
+func (h *UserHandler) handleServiceError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return response.ErrorWithI18n(c, fiber.StatusNotFound, "user_not_found", nil)
+	case errors.Is(err, service.ErrUsernameExists):
+		return response.ErrorWithI18n(c, fiber.StatusConflict, "username_exists", nil)
+	case errors.Is(err, service.ErrEmailExists):
+		return response.ErrorWithI18n(c, fiber.StatusConflict, "email_exists", nil)
+	case errors.Is(err, service.ErrInvalidCurrentPassword):
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_current_password", nil)
+	case errors.Is(err, service.ErrInvalidResetToken):
+		return response.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_reset_token", nil)
+	}
+	return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+		"error": err.Error(),
+	})
+}
+
+// handlePasswordError reports password policy violations as validation errors of field,
+// e.g. a reused password, and maps other errors like handleServiceError
+func (h *UserHandler) handlePasswordError(c *fiber.Ctx, field string, err error) error {
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		return h.handleServiceError(c, err)
+	}
+
+	validationErrors := make([]validator.ValidationError, len(policyErr.Violations))
+	for i, v := range policyErr.Violations {
+		validationErrors[i] = validator.PasswordViolationError(field, v)
+	}
+	return response.ValidationErrorListResponse(c, fiber.StatusUnprocessableEntity, validationFailedMessage, validationErrors)
+}
+
+// parseUserListQuery turns the query parameters of GET /users into a filter and page options.
+// On error it also returns the name of the offending parameter.
+func parseUserListQuery(req *model.UserListQuery) (repository.UserFilter, query.Options, string, error) {
+	filter := repository.UserFilter{
+		Status: req.Status,
+		Search: strings.TrimSpace(req.Search),
+	}
+	opts := query.Options{
+		Page:  req.Page,
+		Limit: req.Limit,
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	if opts.Limit < 1 {
+		opts.Limit = defaultUserListLimit
+	}
+
+	var err error
+	if filter.CreatedFrom, err = query.ParseTime(req.CreatedFrom, false); err != nil {
+		return filter, opts, "created_from", err
+	}
+	if filter.CreatedTo, err = query.ParseTime(req.CreatedTo, true); err != nil {
+		return filter, opts, "created_to", err
+	}
+	if opts.Sort, err = query.ParseSort(req.Sort, userSortFields, query.Sort{Field: "created_at", Desc: true}); err != nil {
+		return filter, opts, "sort", err
+	}
+	if req.After != "" {
+		if opts.After, err = query.DecodeCursor(req.After, opts.Sort); err != nil {
+			return filter, opts, "after", err
+		}
+	}
+
+	return filter, opts, "", nil
+}
+
+// userSortValue returns the value of the sort field of a user, for the next page cursor
+func userSortValue(user *entity.User, field string) interface{} {
+	switch field {
+	case "username":
+		return user.Username
+	case "email":
+		return user.Email
+	case "status":
+		return user.Status
+	case "created_at":
+		return user.CreatedAt
+	}
+	return user.ID
+}
+
+// toUserResponse converts a user entity to its response model
+func toUserResponse(user *entity.User) *model.UserResponse {
+	resp := &model.UserResponse{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		FullName:  user.FullName,
+		Status:    user.Status,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+		DeletedAt: user.DeletedAt,
+	}
+	if user.IsLocked() {
+		resp.LockedUntil = user.LockedUntil
+	}
+	return resp
+}
+
+// toProfileResponse converts a user entity to the profile response of the current user
+func toProfileResponse(user *entity.User) *model.ProfileResponse {
+	return &model.ProfileResponse{
+		ID:           user.ID,
+		Username:     user.Username,
+		Email:        user.Email,
+		PendingEmail: user.PendingEmail,
+		FullName:     user.FullName,
+		Phone:        user.Phone,
+		Locale:       user.Locale,
+		Timezone:     user.Timezone,
+		Status:       user.Status,
+		CreatedAt:    user.CreatedAt,
+		UpdatedAt:    user.UpdatedAt,
+	}
+}
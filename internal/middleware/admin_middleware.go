@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"errors"
+	"slices"
+
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AdminMiddleware only lets the configured admin users (admin.user_ids) and users
+// holding the admin role through. It must run after PrivateMiddleware, which puts
+// the authenticated user_id and token claims in the context.
+func AdminMiddleware(adminUserIDs []int, roleService service.RoleService) fiber.Handler {
+	admins := make(map[int]bool, len(adminUserIDs))
+	for _, id := range adminUserIDs {
+		admins[id] = true
+	}
+
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("user_id").(int)
+		if !ok {
+			return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+		}
+
+		if admins[userID] {
+			return c.Next()
+		}
+
+		isAdmin, err := hasRole(c, roleService, constant.RoleAdmin)
+		if err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		if !isAdmin {
+			return response.ErrorWithI18n(c, fiber.StatusForbidden, "admin_required", nil)
+		}
+
+		return c.Next()
+	}
+}
+
+// hasRole checks the roles claim of the private token against the roles the user still holds
+func hasRole(c *fiber.Ctx, roleService service.RoleService, role string) (bool, error) {
+	roles, err := currentRoles(c, roleService)
+	if err != nil {
+		if errors.Is(err, errNoPrivateToken) {
+			return false, nil
+		}
+		return false, err
+	}
+	return slices.Contains(roles, role), nil
+}
@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"context"
+	"errors"
+	"go-rest-api-template/internal/domain/entity"
 	"go-rest-api-template/internal/service"
 	"go-rest-api-template/pkg/response"
 	"strings"
@@ -68,9 +70,9 @@ func ApiKeyMiddleware(apiKeyService service.ApiKeyService, responseHelper *respo
 			})
 		}
 
-		// Check IP whitelist if configured
-		clientIP := c.IP()
-		if !apiKeyEntity.IsIPWhitelisted(clientIP) {
+		// Check IP whitelist and denylist if configured
+		clientIP := GetClientIP(c)
+		if !apiKeyEntity.IsIPAllowed(clientIP) {
 			if responseHelper != nil {
 				return responseHelper.ErrorWithI18n(c, fiber.StatusUnauthorized, "ip_not_whitelisted", nil)
 			}
@@ -81,13 +83,15 @@ func ApiKeyMiddleware(apiKeyService service.ApiKeyService, responseHelper *respo
 		}
 
 		// Store API key info in context for later use
+		c.Locals("api_key", apiKeyEntity)
 		c.Locals("api_key_id", apiKeyEntity.ID)
 		c.Locals("api_key_name", apiKeyEntity.Name)
 		c.Locals("api_key_h2h", apiKeyEntity.IsH2HEnabled())
 
-		// Log API key access (async with timeout)
+		// Log API key access (async with timeout), copying the actor out of the request first
+		actor := service.AuditActorFromContext(c.Context())
 		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			ctx, cancel := context.WithTimeout(service.WithAuditActor(context.Background(), actor), 5*time.Second)
 			defer cancel()
 			_ = apiKeyService.LogApiKeyAccess(ctx, apiKeyEntity.ID)
 		}()
@@ -96,58 +100,49 @@ func ApiKeyMiddleware(apiKeyService service.ApiKeyService, responseHelper *respo
 	}
 }
 
-// AuthKeyMiddleware validates auth keys (alternative authentication method)
-func AuthKeyMiddleware(apiKeyService service.ApiKeyService, responseHelper *response.I18nResponseHelper) fiber.Handler {
+// H2HSignatureMiddleware verifies HMAC request signatures for host-to-host API keys.
+// It must run after a middleware that validated the API key. Keys without H2H pass through,
+// keys with H2H must sign every request with their auth key instead of sending it:
+//
+//	X-Timestamp: unix seconds
+//	X-Nonce:     unique value per request (max 64 chars)
+//	X-Signature: hex(HMAC-SHA256(auth_key, METHOD\npath\ntimestamp\nnonce\nhex(SHA-256(body))))
+func H2HSignatureMiddleware(signatureService service.RequestSignatureService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get auth key from header
-		authKey := c.Get("X-Auth-Key")
-		if authKey == "" {
-			if responseHelper != nil {
-				return responseHelper.ErrorWithI18n(c, fiber.StatusBadRequest, "auth_key_required", nil)
-			}
-			return response.BadRequest(c, "Auth key is required", "")
+		apiKeyEntity, ok := c.Locals("api_key").(*entity.ApiKey)
+		if !ok {
+			return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "api_key_required", nil)
 		}
 
-		// Validate auth key
-		ctx := context.Background()
-		apiKeyEntity, err := apiKeyService.ValidateAuthKey(ctx, authKey)
+		if !apiKeyEntity.IsH2HEnabled() {
+			return c.Next()
+		}
+
+		err := signatureService.Verify(c.Context(), apiKeyEntity, service.SignedRequest{
+			Method:    c.Method(),
+			Path:      c.OriginalURL(),
+			Timestamp: c.Get("X-Timestamp"),
+			Nonce:     c.Get("X-Nonce"),
+			Signature: c.Get("X-Signature"),
+			Body:      c.Body(),
+		})
 		if err != nil {
-			if responseHelper != nil {
-				return responseHelper.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+			switch {
+			case errors.Is(err, service.ErrSignatureRequired):
+				return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "signature_required", nil)
+			case errors.Is(err, service.ErrSignatureExpired):
+				return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "signature_expired", nil)
+			case errors.Is(err, service.ErrNonceReused):
+				return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "nonce_reused", nil)
+			case errors.Is(err, service.ErrSignatureInvalid):
+				return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "signature_invalid", nil)
+			default:
+				return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
 					"error": err.Error(),
 				})
 			}
-			return response.InternalServerError(c, "Internal server error", err.Error())
-		}
-
-		if apiKeyEntity == nil {
-			if responseHelper != nil {
-				return responseHelper.ErrorWithI18n(c, fiber.StatusBadRequest, "invalid_auth_key", nil)
-			}
-			return response.BadRequest(c, "Invalid or inactive auth key", "")
 		}
 
-		// Check IP whitelist if configured
-		clientIP := c.IP()
-		if !apiKeyEntity.IsIPWhitelisted(clientIP) {
-			if responseHelper != nil {
-				return responseHelper.ErrorWithI18n(c, fiber.StatusBadRequest, "ip_not_whitelisted", nil)
-			}
-			return response.BadRequest(c, "IP address not whitelisted", "")
-		}
-
-		// Store API key info in context
-		c.Locals("api_key_id", apiKeyEntity.ID)
-		c.Locals("api_key_name", apiKeyEntity.Name)
-		c.Locals("api_key_h2h", apiKeyEntity.IsH2HEnabled())
-
-		// Log access (async with timeout)
-		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			_ = apiKeyService.LogApiKeyAccess(ctx, apiKeyEntity.ID)
-		}()
-
 		return c.Next()
 	}
 }
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Policy decides whether the caller may act on the resource of the request
+type Policy func(c *fiber.Ctx, permissions entity.PermissionSet) bool
+
+// PermissionMiddleware resolves the permissions granted by the roles of the user.
+// It must run after PrivateMiddleware and before Authorize.
+func PermissionMiddleware(roleService service.RoleService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		roles, err := currentRoles(c, roleService)
+		if err != nil {
+			if errors.Is(err, errNoPrivateToken) {
+				return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+			}
+			return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+
+		permissions, err := roleService.GetPermissions(c.Context(), roles)
+		if err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+
+		c.Locals("roles", roles)
+		c.Locals("permissions", permissions)
+		return c.Next()
+	}
+}
+
+// errNoPrivateToken is returned by currentRoles when PrivateMiddleware did not run
+var errNoPrivateToken = errors.New("no private token in context")
+
+// currentRoles returns the roles of the token that the user still holds. A revoked role
+// stops applying with the next request, an assigned one with the next login or token refresh.
+func currentRoles(c *fiber.Ctx, roleService service.RoleService) ([]string, error) {
+	claims, ok := c.Locals("jwt_claims").(*service.PrivateJWTClaims)
+	if !ok {
+		return nil, errNoPrivateToken
+	}
+	if len(claims.Roles) == 0 {
+		return nil, nil
+	}
+
+	held, err := roleService.GetUserRoles(c.Context(), claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]string, 0, len(claims.Roles))
+	for _, role := range claims.Roles {
+		if slices.Contains(held, role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+// HasPermission checks the permissions resolved by PermissionMiddleware, for handlers
+// that restrict single fields of a request rather than the whole route
+func HasPermission(c *fiber.Ctx, permission string) bool {
+	permissions, ok := c.Locals("permissions").(entity.PermissionSet)
+	return ok && permissions.Has(permission)
+}
+
+// Authorize only lets callers through that hold the permission and pass every policy
+func Authorize(permission string, policies ...Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		permissions, ok := c.Locals("permissions").(entity.PermissionSet)
+		if !ok {
+			return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+		}
+
+		if !permissions.Has(permission) {
+			return response.ErrorWithI18n(c, fiber.StatusForbidden, "permission_denied", map[string]interface{}{
+				"Permission": permission,
+			})
+		}
+
+		for _, policy := range policies {
+			if !policy(c, permissions) {
+				return response.ErrorWithI18n(c, fiber.StatusForbidden, "resource_access_denied", nil)
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// OwnerOrAdmin passes when the route parameter is the authenticated user,
+// or when the caller may manage other users
+func OwnerOrAdmin(param string) Policy {
+	return func(c *fiber.Ctx, permissions entity.PermissionSet) bool {
+		if permissions.Has(constant.PermissionUsersManage) {
+			return true
+		}
+
+		userID, ok := c.Locals("user_id").(int)
+		if !ok {
+			return false
+		}
+
+		id, err := strconv.Atoi(c.Params(param))
+		return err == nil && id == userID
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"go-rest-api-template/pkg/ipfilter"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ClientIPMiddleware resolves the real client address behind the configured trusted proxies
+// (server.trusted_proxies) and stores it as client_ip. X-Forwarded-For is only honoured when the
+// request comes from a trusted proxy, and is read right to left: the first address that is not a
+// trusted proxy is the client. Entries further left can be forged by the client.
+func ClientIPMiddleware(trustedProxies *ipfilter.List) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals("client_ip", resolveClientIP(c, trustedProxies))
+		return c.Next()
+	}
+}
+
+// GetClientIP returns the address resolved by ClientIPMiddleware, or the connection address
+func GetClientIP(c *fiber.Ctx) string {
+	if ip, ok := c.Locals("client_ip").(string); ok && ip != "" {
+		return ip
+	}
+	return c.IP()
+}
+
+func resolveClientIP(c *fiber.Ctx, trustedProxies *ipfilter.List) string {
+	remoteIP := c.Context().RemoteIP()
+	if trustedProxies == nil || trustedProxies.IsEmpty() || !trustedProxies.ContainsIP(remoteIP) {
+		return remoteIP.String()
+	}
+
+	forwarded := strings.Split(c.Get(fiber.HeaderXForwardedFor), ",")
+	client := remoteIP.String()
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break // A malformed entry ends the chain we can trust
+		}
+		client = ip.String()
+		if !trustedProxies.ContainsIP(ip) {
+			break
+		}
+	}
+
+	return client
+}
@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"errors"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -62,6 +64,31 @@ func (h *ContextHelper) GetUserEmail(c *fiber.Ctx) (string, error) {
 	return "", errors.New("user_email not found in context")
 }
 
+// GetTokenClaims extracts the validated private token claims from context (from JWT)
+func (h *ContextHelper) GetTokenClaims(c *fiber.Ctx) (*service.PrivateJWTClaims, error) {
+	if claims, ok := c.Locals("jwt_claims").(*service.PrivateJWTClaims); ok {
+		return claims, nil
+	}
+	return nil, errors.New("jwt_claims not found in context")
+}
+
+// GetImpersonatorID extracts the admin acting as the user from context (from the act claim
+// of an impersonation token). It fails for tokens the user obtained by logging in.
+func (h *ContextHelper) GetImpersonatorID(c *fiber.Ctx) (int, error) {
+	if id, ok := c.Locals("impersonator_id").(int); ok {
+		return id, nil
+	}
+	return 0, errors.New("impersonator_id not found in context")
+}
+
+// GetPermissions extracts the permissions granted by the user's roles from context
+func (h *ContextHelper) GetPermissions(c *fiber.Ctx) entity.PermissionSet {
+	if permissions, ok := c.Locals("permissions").(entity.PermissionSet); ok {
+		return permissions
+	}
+	return entity.PermissionSet{}
+}
+
 // IsAuthenticated checks if user is authenticated (has valid JWT token)
 func (h *ContextHelper) IsAuthenticated(c *fiber.Ctx) bool {
 	if auth, ok := c.Locals("authenticated").(bool); ok {
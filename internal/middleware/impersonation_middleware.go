@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"errors"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ImpersonationAuditMiddleware writes every request made with an impersonation token to
+// the audit log, the admin as actor and the impersonated user as entity. It must run
+// after PrivateMiddleware, which puts the impersonator_id in the context.
+func ImpersonationAuditMiddleware(auditService service.AuditService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		impersonatorID, ok := c.Locals("impersonator_id").(int)
+		if !ok {
+			return c.Next()
+		}
+
+		err := c.Next()
+
+		// Errors returned by handlers only become responses in the error handler
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		userID, _ := c.Locals("user_id").(int)
+		auditService.Record(c.Context(), service.AuditEntry{
+			Action:      constant.AuditActionImpersonatedReq,
+			EntityType:  constant.AuditEntityUser,
+			EntityID:    userID,
+			ActorUserID: impersonatorID,
+			Details: map[string]interface{}{
+				"method": c.Method(),
+				"path":   c.Path(),
+				"status": status,
+			},
+		})
+
+		return err
+	}
+}
+
+// DenyImpersonation rejects impersonation tokens on sensitive routes, like password and
+// two-factor changes, which only the user may perform. It must run after PrivateMiddleware.
+func DenyImpersonation() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if _, ok := c.Locals("impersonator_id").(int); ok {
+			return response.ErrorWithI18n(c, fiber.StatusForbidden, "impersonation_forbidden", nil)
+		}
+
+		return c.Next()
+	}
+}
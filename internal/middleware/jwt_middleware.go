@@ -38,13 +38,14 @@ func PublicMiddleware(apiKeyService service.ApiKeyService, jwtService service.JW
 			return response.BadRequest(c, "Invalid or inactive API key", "")
 		}
 
-		// Check IP whitelist if configured
-		clientIP := c.IP()
-		if !apiKeyEntity.IsIPWhitelisted(clientIP) {
+		// Check IP whitelist and denylist if configured
+		clientIP := GetClientIP(c)
+		if !apiKeyEntity.IsIPAllowed(clientIP) {
 			return response.BadRequest(c, "IP address not whitelisted", "")
 		}
 
 		// Store API key info in context
+		c.Locals("api_key", apiKeyEntity)
 		c.Locals("api_key_id", apiKeyEntity.ID)
 		c.Locals("api_key_name", apiKeyEntity.Name)
 		c.Locals("api_key_h2h", apiKeyEntity.IsH2HEnabled())
@@ -78,8 +79,9 @@ func PublicMiddleware(apiKeyService service.ApiKeyService, jwtService service.JW
 		}
 
 		// Log API key access (async with timeout)
+		actor := service.AuditActorFromContext(c.Context())
 		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			ctx, cancel := context.WithTimeout(service.WithAuditActor(context.Background(), actor), 5*time.Second)
 			defer cancel()
 			_ = apiKeyService.LogApiKeyAccess(ctx, apiKeyEntity.ID)
 		}()
@@ -89,7 +91,7 @@ func PublicMiddleware(apiKeyService service.ApiKeyService, jwtService service.JW
 }
 
 // PrivateMiddleware validates both API keys and private JWT tokens for private endpoints
-func PrivateMiddleware(apiKeyService service.ApiKeyService, jwtService service.JWTService) fiber.Handler {
+func PrivateMiddleware(apiKeyService service.ApiKeyService, jwtService service.JWTService, sessionService service.SessionService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Step 1: Validate API Key first
 		apiKey := c.Get("X-API-Key")
@@ -108,9 +110,9 @@ func PrivateMiddleware(apiKeyService service.ApiKeyService, jwtService service.J
 			return response.BadRequest(c, "Invalid or inactive API key", "")
 		}
 
-		// Check IP whitelist if configured
-		clientIP := c.IP()
-		if !apiKeyEntity.IsIPWhitelisted(clientIP) {
+		// Check IP whitelist and denylist if configured
+		clientIP := GetClientIP(c)
+		if !apiKeyEntity.IsIPAllowed(clientIP) {
 			return response.BadRequest(c, "IP address not whitelisted", "")
 		}
 
@@ -136,7 +138,14 @@ func PrivateMiddleware(apiKeyService service.ApiKeyService, jwtService service.J
 			return response.BadRequest(c, "Token API key doesn't match request API key", "")
 		}
 
+		// Step 3: Check the login session of the token is still active
+		if !isSessionActive(ctx, c, sessionService, claims) {
+			return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "session_terminated", nil)
+		}
+		sessionID, _ := c.Locals("session_id").(int)
+
 		// Store API key info in context
+		c.Locals("api_key", apiKeyEntity)
 		c.Locals("api_key_id", apiKeyEntity.ID)
 		c.Locals("api_key_name", apiKeyEntity.Name)
 		c.Locals("api_key_h2h", apiKeyEntity.IsH2HEnabled())
@@ -146,12 +155,18 @@ func PrivateMiddleware(apiKeyService service.ApiKeyService, jwtService service.J
 		c.Locals("username", claims.Username)
 		c.Locals("user_email", claims.Email)
 		c.Locals("user", user)
+		c.Locals("jwt_claims", claims)
+		setImpersonator(c, claims)
 
-		// Log API key access (async with timeout)
+		// Log API key access and session activity (async with timeout)
+		actor := service.AuditActorFromContext(c.Context())
 		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			ctx, cancel := context.WithTimeout(service.WithAuditActor(context.Background(), actor), 5*time.Second)
 			defer cancel()
 			_ = apiKeyService.LogApiKeyAccess(ctx, apiKeyEntity.ID)
+			if sessionID != 0 {
+				_ = sessionService.Touch(ctx, sessionID)
+			}
 		}()
 
 		return c.Next()
@@ -160,7 +175,7 @@ func PrivateMiddleware(apiKeyService service.ApiKeyService, jwtService service.J
 
 // OptionalPrivateJWTMiddleware validates API key and optionally validates private JWT token
 // Useful for endpoints that can work with or without user authentication
-func OptionalPrivateJWTMiddleware(apiKeyService service.ApiKeyService, jwtService service.JWTService) fiber.Handler {
+func OptionalPrivateJWTMiddleware(apiKeyService service.ApiKeyService, jwtService service.JWTService, sessionService service.SessionService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Step 1: Validate API Key (required)
 		apiKey := c.Get("X-API-Key")
@@ -179,13 +194,14 @@ func OptionalPrivateJWTMiddleware(apiKeyService service.ApiKeyService, jwtServic
 			return response.BadRequest(c, "Invalid or inactive API key", "")
 		}
 
-		// Check IP whitelist if configured
-		clientIP := c.IP()
-		if !apiKeyEntity.IsIPWhitelisted(clientIP) {
+		// Check IP whitelist and denylist if configured
+		clientIP := GetClientIP(c)
+		if !apiKeyEntity.IsIPAllowed(clientIP) {
 			return response.BadRequest(c, "IP address not whitelisted", "")
 		}
 
 		// Store API key info in context
+		c.Locals("api_key", apiKeyEntity)
 		c.Locals("api_key_id", apiKeyEntity.ID)
 		c.Locals("api_key_name", apiKeyEntity.Name)
 		c.Locals("api_key_h2h", apiKeyEntity.IsH2HEnabled())
@@ -198,14 +214,16 @@ func OptionalPrivateJWTMiddleware(apiKeyService service.ApiKeyService, jwtServic
 				// Validate private JWT token
 				claims, tokenApiKey, user, err := jwtService.ValidatePrivateToken(tokenString)
 				if err == nil && tokenApiKey != nil {
-					// Verify token API key matches request API key
-					if tokenApiKey.ID == apiKeyEntity.ID {
+					// Verify token API key matches request API key and the session is still active
+					if tokenApiKey.ID == apiKeyEntity.ID && isSessionActive(ctx, c, sessionService, claims) {
 						// Store user info if token is valid
 						c.Locals("user_id", claims.UserID)
 						c.Locals("username", claims.Username)
 						c.Locals("user_email", claims.Email)
 						c.Locals("user", user)
+						c.Locals("jwt_claims", claims)
 						c.Locals("authenticated", true)
+						setImpersonator(c, claims)
 					} else {
 						// Token API key doesn't match
 						c.Locals("authenticated", false)
@@ -221,8 +239,9 @@ func OptionalPrivateJWTMiddleware(apiKeyService service.ApiKeyService, jwtServic
 		}
 
 		// Log API key access (async with timeout)
+		actor := service.AuditActorFromContext(c.Context())
 		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			ctx, cancel := context.WithTimeout(service.WithAuditActor(context.Background(), actor), 5*time.Second)
 			defer cancel()
 			_ = apiKeyService.LogApiKeyAccess(ctx, apiKeyEntity.ID)
 		}()
@@ -230,3 +249,25 @@ func OptionalPrivateJWTMiddleware(apiKeyService service.ApiKeyService, jwtServic
 		return c.Next()
 	}
 }
+
+// isSessionActive checks the sid claim of a private token and stores the session id in context.
+// Tokens without sid were issued before session tracking and are accepted as is.
+func isSessionActive(ctx context.Context, c *fiber.Ctx, sessionService service.SessionService, claims *service.PrivateJWTClaims) bool {
+	if claims.SessionID == "" {
+		return true
+	}
+
+	session, err := sessionService.Validate(ctx, claims.SessionID)
+	if err != nil {
+		return false
+	}
+	c.Locals("session_id", session.ID)
+	return true
+}
+
+// setImpersonator stores the admin of an impersonation token in context as "impersonator_id"
+func setImpersonator(c *fiber.Ctx, claims *service.PrivateJWTClaims) {
+	if claims.Act != nil {
+		c.Locals("impersonator_id", claims.Act.UserID)
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireMFAMiddleware only lets users with two-factor authentication enabled through
+// (mfa.required_for_admins). It must run after PrivateMiddleware, which puts the
+// authenticated user_id in the context.
+func RequireMFAMiddleware(mfaService service.MFAService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("user_id").(int)
+		if !ok {
+			return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "auth_required", nil)
+		}
+
+		enabled, err := mfaService.IsEnabled(c.Context(), userID)
+		if err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		if !enabled {
+			return response.ErrorWithI18n(c, fiber.StatusForbidden, "mfa_setup_required", nil)
+		}
+
+		return c.Next()
+	}
+}
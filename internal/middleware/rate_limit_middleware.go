@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimitMiddleware limits requests per API key, using the limits stored on the key.
+// It must run after a middleware that validated the API key.
+func RateLimitMiddleware(rateLimitService service.RateLimitService) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		apiKeyEntity, ok := c.Locals("api_key").(*entity.ApiKey)
+		if !ok {
+			return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "api_key_required", nil)
+		}
+
+		decision, err := rateLimitService.AllowApiKey(c.Context(), apiKeyEntity)
+		if err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+
+		return applyRateLimitDecision(c, decision)
+	}
+}
+
+// IPRateLimitMiddleware limits requests per client IP for a single action, e.g. login.
+// It protects endpoints that are attractive for credential stuffing regardless of the API key used.
+func IPRateLimitMiddleware(rateLimitService service.RateLimitService, action string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		decision, err := rateLimitService.AllowIP(c.Context(), action, GetClientIP(c))
+		if err != nil {
+			return response.ErrorWithI18n(c, fiber.StatusInternalServerError, "internal_server", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+
+		return applyRateLimitDecision(c, decision)
+	}
+}
+
+// applyRateLimitDecision sets the X-RateLimit-* headers and rejects the request with 429 when needed
+func applyRateLimitDecision(c *fiber.Ctx, decision *service.RateLimitDecision) error {
+	c.Set("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+	c.Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	c.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.ResetAfter)))
+	if decision.QuotaLimit > 0 {
+		c.Set("X-RateLimit-Quota-Limit", strconv.Itoa(decision.QuotaLimit))
+		c.Set("X-RateLimit-Quota-Remaining", strconv.Itoa(decision.QuotaRemaining))
+	}
+
+	if decision.Allowed {
+		return c.Next()
+	}
+
+	retryAfter := ceilSeconds(decision.RetryAfter)
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	if decision.QuotaExceeded {
+		return response.ErrorWithI18n(c, fiber.StatusTooManyRequests, "quota_exceeded", nil)
+	}
+	return response.ErrorWithI18n(c, fiber.StatusTooManyRequests, "rate_limit_exceeded", map[string]interface{}{
+		"RetryAfter": retryAfter,
+	})
+}
+
+// ceilSeconds rounds up to whole seconds, so clients never retry too early
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
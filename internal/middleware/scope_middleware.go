@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireScopes only lets API keys through that were granted every given scope.
+// It must run after a middleware that validated the API key.
+func RequireScopes(scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		apiKeyEntity, ok := c.Locals("api_key").(*entity.ApiKey)
+		if !ok {
+			return response.ErrorWithI18n(c, fiber.StatusUnauthorized, "api_key_required", nil)
+		}
+
+		for _, scope := range scopes {
+			if !apiKeyEntity.HasScope(scope) {
+				return response.ErrorWithI18n(c, fiber.StatusForbidden, "insufficient_scope", map[string]interface{}{
+					"Scope": scope,
+				})
+			}
+		}
+
+		return c.Next()
+	}
+}
@@ -1,38 +1,119 @@
 package model
 
 import (
+	"go-rest-api-template/pkg/validator"
 	"time"
 )
 
 // ApiKeyModel - Database model (infrastructure concern) - Read-only for JWT middleware
 type ApiKeyModel struct {
-	ID          int        `db:"id" json:"id"`
-	Name        string     `db:"name" json:"name"`
-	Description *string    `db:"description" json:"description"`
-	ApiKey      string     `db:"api_key" json:"api_key"`
-	AuthKey     string     `db:"auth_key" json:"auth_key"`
-	Status      string     `db:"status" json:"status"`
-	H2H         string     `db:"h2h" json:"h2h"`
+	ID               int     `db:"id" json:"id"`
+	Name             string  `db:"name" json:"name"`
+	Description      *string `db:"description" json:"description"`
+	ApiKeyPrefix     *string `db:"api_key_prefix" json:"api_key_prefix"`
+	ApiKeyHash       *string `db:"api_key_hash" json:"-"`
+	ApiKey           *string `db:"api_key" json:"-"`  // legacy plaintext, cleared by migrate-up
+	AuthKey          *string `db:"auth_key" json:"-"` // legacy plaintext, cleared by migrate-up
+	AuthKeyEncrypted *string `db:"auth_key_encrypted" json:"-"`
+
+	PreviousApiKeyPrefix     *string    `db:"previous_api_key_prefix" json:"-"`
+	PreviousApiKeyHash       *string    `db:"previous_api_key_hash" json:"-"`
+	PreviousAuthKeyEncrypted *string    `db:"previous_auth_key_encrypted" json:"-"`
+	PreviousKeyExpiresAt     *time.Time `db:"previous_key_expires_at" json:"previous_key_expires_at"`
+
+	Status string  `db:"status" json:"status"`
+	H2H    string  `db:"h2h" json:"h2h"`
+	Scopes *string `db:"scopes" json:"scopes"` // comma separated
+
+	RateLimitPerSecond int `db:"rate_limit_per_second" json:"rate_limit_per_second"`
+	RateLimitBurst     int `db:"rate_limit_burst" json:"rate_limit_burst"`
+	DailyQuota         int `db:"daily_quota" json:"daily_quota"`
+
 	LastAccess  *time.Time `db:"last_access" json:"last_access"`
 	IPWhitelist *string    `db:"ip_whitelist" json:"ip_whitelist"`
+	IPDenylist  *string    `db:"ip_denylist" json:"ip_denylist"`
 	CreatedAt   *time.Time `db:"created_at" json:"created_at"`
 	CreatedBy   int        `db:"created_by" json:"created_by"`
 	UpdatedAt   *time.Time `db:"updated_at" json:"updated_at"`
 	UpdatedBy   *int       `db:"updated_by" json:"updated_by"`
 }
 
-// ApiKeyResponse - DTO for HTTP responses (read-only)
+// ApiKeyCreateRequest - DTO for creating API keys
+type ApiKeyCreateRequest struct {
+	Name        string   `json:"name" validate:"required,min=3,max=256"`
+	Description *string  `json:"description" validate:"omitempty,max=1000"`
+	H2H         string   `json:"h2h" validate:"omitempty,oneof=Y N"`
+	Scopes      []string `json:"scopes" validate:"omitempty,dive,oneof=* auth:register auth:login users:read users:write"`
+	IPWhitelist *string  `json:"ip_whitelist" validate:"omitempty,max=1024"` // comma separated addresses and CIDR ranges
+	IPDenylist  *string  `json:"ip_denylist" validate:"omitempty,max=1024"`
+
+	RateLimitPerSecond int `json:"rate_limit_per_second" validate:"min=0"`
+	RateLimitBurst     int `json:"rate_limit_burst" validate:"min=0"`
+	DailyQuota         int `json:"daily_quota" validate:"min=0"`
+}
+
+// ApiKeyUpdateRequest - DTO for updating API key settings (secrets are changed by rotation only)
+type ApiKeyUpdateRequest struct {
+	Name        string   `json:"name" validate:"omitempty,min=3,max=256"`
+	Description *string  `json:"description" validate:"omitempty,max=1000"`
+	Status      string   `json:"status" validate:"omitempty,oneof=active inactive"`
+	H2H         string   `json:"h2h" validate:"omitempty,oneof=Y N"`
+	Scopes      []string `json:"scopes" validate:"omitempty,dive,oneof=* auth:register auth:login users:read users:write"`
+	IPWhitelist *string  `json:"ip_whitelist" validate:"omitempty,max=1024"` // comma separated addresses and CIDR ranges
+	IPDenylist  *string  `json:"ip_denylist" validate:"omitempty,max=1024"`
+
+	RateLimitPerSecond *int `json:"rate_limit_per_second" validate:"omitempty,min=0"`
+	RateLimitBurst     *int `json:"rate_limit_burst" validate:"omitempty,min=0"`
+	DailyQuota         *int `json:"daily_quota" validate:"omitempty,min=0"`
+}
+
+// ApiKeyRotateRequest - DTO for rotating API key secrets
+type ApiKeyRotateRequest struct {
+	GracePeriodHours int `json:"grace_period_hours" validate:"min=0,max=720"` // old secrets keep working this long
+}
+
+// ApiKeyResponse - DTO for HTTP responses
 type ApiKeyResponse struct {
-	ID          int        `json:"id"`
-	Name        string     `json:"name"`
-	Description *string    `json:"description"`
-	Status      string     `json:"status"`
-	H2H         string     `json:"h2h"`
-	LastAccess  *time.Time `json:"last_access"`
-	IPWhitelist *string    `json:"ip_whitelist"`
-	CreatedAt   *time.Time `json:"created_at"`
-	CreatedBy   int        `json:"created_by"`
-	UpdatedAt   *time.Time `json:"updated_at"`
-	UpdatedBy   *int       `json:"updated_by"`
+	ID                   int        `json:"id"`
+	Name                 string     `json:"name"`
+	Description          *string    `json:"description"`
+	ApiKeyPrefix         string     `json:"api_key_prefix"`
+	PreviousKeyExpiresAt *time.Time `json:"previous_key_expires_at"`
+	Status               string     `json:"status"`
+	H2H                  string     `json:"h2h"`
+	Scopes               []string   `json:"scopes"`
+	RateLimitPerSecond   int        `json:"rate_limit_per_second"`
+	RateLimitBurst       int        `json:"rate_limit_burst"`
+	DailyQuota           int        `json:"daily_quota"`
+	LastAccess           *time.Time `json:"last_access"`
+	IPWhitelist          *string    `json:"ip_whitelist"`
+	IPDenylist           *string    `json:"ip_denylist"`
+	CreatedAt            *time.Time `json:"created_at"`
+	CreatedBy            int        `json:"created_by"`
+	UpdatedAt            *time.Time `json:"updated_at"`
+	UpdatedBy            *int       `json:"updated_by"`
 	// Note: ApiKey and AuthKey are intentionally excluded for security
 }
+
+// ApiKeyCreatedResponse - DTO returned once when a key is created.
+// The secrets are only stored hashed/encrypted and cannot be shown again.
+type ApiKeyCreatedResponse struct {
+	ApiKeyResponse
+	ApiKey  string `json:"api_key"`
+	AuthKey string `json:"auth_key"`
+}
+
+// Validate validates ApiKeyCreateRequest
+func (r *ApiKeyCreateRequest) Validate() error {
+	return validator.ValidateStruct(r)
+}
+
+// Validate validates ApiKeyUpdateRequest
+func (r *ApiKeyUpdateRequest) Validate() error {
+	return validator.ValidateStruct(r)
+}
+
+// Validate validates ApiKeyRotateRequest
+func (r *ApiKeyRotateRequest) Validate() error {
+	return validator.ValidateStruct(r)
+}
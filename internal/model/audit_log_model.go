@@ -0,0 +1,57 @@
+package model
+
+import (
+	"go-rest-api-template/pkg/validator"
+	"time"
+)
+
+// AuditLogModel - Database model (infrastructure concern)
+type AuditLogModel struct {
+	ID          int       `db:"id" json:"id"`
+	ActorUserID *int      `db:"actor_user_id" json:"actor_user_id"`
+	ApiKeyID    *int      `db:"api_key_id" json:"api_key_id"`
+	IP          string    `db:"ip" json:"ip"`
+	Action      string    `db:"action" json:"action"`
+	EntityType  string    `db:"entity_type" json:"entity_type"`
+	EntityID    *int      `db:"entity_id" json:"entity_id"`
+	Before      *string   `db:"before" json:"before"` // JSON
+	After       *string   `db:"after" json:"after"`   // JSON
+	Details     *string   `db:"details" json:"details"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// AuditLogListQuery - DTO for GET /admin/audit-logs query parameters
+type AuditLogListQuery struct {
+	Page        int    `query:"page" validate:"omitempty,min=1"`
+	Limit       int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	After       string `query:"after" validate:"omitempty,max=512"`
+	Sort        string `query:"sort" validate:"omitempty,max=50"`
+	ActorUserID int    `query:"actor_user_id" validate:"omitempty,min=1"`
+	ApiKeyID    int    `query:"api_key_id" validate:"omitempty,min=1"`
+	Action      string `query:"action" validate:"omitempty,max=64"`
+	EntityType  string `query:"entity_type" validate:"omitempty,max=32"`
+	EntityID    int    `query:"entity_id" validate:"omitempty,min=1"`
+	IP          string `query:"ip" validate:"omitempty,ip"`
+	CreatedFrom string `query:"created_from" validate:"omitempty,max=35"`
+	CreatedTo   string `query:"created_to" validate:"omitempty,max=35"`
+}
+
+// AuditLogResponse - DTO for HTTP responses
+type AuditLogResponse struct {
+	ID          int                    `json:"id"`
+	ActorUserID *int                   `json:"actor_user_id"`
+	ApiKeyID    *int                   `json:"api_key_id"`
+	IP          string                 `json:"ip"`
+	Action      string                 `json:"action"`
+	EntityType  string                 `json:"entity_type"`
+	EntityID    *int                   `json:"entity_id"`
+	Before      map[string]interface{} `json:"before,omitempty"`
+	After       map[string]interface{} `json:"after,omitempty"`
+	Details     map[string]interface{} `json:"details,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+// Validate validates AuditLogListQuery
+func (r *AuditLogListQuery) Validate() error {
+	return validator.ValidateStruct(r)
+}
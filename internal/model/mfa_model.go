@@ -0,0 +1,15 @@
+package model
+
+import (
+	"time"
+)
+
+// UserMFAModel - Database model (infrastructure concern)
+type UserMFAModel struct {
+	UserID       int        `db:"user_id" json:"user_id"`
+	Secret       string     `db:"secret" json:"-"`
+	EnabledAt    *time.Time `db:"enabled_at" json:"enabled_at,omitempty"`
+	LastUsedStep int64      `db:"last_used_step" json:"-"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time  `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+)
+
+// RefreshTokenModel - Database model (infrastructure concern)
+type RefreshTokenModel struct {
+	ID           int        `db:"id" json:"id"`
+	UserID       int        `db:"user_id" json:"user_id"`
+	ApiKeyID     int        `db:"api_key_id" json:"api_key_id"`
+	FamilyID     string     `db:"family_id" json:"family_id"`
+	SessionToken *string    `db:"session_token" json:"-"`
+	TokenHash    string     `db:"token_hash" json:"-"`
+	ExpiresAt    time.Time  `db:"expires_at" json:"expires_at"`
+	RotatedAt    *time.Time `db:"rotated_at" json:"rotated_at,omitempty"`
+	RevokedAt    *time.Time `db:"revoked_at" json:"revoked_at,omitempty"`
+	ReplacedBy   *int       `db:"replaced_by" json:"replaced_by,omitempty"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+}
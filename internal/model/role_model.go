@@ -0,0 +1,30 @@
+package model
+
+import (
+	"go-rest-api-template/pkg/validator"
+	"time"
+)
+
+// RoleModel - Database model (infrastructure concern)
+type RoleModel struct {
+	ID          int       `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description *string   `db:"description" json:"description"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
+
+// AssignRoleRequest - DTO for assigning a role to a user
+type AssignRoleRequest struct {
+	Role string `json:"role" validate:"required,max=64"`
+}
+
+// Validate validates AssignRoleRequest
+func (r *AssignRoleRequest) Validate() error {
+	return validator.ValidateStruct(r)
+}
+
+// UserRolesResponse - DTO for the roles of a user
+type UserRolesResponse struct {
+	UserID int      `json:"user_id"`
+	Roles  []string `json:"roles"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+// SessionModel - Database model (infrastructure concern)
+type SessionModel struct {
+	ID           int        `db:"id" json:"id"`
+	UserID       int        `db:"user_id" json:"user_id"`
+	ApiKeyID     *int       `db:"api_key_id" json:"api_key_id"`
+	SessionToken string     `db:"session_token" json:"-"`
+	IPAddress    *string    `db:"ip_address" json:"ip_address"`
+	UserAgent    *string    `db:"user_agent" json:"user_agent"`
+	ExpiresAt    time.Time  `db:"expires_at" json:"expires_at"`
+	LastSeenAt   *time.Time `db:"last_seen_at" json:"last_seen_at"`
+	TerminatedAt *time.Time `db:"terminated_at" json:"terminated_at"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt    *time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// SessionResponse - DTO for HTTP responses ("active devices")
+type SessionResponse struct {
+	ID         int        `json:"id"`
+	ApiKeyID   int        `json:"api_key_id"`
+	IPAddress  string     `json:"ip_address"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	Current    bool       `json:"current"`
+}
@@ -7,27 +7,36 @@ import (
 
 // UserModel - Database model (infrastructure concern)
 type UserModel struct {
-	ID                 int        `db:"id" json:"id"`
-	Username           string     `db:"username" json:"username"`
-	AuthKey            string     `db:"auth_key" json:"-"`
-	PasswordHash       string     `db:"password_hash" json:"-"`
-	PasswordResetToken *string    `db:"password_reset_token" json:"-"`
-	Email              string     `db:"email" json:"email"`
-	Status             string     `db:"status" json:"status"`
-	CreatedAt          time.Time  `db:"created_at" json:"created_at"`
-	CreatedBy          *int       `db:"created_by" json:"created_by,omitempty"`
-	UpdatedAt          *time.Time `db:"updated_at" json:"updated_at,omitempty"`
-	UpdatedBy          *int       `db:"updated_by" json:"updated_by,omitempty"`
-	DeletedAt          *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
-	DeletedBy          *int       `db:"deleted_by" json:"deleted_by,omitempty"`
-	VerificationToken  *string    `db:"verification_token" json:"-"`
+	ID                     int        `db:"id" json:"id"`
+	Username               string     `db:"username" json:"username"`
+	AuthKey                string     `db:"auth_key" json:"-"`
+	PasswordHash           string     `db:"password_hash" json:"-"`
+	PasswordResetToken     *string    `db:"password_reset_token" json:"-"`
+	PasswordResetExpiresAt *time.Time `db:"password_reset_expires_at" json:"-"`
+	Email                  string     `db:"email" json:"email"`
+	FullName               string     `db:"full_name" json:"full_name"`
+	Phone                  string     `db:"phone" json:"phone"`
+	Locale                 string     `db:"locale" json:"locale"`
+	Timezone               string     `db:"timezone" json:"timezone"`
+	PendingEmail           *string    `db:"pending_email" json:"pending_email,omitempty"`
+	Status                 string     `db:"status" json:"status"`
+	FailedLoginAttempts    int        `db:"failed_login_attempts" json:"-"`
+	LockedUntil            *time.Time `db:"locked_until" json:"locked_until,omitempty"`
+	CreatedAt              time.Time  `db:"created_at" json:"created_at"`
+	CreatedBy              *int       `db:"created_by" json:"created_by,omitempty"`
+	UpdatedAt              *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+	UpdatedBy              *int       `db:"updated_by" json:"updated_by,omitempty"`
+	DeletedAt              *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	DeletedBy              *int       `db:"deleted_by" json:"deleted_by,omitempty"`
+	AnonymizedAt           *time.Time `db:"anonymized_at" json:"-"` // set when a purge anonymized the user
+	VerificationToken      *string    `db:"verification_token" json:"-"`
 }
 
 // UserCreateRequest - DTO for HTTP requests with comprehensive validation
 type UserCreateRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50,alphanum"`
 	Email    string `json:"email" validate:"required,email,max=100"`
-	Password string `json:"password" validate:"required,min=8,max=100"`
+	Password string `json:"password" validate:"required,password"`
 }
 
 // UserLoginRequest - DTO for login requests
@@ -40,8 +49,8 @@ type UserLoginRequest struct {
 type UserUpdateRequest struct {
 	Username string `json:"username" validate:"omitempty,min=3,max=50,alphanum"`
 	Email    string `json:"email" validate:"omitempty,email,max=100"`
-	Password string `json:"password" validate:"omitempty,min=8,max=100"`
-	Status   string `json:"status" validate:"omitempty,oneof=active inactive suspended"`
+	Password string `json:"password" validate:"omitempty,password"`
+	Status   string `json:"status" validate:"omitempty,oneof=active inactive suspended banned"`
 }
 
 // ForgotPasswordRequest - DTO for forgot password requests
@@ -52,23 +61,89 @@ type ForgotPasswordRequest struct {
 // ResetPasswordRequest - DTO for reset password requests
 type ResetPasswordRequest struct {
 	Token       string `json:"token" validate:"required,min=1,max=255"`
-	NewPassword string `json:"new_password" validate:"required,min=8,max=100"`
+	NewPassword string `json:"new_password" validate:"required,password"`
 }
 
 // ChangePasswordRequest - DTO for change password requests
 type ChangePasswordRequest struct {
 	CurrentPassword string `json:"current_password" validate:"required,min=1"`
-	NewPassword     string `json:"new_password" validate:"required,min=8,max=100,nefield=CurrentPassword"`
+	NewPassword     string `json:"new_password" validate:"required,password,nefield=CurrentPassword"`
+}
+
+// AccountErasureRequest - DTO for POST /me/erase, the password confirms the request
+type AccountErasureRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// ImpersonationRequest - DTO for POST /admin/users/:id/impersonate, the reason goes to the audit log
+type ImpersonationRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// ProfileUpdateRequest - DTO for PATCH /me, omitted fields stay unchanged and
+// empty strings clear the optional fields
+type ProfileUpdateRequest struct {
+	FullName *string `json:"full_name" validate:"omitnil,max=100"`
+	Phone    *string `json:"phone" validate:"omitnil,phone"`
+	Locale   *string `json:"locale" validate:"omitnil,max=35,locale"`
+	Timezone *string `json:"timezone" validate:"omitnil,max=64,iana_timezone"`
+	Email    *string `json:"email" validate:"omitnil,email,max=100"`
+}
+
+// UserListQuery - DTO for GET /users query parameters
+type UserListQuery struct {
+	Page        int    `query:"page" validate:"omitempty,min=1"`
+	Limit       int    `query:"limit" validate:"omitempty,min=1,max=100"`
+	After       string `query:"after" validate:"omitempty,max=512"`
+	Sort        string `query:"sort" validate:"omitempty,max=50"`
+	Status      string `query:"status" validate:"omitempty,oneof=active pending inactive suspended banned"`
+	CreatedFrom string `query:"created_from" validate:"omitempty,max=35"`
+	CreatedTo   string `query:"created_to" validate:"omitempty,max=35"`
+	Search      string `query:"search" validate:"omitempty,max=100"`
+}
+
+// UserImportRequest - DTO for the form fields of POST /admin/users/import, sent along with the file
+type UserImportRequest struct {
+	Format    string `form:"format" json:"format" validate:"omitempty,oneof=csv ndjson"` // taken from the file name when empty
+	Passwords string `form:"passwords" json:"passwords" validate:"required,oneof=hashed invite"`
+	DryRun    bool   `form:"dry_run" json:"dry_run"`
+}
+
+// UserExportQuery - DTO for POST /admin/users/export query parameters, the filters of GET /users
+type UserExportQuery struct {
+	Format      string `query:"format" validate:"omitempty,oneof=csv ndjson"`
+	Status      string `query:"status" validate:"omitempty,oneof=active pending inactive suspended banned"`
+	CreatedFrom string `query:"created_from" validate:"omitempty,max=35"`
+	CreatedTo   string `query:"created_to" validate:"omitempty,max=35"`
+	Search      string `query:"search" validate:"omitempty,max=100"`
 }
 
 // UserResponse - DTO for HTTP responses
 type UserResponse struct {
-	ID        int        `json:"id"`
-	Username  string     `json:"username"`
-	Email     string     `json:"email"`
-	Status    string     `json:"status"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at"`
+	ID          int        `json:"id"`
+	Username    string     `json:"username"`
+	Email       string     `json:"email"`
+	FullName    string     `json:"full_name"`
+	Status      string     `json:"status"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"` // set while locked out after failed logins
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // only in the list of deleted users
+}
+
+// ProfileResponse - DTO for the profile of the current user
+type ProfileResponse struct {
+	ID           int        `json:"id"`
+	Username     string     `json:"username"`
+	Email        string     `json:"email"`
+	PendingEmail *string    `json:"pending_email"` // new address waiting for verification
+	FullName     string     `json:"full_name"`
+	Phone        string     `json:"phone"`
+	Locale       string     `json:"locale"`
+	Timezone     string     `json:"timezone"`
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
 }
 
 // Validate validates UserCreateRequest
@@ -86,6 +161,16 @@ func (r *UserUpdateRequest) Validate() error {
 	return validator.ValidateStruct(r)
 }
 
+// Validate validates ProfileUpdateRequest
+func (r *ProfileUpdateRequest) Validate() error {
+	return validator.ValidateStruct(r)
+}
+
+// Validate validates UserListQuery
+func (r *UserListQuery) Validate() error {
+	return validator.ValidateStruct(r)
+}
+
 // Validate validates ForgotPasswordRequest
 func (r *ForgotPasswordRequest) Validate() error {
 	return validator.ValidateStruct(r)
@@ -100,3 +185,23 @@ func (r *ResetPasswordRequest) Validate() error {
 func (r *ChangePasswordRequest) Validate() error {
 	return validator.ValidateStruct(r)
 }
+
+// Validate validates UserImportRequest
+func (r *UserImportRequest) Validate() error {
+	return validator.ValidateStruct(r)
+}
+
+// Validate validates UserExportQuery
+func (r *UserExportQuery) Validate() error {
+	return validator.ValidateStruct(r)
+}
+
+// Validate validates AccountErasureRequest
+func (r *AccountErasureRequest) Validate() error {
+	return validator.ValidateStruct(r)
+}
+
+// Validate validates ImpersonationRequest
+func (r *ImpersonationRequest) Validate() error {
+	return validator.ValidateStruct(r)
+}
@@ -6,11 +6,13 @@ import (
 	"go-rest-api-template/internal/domain/entity"
 	"go-rest-api-template/internal/domain/repository"
 	"go-rest-api-template/internal/model"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
-// apiKeyRepositoryImpl - Infrastructure implementation (read-only)
+// apiKeyRepositoryImpl - Infrastructure implementation
 type apiKeyRepositoryImpl struct {
 	db *sqlx.DB
 }
@@ -20,34 +22,18 @@ func NewApiKeyRepository(db *sqlx.DB) repository.ApiKeyRepository {
 	return &apiKeyRepositoryImpl{db: db}
 }
 
-func (r *apiKeyRepositoryImpl) GetByApiKey(ctx context.Context, apiKey string) (*entity.ApiKey, error) {
-	var apiKeyModel model.ApiKeyModel
+func (r *apiKeyRepositoryImpl) GetByPrefix(ctx context.Context, prefix string) ([]*entity.ApiKey, error) {
+	var apiKeyModels []model.ApiKeyModel
 
-	query := `SELECT * FROM api_key WHERE api_key = ? AND status = 'active'`
-	err := r.db.GetContext(ctx, &apiKeyModel, query, apiKey)
+	// A rotated key is also found by its previous prefix while the grace period lasts
+	query := `SELECT * FROM api_key WHERE status = 'active' 
+			  AND (api_key_prefix = ? OR (previous_api_key_prefix = ? AND previous_key_expires_at > NOW()))`
+	err := r.db.SelectContext(ctx, &apiKeyModels, query, prefix, prefix)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
 		return nil, err
 	}
 
-	return r.modelToEntity(&apiKeyModel), nil
-}
-
-func (r *apiKeyRepositoryImpl) GetByAuthKey(ctx context.Context, authKey string) (*entity.ApiKey, error) {
-	var apiKeyModel model.ApiKeyModel
-
-	query := `SELECT * FROM api_key WHERE auth_key = ? AND status = 'active'`
-	err := r.db.GetContext(ctx, &apiKeyModel, query, authKey)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
-	}
-
-	return r.modelToEntity(&apiKeyModel), nil
+	return r.modelsToEntities(apiKeyModels), nil
 }
 
 func (r *apiKeyRepositoryImpl) GetByID(ctx context.Context, id int) (*entity.ApiKey, error) {
@@ -96,6 +82,76 @@ func (r *apiKeyRepositoryImpl) GetCount(ctx context.Context) (int, error) {
 	return count, err
 }
 
+func (r *apiKeyRepositoryImpl) Create(ctx context.Context, apiKey *entity.ApiKey) error {
+	query := `INSERT INTO api_key (name, description, api_key_prefix, api_key_hash, auth_key_encrypted, status, h2h, scopes, 
+			  rate_limit_per_second, rate_limit_burst, daily_quota, ip_whitelist, ip_denylist, created_at, created_by) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), ?)`
+	result, err := r.db.ExecContext(ctx, query, apiKey.Name, apiKey.Description, apiKey.ApiKeyPrefix, apiKey.ApiKeyHash,
+		apiKey.AuthKeyEncrypted, apiKey.Status, apiKey.H2H, strings.Join(apiKey.Scopes, ","), apiKey.RateLimitPerSecond,
+		apiKey.RateLimitBurst, apiKey.DailyQuota, apiKey.IPWhitelist, apiKey.IPDenylist, apiKey.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	apiKey.ID = int(id)
+
+	return nil
+}
+
+func (r *apiKeyRepositoryImpl) Update(ctx context.Context, apiKey *entity.ApiKey) error {
+	query := `UPDATE api_key SET name = ?, description = ?, status = ?, h2h = ?, scopes = ?, rate_limit_per_second = ?, 
+			  rate_limit_burst = ?, daily_quota = ?, ip_whitelist = ?, ip_denylist = ?, updated_at = NOW(), updated_by = ? 
+			  WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, apiKey.Name, apiKey.Description, apiKey.Status, apiKey.H2H,
+		strings.Join(apiKey.Scopes, ","), apiKey.RateLimitPerSecond, apiKey.RateLimitBurst, apiKey.DailyQuota,
+		apiKey.IPWhitelist, apiKey.IPDenylist, apiKey.UpdatedBy, apiKey.ID)
+	return err
+}
+
+func (r *apiKeyRepositoryImpl) Rotate(ctx context.Context, apiKey *entity.ApiKey, previousExpiresAt time.Time) error {
+	// MySQL assigns left to right, so the previous_* columns receive the secrets being replaced
+	query := `UPDATE api_key SET 
+			  previous_api_key_prefix = api_key_prefix, previous_api_key_hash = api_key_hash, 
+			  previous_auth_key_encrypted = auth_key_encrypted, previous_key_expires_at = ?, 
+			  api_key_prefix = ?, api_key_hash = ?, auth_key_encrypted = ?, updated_at = NOW(), updated_by = ? 
+			  WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, previousExpiresAt, apiKey.ApiKeyPrefix, apiKey.ApiKeyHash,
+		apiKey.AuthKeyEncrypted, apiKey.UpdatedBy, apiKey.ID)
+	return err
+}
+
+func (r *apiKeyRepositoryImpl) Revoke(ctx context.Context, id int, updatedBy *int) error {
+	// Revocation also ends any rotation grace period
+	query := `UPDATE api_key SET status = 'revoked', previous_key_expires_at = NULL, updated_at = NOW(), updated_by = ? 
+			  WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, updatedBy, id)
+	return err
+}
+
+func (r *apiKeyRepositoryImpl) GetWithPlaintextSecrets(ctx context.Context) ([]*entity.ApiKey, error) {
+	var apiKeyModels []model.ApiKeyModel
+
+	query := `SELECT * FROM api_key WHERE api_key IS NOT NULL OR auth_key IS NOT NULL`
+	err := r.db.SelectContext(ctx, &apiKeyModels, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.modelsToEntities(apiKeyModels), nil
+}
+
+func (r *apiKeyRepositoryImpl) UpdateSecrets(ctx context.Context, apiKey *entity.ApiKey) error {
+	// Clearing the plaintext columns is part of the same statement, so no row is left half converted
+	query := `UPDATE api_key SET api_key_prefix = ?, api_key_hash = ?, auth_key_encrypted = ?, api_key = NULL, auth_key = NULL 
+			  WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, query, apiKey.ApiKeyPrefix, apiKey.ApiKeyHash, apiKey.AuthKeyEncrypted, apiKey.ID)
+	return err
+}
+
 func (r *apiKeyRepositoryImpl) UpdateLastAccess(ctx context.Context, id int) error {
 	query := `UPDATE api_key SET last_access = NOW() WHERE id = ?`
 	_, err := r.db.ExecContext(ctx, query, id)
@@ -104,21 +160,53 @@ func (r *apiKeyRepositoryImpl) UpdateLastAccess(ctx context.Context, id int) err
 
 // Helper methods for model conversion
 func (r *apiKeyRepositoryImpl) modelToEntity(model *model.ApiKeyModel) *entity.ApiKey {
-	return &entity.ApiKey{
-		ID:          model.ID,
-		Name:        model.Name,
-		Description: model.Description,
-		ApiKey:      model.ApiKey,
-		AuthKey:     model.AuthKey,
-		Status:      model.Status,
-		H2H:         model.H2H,
-		LastAccess:  model.LastAccess,
-		IPWhitelist: model.IPWhitelist,
-		CreatedAt:   model.CreatedAt,
-		CreatedBy:   model.CreatedBy,
-		UpdatedAt:   model.UpdatedAt,
-		UpdatedBy:   model.UpdatedBy,
+	apiKey := &entity.ApiKey{
+		ID:                 model.ID,
+		Name:               model.Name,
+		Description:        model.Description,
+		Status:             model.Status,
+		H2H:                model.H2H,
+		RateLimitPerSecond: model.RateLimitPerSecond,
+		RateLimitBurst:     model.RateLimitBurst,
+		DailyQuota:         model.DailyQuota,
+		LastAccess:         model.LastAccess,
+		IPWhitelist:        model.IPWhitelist,
+		IPDenylist:         model.IPDenylist,
+		CreatedAt:          model.CreatedAt,
+		CreatedBy:          model.CreatedBy,
+		UpdatedAt:          model.UpdatedAt,
+		UpdatedBy:          model.UpdatedBy,
+	}
+	if model.ApiKeyPrefix != nil {
+		apiKey.ApiKeyPrefix = *model.ApiKeyPrefix
+	}
+	if model.ApiKeyHash != nil {
+		apiKey.ApiKeyHash = *model.ApiKeyHash
+	}
+	if model.AuthKeyEncrypted != nil {
+		apiKey.AuthKeyEncrypted = *model.AuthKeyEncrypted
+	}
+	if model.Scopes != nil && *model.Scopes != "" {
+		apiKey.Scopes = strings.Split(*model.Scopes, ",")
+	}
+	if model.PreviousApiKeyPrefix != nil {
+		apiKey.PreviousApiKeyPrefix = *model.PreviousApiKeyPrefix
+	}
+	if model.PreviousApiKeyHash != nil {
+		apiKey.PreviousApiKeyHash = *model.PreviousApiKeyHash
+	}
+	if model.PreviousAuthKeyEncrypted != nil {
+		apiKey.PreviousAuthKeyEncrypted = *model.PreviousAuthKeyEncrypted
+	}
+	apiKey.PreviousKeyExpiresAt = model.PreviousKeyExpiresAt
+	// Legacy plaintext values, only present until converted
+	if model.ApiKey != nil {
+		apiKey.ApiKey = *model.ApiKey
+	}
+	if model.AuthKey != nil {
+		apiKey.AuthKey = *model.AuthKey
 	}
+	return apiKey
 }
 
 func (r *apiKeyRepositoryImpl) modelsToEntities(models []model.ApiKeyModel) []*entity.ApiKey {
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/internal/model"
+	"go-rest-api-template/pkg/query"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// auditLogRepositoryImpl - Infrastructure implementation
+type auditLogRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewAuditLogRepository creates repository implementation
+func NewAuditLogRepository(db *sqlx.DB) repository.AuditLogRepository {
+	return &auditLogRepositoryImpl{db: db}
+}
+
+func (r *auditLogRepositoryImpl) Create(ctx context.Context, log *entity.AuditLog) error {
+	before, err := marshalJSONColumn(log.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalJSONColumn(log.After)
+	if err != nil {
+		return err
+	}
+	details, err := marshalJSONColumn(log.Details)
+	if err != nil {
+		return err
+	}
+
+	// before and after are MySQL keywords
+	query := "INSERT INTO audit_log (actor_user_id, api_key_id, ip, action, entity_type, entity_id, `before`, `after`, details, created_at) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := r.db.ExecContext(ctx, query, log.ActorUserID, log.ApiKeyID, log.IP, log.Action, log.EntityType,
+		log.EntityID, before, after, details, log.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, _ := result.LastInsertId()
+	log.ID = int(id)
+	return nil
+}
+
+func (r *auditLogRepositoryImpl) GetAll(ctx context.Context, filter repository.AuditLogFilter, opts query.Options) ([]*entity.AuditLog, error) {
+	var logModels []model.AuditLogModel
+
+	stmt, args := r.filterQuery(filter).Select(`SELECT * FROM audit_log`, "id", opts)
+	err := r.db.SelectContext(ctx, &logModels, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]*entity.AuditLog, len(logModels))
+	for i := range logModels {
+		logs[i] = r.modelToEntity(&logModels[i])
+	}
+
+	return logs, nil
+}
+
+func (r *auditLogRepositoryImpl) GetCount(ctx context.Context, filter repository.AuditLogFilter) (int, error) {
+	var count int
+	stmt, args := r.filterQuery(filter).Count(`SELECT COUNT(*) FROM audit_log`)
+	err := r.db.GetContext(ctx, &count, stmt, args...)
+	return count, err
+}
+
+func (r *auditLogRepositoryImpl) GetByUser(ctx context.Context, userID int) ([]*entity.AuditLog, error) {
+	var logModels []model.AuditLogModel
+
+	query := `SELECT * FROM audit_log WHERE actor_user_id = ? OR (entity_type = ? AND entity_id = ?) ORDER BY id`
+	err := r.db.SelectContext(ctx, &logModels, query, userID, constant.AuditEntityUser, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]*entity.AuditLog, len(logModels))
+	for i := range logModels {
+		logs[i] = r.modelToEntity(&logModels[i])
+	}
+
+	return logs, nil
+}
+
+func (r *auditLogRepositoryImpl) EraseUserData(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE audit_log SET ip = '' WHERE actor_user_id = ?`, userID); err != nil {
+		return err
+	}
+	// Failed logins carry the username in details
+	query := "UPDATE audit_log SET `before` = NULL, `after` = NULL, details = NULL WHERE entity_type = ? AND entity_id = ?"
+	if _, err := tx.ExecContext(ctx, query, constant.AuditEntityUser, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// filterQuery builds the conditions shared by GetAll and GetCount
+func (r *auditLogRepositoryImpl) filterQuery(filter repository.AuditLogFilter) *query.Builder {
+	builder := query.NewBuilder()
+	if filter.ActorUserID > 0 {
+		builder.Where("actor_user_id = ?", filter.ActorUserID)
+	}
+	if filter.ApiKeyID > 0 {
+		builder.Where("api_key_id = ?", filter.ApiKeyID)
+	}
+	if filter.Action != "" {
+		builder.Where("action = ?", filter.Action)
+	}
+	if filter.EntityType != "" {
+		builder.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID > 0 {
+		builder.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.IP != "" {
+		builder.Where("ip = ?", filter.IP)
+	}
+	builder.WhereTime("created_at >= ?", filter.CreatedFrom)
+	builder.WhereTime("created_at <= ?", filter.CreatedTo)
+	return builder
+}
+
+// marshalJSONColumn encodes a map for a JSON column, NULL when it is empty
+func marshalJSONColumn(value map[string]interface{}) (*string, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	encoded := string(data)
+	return &encoded, nil
+}
+
+// unmarshalJSONColumn decodes a JSON column, nil for NULL and values that are no object
+func unmarshalJSONColumn(value *string) map[string]interface{} {
+	if value == nil {
+		return nil
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(*value), &decoded); err != nil {
+		return nil
+	}
+	return decoded
+}
+
+// Helper methods for model conversion
+func (r *auditLogRepositoryImpl) modelToEntity(model *model.AuditLogModel) *entity.AuditLog {
+	return &entity.AuditLog{
+		ID:          model.ID,
+		ActorUserID: model.ActorUserID,
+		ApiKeyID:    model.ApiKeyID,
+		IP:          model.IP,
+		Action:      model.Action,
+		EntityType:  model.EntityType,
+		EntityID:    model.EntityID,
+		Before:      unmarshalJSONColumn(model.Before),
+		After:       unmarshalJSONColumn(model.After),
+		Details:     unmarshalJSONColumn(model.Details),
+		CreatedAt:   model.CreatedAt,
+	}
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// mfaRepositoryImpl - Infrastructure implementation
+type mfaRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewMFARepository creates repository implementation
+func NewMFARepository(db *sqlx.DB) repository.MFARepository {
+	return &mfaRepositoryImpl{db: db}
+}
+
+func (r *mfaRepositoryImpl) GetByUserID(ctx context.Context, userID int) (*entity.UserMFA, error) {
+	var mfaModel model.UserMFAModel
+
+	query := `SELECT * FROM user_mfa WHERE user_id = ?`
+	err := r.db.GetContext(ctx, &mfaModel, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return r.modelToEntity(&mfaModel), nil
+}
+
+func (r *mfaRepositoryImpl) Save(ctx context.Context, mfa *entity.UserMFA) error {
+	mfaModel := &model.UserMFAModel{
+		UserID:       mfa.UserID,
+		Secret:       mfa.Secret,
+		EnabledAt:    mfa.EnabledAt,
+		LastUsedStep: mfa.LastUsedStep,
+		CreatedAt:    mfa.CreatedAt,
+		UpdatedAt:    mfa.UpdatedAt,
+	}
+
+	query := `INSERT INTO user_mfa (user_id, secret, enabled_at, last_used_step, created_at, updated_at)
+			  VALUES (:user_id, :secret, :enabled_at, :last_used_step, :created_at, :updated_at)
+			  ON DUPLICATE KEY UPDATE secret = VALUES(secret), enabled_at = VALUES(enabled_at),
+			  last_used_step = VALUES(last_used_step), updated_at = VALUES(updated_at)`
+
+	_, err := r.db.NamedExecContext(ctx, query, mfaModel)
+	return err
+}
+
+func (r *mfaRepositoryImpl) UpdateLastUsedStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `UPDATE user_mfa SET last_used_step = ?, updated_at = NOW() WHERE user_id = ? AND last_used_step < ?`
+	result, err := r.db.ExecContext(ctx, query, step, userID, step)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *mfaRepositoryImpl) Delete(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_code WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *mfaRepositoryImpl) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_code WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	for _, codeHash := range codeHashes {
+		query := `INSERT INTO user_recovery_code (user_id, code_hash, created_at) VALUES (?, ?, NOW())`
+		if _, err := tx.ExecContext(ctx, query, userID, codeHash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *mfaRepositoryImpl) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `UPDATE user_recovery_code SET used_at = NOW() WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// Helper methods for model conversion
+func (r *mfaRepositoryImpl) modelToEntity(model *model.UserMFAModel) *entity.UserMFA {
+	return &entity.UserMFA{
+		UserID:       model.UserID,
+		Secret:       model.Secret,
+		EnabledAt:    model.EnabledAt,
+		LastUsedStep: model.LastUsedStep,
+		CreatedAt:    model.CreatedAt,
+		UpdatedAt:    model.UpdatedAt,
+	}
+}
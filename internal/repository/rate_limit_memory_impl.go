@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"math"
+	"sync"
+	"time"
+)
+
+type tokenBucket struct {
+	tokens  float64
+	rate    float64
+	burst   float64
+	updated time.Time
+}
+
+type windowCounter struct {
+	count     int
+	windowEnd time.Time
+}
+
+// rateLimitMemoryImpl - In-memory implementation for single node deployments
+type rateLimitMemoryImpl struct {
+	mu       sync.Mutex
+	buckets  map[string]*tokenBucket
+	counters map[string]*windowCounter
+}
+
+// NewInMemoryRateLimitRepository creates in-memory repository implementation
+func NewInMemoryRateLimitRepository() repository.RateLimitRepository {
+	return &rateLimitMemoryImpl{
+		buckets:  make(map[string]*tokenBucket),
+		counters: make(map[string]*windowCounter),
+	}
+}
+
+func (r *rateLimitMemoryImpl) Take(ctx context.Context, key string, rate float64, burst int) (*entity.RateLimitStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	bucket, exists := r.buckets[key]
+	if !exists {
+		bucket = &tokenBucket{tokens: float64(burst), updated: now}
+		r.buckets[key] = bucket
+	}
+	// Limits may have been changed since the bucket was created
+	bucket.rate = rate
+	bucket.burst = float64(burst)
+	bucket.refill(now)
+
+	status := &entity.RateLimitStatus{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		status.Allowed = true
+	} else {
+		status.RetryAfter = secondsToDuration((1 - bucket.tokens) / rate)
+	}
+	status.Remaining = int(math.Floor(bucket.tokens))
+	status.ResetAfter = secondsToDuration((bucket.burst - bucket.tokens) / rate)
+
+	return status, nil
+}
+
+func (r *rateLimitMemoryImpl) Increment(ctx context.Context, key string, windowEnd time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counter, exists := r.counters[key]
+	if !exists || !time.Now().Before(counter.windowEnd) {
+		counter = &windowCounter{windowEnd: windowEnd}
+		r.counters[key] = counter
+	}
+	counter.count++
+
+	return counter.count, nil
+}
+
+func (r *rateLimitMemoryImpl) Count(ctx context.Context, key string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counter, exists := r.counters[key]
+	if !exists || !time.Now().Before(counter.windowEnd) {
+		return 0, nil
+	}
+	return counter.count, nil
+}
+
+func (r *rateLimitMemoryImpl) PurgeExpired(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for key, bucket := range r.buckets {
+		// A full bucket behaves exactly like a new one
+		bucket.refill(now)
+		if bucket.tokens >= bucket.burst {
+			delete(r.buckets, key)
+		}
+	}
+	for key, counter := range r.counters {
+		if !now.Before(counter.windowEnd) {
+			delete(r.counters, key)
+		}
+	}
+	return nil
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.updated).Seconds()
+	b.tokens = math.Min(b.burst, b.tokens+elapsed*b.rate)
+	b.updated = now
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// refreshTokenRepositoryImpl - Infrastructure implementation
+type refreshTokenRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewRefreshTokenRepository creates repository implementation
+func NewRefreshTokenRepository(db *sqlx.DB) repository.RefreshTokenRepository {
+	return &refreshTokenRepositoryImpl{db: db}
+}
+
+func (r *refreshTokenRepositoryImpl) Create(ctx context.Context, token *entity.RefreshToken) error {
+	tokenModel := &model.RefreshTokenModel{
+		UserID:       token.UserID,
+		ApiKeyID:     token.ApiKeyID,
+		FamilyID:     token.FamilyID,
+		SessionToken: &token.SessionToken,
+		TokenHash:    token.TokenHash,
+		ExpiresAt:    token.ExpiresAt,
+		CreatedAt:    token.CreatedAt,
+	}
+
+	query := `INSERT INTO refresh_token (user_id, api_key_id, family_id, session_token, token_hash, expires_at, created_at) 
+			  VALUES (:user_id, :api_key_id, :family_id, :session_token, :token_hash, :expires_at, :created_at)`
+
+	result, err := r.db.NamedExecContext(ctx, query, tokenModel)
+	if err != nil {
+		return err
+	}
+
+	id, _ := result.LastInsertId()
+	token.ID = int(id)
+
+	return nil
+}
+
+func (r *refreshTokenRepositoryImpl) GetByTokenHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	var tokenModel model.RefreshTokenModel
+
+	query := `SELECT * FROM refresh_token WHERE token_hash = ?`
+	err := r.db.GetContext(ctx, &tokenModel, query, tokenHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return r.modelToEntity(&tokenModel), nil
+}
+
+func (r *refreshTokenRepositoryImpl) MarkRotated(ctx context.Context, id int, replacedBy int) (bool, error) {
+	query := `UPDATE refresh_token SET rotated_at = NOW(), replaced_by = ? 
+			  WHERE id = ? AND rotated_at IS NULL AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, replacedBy, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+func (r *refreshTokenRepositoryImpl) RevokeFamily(ctx context.Context, familyID string) error {
+	query := `UPDATE refresh_token SET revoked_at = NOW() WHERE family_id = ? AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, familyID)
+	return err
+}
+
+func (r *refreshTokenRepositoryImpl) RevokeByUserID(ctx context.Context, userID int) error {
+	query := `UPDATE refresh_token SET revoked_at = NOW() WHERE user_id = ? AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+func (r *refreshTokenRepositoryImpl) RevokeBySessionToken(ctx context.Context, sessionToken string) error {
+	query := `UPDATE refresh_token SET revoked_at = NOW() WHERE session_token = ? AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, sessionToken)
+	return err
+}
+
+// Helper methods for model conversion
+func (r *refreshTokenRepositoryImpl) modelToEntity(model *model.RefreshTokenModel) *entity.RefreshToken {
+	token := &entity.RefreshToken{
+		ID:         model.ID,
+		UserID:     model.UserID,
+		ApiKeyID:   model.ApiKeyID,
+		FamilyID:   model.FamilyID,
+		TokenHash:  model.TokenHash,
+		ExpiresAt:  model.ExpiresAt,
+		RotatedAt:  model.RotatedAt,
+		RevokedAt:  model.RevokedAt,
+		ReplacedBy: model.ReplacedBy,
+		CreatedAt:  model.CreatedAt,
+	}
+	if model.SessionToken != nil {
+		token.SessionToken = *model.SessionToken
+	}
+	return token
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/repository"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// requestNonceMemoryImpl - In-memory implementation for single node deployments
+type requestNonceMemoryImpl struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time // api key id + nonce -> expires at
+}
+
+// NewInMemoryRequestNonceRepository creates in-memory repository implementation
+func NewInMemoryRequestNonceRepository() repository.RequestNonceRepository {
+	return &requestNonceMemoryImpl{
+		nonces: make(map[string]time.Time),
+	}
+}
+
+func (r *requestNonceMemoryImpl) Use(ctx context.Context, apiKeyID int, nonce string, expiresAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := strconv.Itoa(apiKeyID) + ":" + nonce
+	if _, exists := r.nonces[key]; exists {
+		return false, nil
+	}
+	r.nonces[key] = expiresAt
+	return true, nil
+}
+
+func (r *requestNonceMemoryImpl) PurgeExpired(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for key, expiresAt := range r.nonces {
+		if expiresAt.Before(now) {
+			delete(r.nonces, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/repository"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// requestNonceRepositoryImpl - MySQL implementation
+type requestNonceRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewRequestNonceRepository creates MySQL backed repository implementation
+func NewRequestNonceRepository(db *sqlx.DB) repository.RequestNonceRepository {
+	return &requestNonceRepositoryImpl{db: db}
+}
+
+func (r *requestNonceRepositoryImpl) Use(ctx context.Context, apiKeyID int, nonce string, expiresAt time.Time) (bool, error) {
+	// The primary key rejects a second insert of the same nonce atomically
+	query := `INSERT IGNORE INTO request_nonce (api_key_id, nonce, expires_at, created_at) VALUES (?, ?, ?, NOW())`
+	result, err := r.db.ExecContext(ctx, query, apiKeyID, nonce, expiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows == 1, nil
+}
+
+func (r *requestNonceRepositoryImpl) PurgeExpired(ctx context.Context) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM request_nonce WHERE expires_at < NOW()`)
+	return err
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/internal/model"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// roleRepositoryImpl - Infrastructure implementation
+type roleRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewRoleRepository creates repository implementation
+func NewRoleRepository(db *sqlx.DB) repository.RoleRepository {
+	return &roleRepositoryImpl{db: db}
+}
+
+func (r *roleRepositoryImpl) GetAll(ctx context.Context) ([]*entity.Role, error) {
+	var roleModels []model.RoleModel
+
+	query := `SELECT * FROM role ORDER BY name`
+	if err := r.db.SelectContext(ctx, &roleModels, query); err != nil {
+		return nil, err
+	}
+
+	roles := make([]*entity.Role, len(roleModels))
+	for i := range roleModels {
+		roles[i] = r.modelToEntity(&roleModels[i])
+		permissions, err := r.GetPermissions(ctx, []string{roles[i].Name})
+		if err != nil {
+			return nil, err
+		}
+		roles[i].Permissions = permissions
+	}
+
+	return roles, nil
+}
+
+func (r *roleRepositoryImpl) GetByName(ctx context.Context, name string) (*entity.Role, error) {
+	var roleModel model.RoleModel
+
+	query := `SELECT * FROM role WHERE name = ?`
+	err := r.db.GetContext(ctx, &roleModel, query, name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	role := r.modelToEntity(&roleModel)
+	role.Permissions, err = r.GetPermissions(ctx, []string{role.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	return role, nil
+}
+
+func (r *roleRepositoryImpl) GetUserRoles(ctx context.Context, userID int) ([]string, error) {
+	roles := []string{}
+
+	query := `SELECT r.name FROM user_role ur JOIN role r ON r.id = ur.role_id 
+			  WHERE ur.user_id = ? ORDER BY r.name`
+	if err := r.db.SelectContext(ctx, &roles, query, userID); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *roleRepositoryImpl) AssignRole(ctx context.Context, userID, roleID int, createdBy *int) error {
+	query := `INSERT IGNORE INTO user_role (user_id, role_id, created_at, created_by) VALUES (?, ?, NOW(), ?)`
+	_, err := r.db.ExecContext(ctx, query, userID, roleID, createdBy)
+	return err
+}
+
+func (r *roleRepositoryImpl) RevokeRole(ctx context.Context, userID, roleID int) error {
+	query := `DELETE FROM user_role WHERE user_id = ? AND role_id = ?`
+	_, err := r.db.ExecContext(ctx, query, userID, roleID)
+	return err
+}
+
+func (r *roleRepositoryImpl) GetPermissions(ctx context.Context, roles []string) ([]string, error) {
+	permissions := []string{}
+	if len(roles) == 0 {
+		return permissions, nil
+	}
+
+	query, args, err := sqlx.In(`SELECT DISTINCT p.name FROM role_permission rp 
+			  JOIN role r ON r.id = rp.role_id 
+			  JOIN permission p ON p.id = rp.permission_id 
+			  WHERE r.name IN (?) ORDER BY p.name`, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.db.SelectContext(ctx, &permissions, r.db.Rebind(query), args...); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (r *roleRepositoryImpl) modelToEntity(roleModel *model.RoleModel) *entity.Role {
+	return &entity.Role{
+		ID:          roleModel.ID,
+		Name:        roleModel.Name,
+		Description: roleModel.Description,
+		CreatedAt:   roleModel.CreatedAt,
+	}
+}
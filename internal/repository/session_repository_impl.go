@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/internal/model"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// sessionRepositoryImpl - Infrastructure implementation
+type sessionRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewSessionRepository creates repository implementation
+func NewSessionRepository(db *sqlx.DB) repository.SessionRepository {
+	return &sessionRepositoryImpl{db: db}
+}
+
+func (r *sessionRepositoryImpl) Create(ctx context.Context, session *entity.Session) error {
+	sessionModel := &model.SessionModel{
+		UserID:       session.UserID,
+		ApiKeyID:     &session.ApiKeyID,
+		SessionToken: session.SessionToken,
+		IPAddress:    &session.IPAddress,
+		UserAgent:    &session.UserAgent,
+		ExpiresAt:    session.ExpiresAt,
+		LastSeenAt:   session.LastSeenAt,
+		CreatedAt:    session.CreatedAt,
+	}
+
+	query := `INSERT INTO user_sessions (user_id, api_key_id, session_token, ip_address, user_agent, expires_at, last_seen_at, created_at, updated_at) 
+			  VALUES (:user_id, :api_key_id, :session_token, :ip_address, :user_agent, :expires_at, :last_seen_at, :created_at, NOW())`
+
+	result, err := r.db.NamedExecContext(ctx, query, sessionModel)
+	if err != nil {
+		return err
+	}
+
+	id, _ := result.LastInsertId()
+	session.ID = int(id)
+
+	return nil
+}
+
+func (r *sessionRepositoryImpl) GetByID(ctx context.Context, id int) (*entity.Session, error) {
+	var sessionModel model.SessionModel
+
+	query := `SELECT * FROM user_sessions WHERE id = ?`
+	err := r.db.GetContext(ctx, &sessionModel, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return r.modelToEntity(&sessionModel), nil
+}
+
+func (r *sessionRepositoryImpl) GetBySessionToken(ctx context.Context, sessionToken string) (*entity.Session, error) {
+	var sessionModel model.SessionModel
+
+	query := `SELECT * FROM user_sessions WHERE session_token = ?`
+	err := r.db.GetContext(ctx, &sessionModel, query, sessionToken)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return r.modelToEntity(&sessionModel), nil
+}
+
+func (r *sessionRepositoryImpl) GetActiveByUserID(ctx context.Context, userID int) ([]*entity.Session, error) {
+	var sessionModels []model.SessionModel
+
+	query := `SELECT * FROM user_sessions WHERE user_id = ? AND terminated_at IS NULL AND expires_at > NOW() 
+			  ORDER BY COALESCE(last_seen_at, created_at) DESC`
+	err := r.db.SelectContext(ctx, &sessionModels, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*entity.Session, len(sessionModels))
+	for i := range sessionModels {
+		sessions[i] = r.modelToEntity(&sessionModels[i])
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepositoryImpl) GetByUserID(ctx context.Context, userID int) ([]*entity.Session, error) {
+	var sessionModels []model.SessionModel
+
+	query := `SELECT * FROM user_sessions WHERE user_id = ? ORDER BY created_at DESC, id DESC`
+	err := r.db.SelectContext(ctx, &sessionModels, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*entity.Session, len(sessionModels))
+	for i := range sessionModels {
+		sessions[i] = r.modelToEntity(&sessionModels[i])
+	}
+	return sessions, nil
+}
+
+func (r *sessionRepositoryImpl) Touch(ctx context.Context, id int) error {
+	// Only write once per minute to keep hot sessions cheap
+	query := `UPDATE user_sessions SET last_seen_at = NOW() 
+			  WHERE id = ? AND (last_seen_at IS NULL OR last_seen_at < NOW() - INTERVAL 1 MINUTE)`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *sessionRepositoryImpl) Extend(ctx context.Context, id int, expiresAt time.Time) error {
+	query := `UPDATE user_sessions SET expires_at = ?, last_seen_at = NOW() WHERE id = ? AND terminated_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, expiresAt, id)
+	return err
+}
+
+func (r *sessionRepositoryImpl) Terminate(ctx context.Context, id int) error {
+	query := `UPDATE user_sessions SET terminated_at = NOW() WHERE id = ? AND terminated_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+func (r *sessionRepositoryImpl) TerminateByUserID(ctx context.Context, userID int) error {
+	query := `UPDATE user_sessions SET terminated_at = NOW() WHERE user_id = ? AND terminated_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// Helper methods for model conversion
+func (r *sessionRepositoryImpl) modelToEntity(model *model.SessionModel) *entity.Session {
+	session := &entity.Session{
+		ID:           model.ID,
+		UserID:       model.UserID,
+		SessionToken: model.SessionToken,
+		ExpiresAt:    model.ExpiresAt,
+		LastSeenAt:   model.LastSeenAt,
+		TerminatedAt: model.TerminatedAt,
+		CreatedAt:    model.CreatedAt,
+		UpdatedAt:    model.UpdatedAt,
+	}
+	if model.ApiKeyID != nil {
+		session.ApiKeyID = *model.ApiKeyID
+	}
+	if model.IPAddress != nil {
+		session.IPAddress = *model.IPAddress
+	}
+	if model.UserAgent != nil {
+		session.UserAgent = *model.UserAgent
+	}
+	return session
+}
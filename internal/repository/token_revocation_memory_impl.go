@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/repository"
+	"sync"
+	"time"
+)
+
+// userRevocation holds a "logout everywhere" cut-off for a user
+type userRevocation struct {
+	revokedBefore time.Time
+	expiresAt     time.Time
+}
+
+// tokenRevocationMemoryImpl - In-memory implementation for single node deployments
+type tokenRevocationMemoryImpl struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time // jti -> expires at
+	users  map[int]userRevocation
+}
+
+// NewInMemoryTokenRevocationRepository creates in-memory repository implementation
+func NewInMemoryTokenRevocationRepository() repository.TokenRevocationRepository {
+	return &tokenRevocationMemoryImpl{
+		tokens: make(map[string]time.Time),
+		users:  make(map[int]userRevocation),
+	}
+}
+
+func (r *tokenRevocationMemoryImpl) RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[jti] = expiresAt
+	return nil
+}
+
+func (r *tokenRevocationMemoryImpl) RevokeUserTokens(ctx context.Context, userID int, revokedBefore, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[userID] = userRevocation{revokedBefore: revokedBefore, expiresAt: expiresAt}
+	return nil
+}
+
+func (r *tokenRevocationMemoryImpl) IsRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.tokens[jti]; ok && jti != "" {
+		return true, nil
+	}
+	if revocation, ok := r.users[userID]; ok {
+		return issuedAt.Before(revocation.revokedBefore), nil
+	}
+	return false, nil
+}
+
+func (r *tokenRevocationMemoryImpl) PurgeExpired(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for jti, expiresAt := range r.tokens {
+		if expiresAt.Before(now) {
+			delete(r.tokens, jti)
+		}
+	}
+	for userID, revocation := range r.users {
+		if revocation.expiresAt.Before(now) {
+			delete(r.users, userID)
+		}
+	}
+	return nil
+}
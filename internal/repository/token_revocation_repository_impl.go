@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-api-template/internal/domain/repository"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// tokenRevocationRepositoryImpl - MySQL implementation
+type tokenRevocationRepositoryImpl struct {
+	db *sqlx.DB
+}
+
+// NewTokenRevocationRepository creates MySQL backed repository implementation
+func NewTokenRevocationRepository(db *sqlx.DB) repository.TokenRevocationRepository {
+	return &tokenRevocationRepositoryImpl{db: db}
+}
+
+func (r *tokenRevocationRepositoryImpl) RevokeToken(ctx context.Context, jti string, userID int, expiresAt time.Time) error {
+	query := `INSERT INTO revoked_token (jti, user_id, expires_at, created_at) VALUES (?, ?, ?, NOW()) 
+			  ON DUPLICATE KEY UPDATE expires_at = VALUES(expires_at)`
+	_, err := r.db.ExecContext(ctx, query, jti, userID, expiresAt)
+	return err
+}
+
+func (r *tokenRevocationRepositoryImpl) RevokeUserTokens(ctx context.Context, userID int, revokedBefore, expiresAt time.Time) error {
+	query := `INSERT INTO user_token_revocation (user_id, revoked_before, expires_at) VALUES (?, ?, ?) 
+			  ON DUPLICATE KEY UPDATE revoked_before = VALUES(revoked_before), expires_at = VALUES(expires_at)`
+	_, err := r.db.ExecContext(ctx, query, userID, revokedBefore, expiresAt)
+	return err
+}
+
+func (r *tokenRevocationRepositoryImpl) IsRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error) {
+	if jti != "" {
+		var count int
+		query := `SELECT COUNT(*) FROM revoked_token WHERE jti = ?`
+		if err := r.db.GetContext(ctx, &count, query, jti); err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+
+	var revokedBefore time.Time
+	query := `SELECT revoked_before FROM user_token_revocation WHERE user_id = ?`
+	err := r.db.GetContext(ctx, &revokedBefore, query, userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return issuedAt.Before(revokedBefore), nil
+}
+
+func (r *tokenRevocationRepositoryImpl) PurgeExpired(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM revoked_token WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, `DELETE FROM user_token_revocation WHERE expires_at < NOW()`)
+	return err
+}
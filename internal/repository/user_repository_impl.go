@@ -2,10 +2,12 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"go-rest-api-template/internal/constant"
 	"go-rest-api-template/internal/domain/entity"
 	"go-rest-api-template/internal/domain/repository"
 	"go-rest-api-template/internal/model"
+	"go-rest-api-template/pkg/query"
 	"time"
 
 	common "github.com/budimanlai/go-common"
@@ -28,6 +30,10 @@ func (r *userRepositoryImpl) Create(ctx context.Context, user *entity.User) erro
 	userModel := &model.UserModel{
 		Username:          user.Username,
 		Email:             user.Email,
+		FullName:          user.FullName,
+		Phone:             user.Phone,
+		Locale:            user.Locale,
+		Timezone:          user.Timezone,
 		PasswordHash:      user.PasswordHash,
 		VerificationToken: user.VerificationToken,
 		AuthKey:           common.GenerateRandomString(constant.AuthKeyLength),
@@ -36,13 +42,16 @@ func (r *userRepositoryImpl) Create(ctx context.Context, user *entity.User) erro
 		UpdatedAt: &now,
 		DeletedAt: nil, // Not deleted initially
 		DeletedBy: nil, // Not deleted initially
-		// Set status to active by default
-		Status:    constant.UserStatusActive,
+		Status:    user.Status,
 		CreatedBy: user.CreatedBy,
 	}
+	// Set status to active by default
+	if userModel.Status == "" {
+		userModel.Status = constant.UserStatusActive
+	}
 
-	query := `INSERT INTO user (username, auth_key, email, password_hash, status, created_by, created_at, updated_at) 
-			  VALUES (:username, :auth_key, :email, :password_hash, :status, :created_by, NOW(), NOW())`
+	query := `INSERT INTO user (username, auth_key, email, full_name, phone, locale, timezone, password_hash, status, created_by, created_at, updated_at) 
+			  VALUES (:username, :auth_key, :email, :full_name, :phone, :locale, :timezone, :password_hash, :status, :created_by, NOW(), NOW())`
 
 	result, err := r.db.NamedExecContext(ctx, query, userModel)
 	if err != nil {
@@ -52,6 +61,7 @@ func (r *userRepositoryImpl) Create(ctx context.Context, user *entity.User) erro
 	// Set the ID back to domain entity
 	id, _ := result.LastInsertId()
 	user.ID = int(id)
+	user.Status = userModel.Status
 
 	return nil
 }
@@ -66,22 +76,7 @@ func (r *userRepositoryImpl) GetByID(ctx context.Context, id int) (*entity.User,
 	}
 
 	// Convert database model to domain entity
-	return &entity.User{
-		ID:                 userModel.ID,
-		Username:           userModel.Username,
-		Email:              userModel.Email,
-		PasswordHash:       userModel.PasswordHash,
-		PasswordResetToken: userModel.PasswordResetToken,
-		Status:             userModel.Status,
-		VerificationToken:  userModel.VerificationToken,
-		AuthKey:            userModel.AuthKey,
-		CreatedAt:          userModel.CreatedAt,
-		UpdatedAt:          userModel.UpdatedAt,
-		DeletedAt:          userModel.DeletedAt,
-		CreatedBy:          userModel.CreatedBy,
-		UpdatedBy:          userModel.UpdatedBy,
-		DeletedBy:          userModel.DeletedBy,
-	}, nil
+	return r.modelToEntity(&userModel), nil
 }
 
 func (r *userRepositoryImpl) GetByEmail(ctx context.Context, email string) (*entity.User, error) {
@@ -93,22 +88,7 @@ func (r *userRepositoryImpl) GetByEmail(ctx context.Context, email string) (*ent
 		return nil, err
 	}
 
-	return &entity.User{
-		ID:                 userModel.ID,
-		Username:           userModel.Username,
-		Email:              userModel.Email,
-		PasswordHash:       userModel.PasswordHash,
-		PasswordResetToken: userModel.PasswordResetToken,
-		Status:             userModel.Status,
-		VerificationToken:  userModel.VerificationToken,
-		AuthKey:            userModel.AuthKey,
-		CreatedAt:          userModel.CreatedAt,
-		UpdatedAt:          userModel.UpdatedAt,
-		DeletedAt:          userModel.DeletedAt,
-		CreatedBy:          userModel.CreatedBy,
-		UpdatedBy:          userModel.UpdatedBy,
-		DeletedBy:          userModel.DeletedBy,
-	}, nil
+	return r.modelToEntity(&userModel), nil
 }
 
 func (r *userRepositoryImpl) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
@@ -120,22 +100,7 @@ func (r *userRepositoryImpl) GetByUsername(ctx context.Context, username string)
 		return nil, err
 	}
 
-	return &entity.User{
-		ID:                 userModel.ID,
-		Username:           userModel.Username,
-		Email:              userModel.Email,
-		PasswordHash:       userModel.PasswordHash,
-		PasswordResetToken: userModel.PasswordResetToken,
-		Status:             userModel.Status,
-		VerificationToken:  userModel.VerificationToken,
-		AuthKey:            userModel.AuthKey,
-		CreatedAt:          userModel.CreatedAt,
-		UpdatedAt:          userModel.UpdatedAt,
-		DeletedAt:          userModel.DeletedAt,
-		CreatedBy:          userModel.CreatedBy,
-		UpdatedBy:          userModel.UpdatedBy,
-		DeletedBy:          userModel.DeletedBy,
-	}, nil
+	return r.modelToEntity(&userModel), nil
 }
 
 func (r *userRepositoryImpl) Update(ctx context.Context, user *entity.User) error {
@@ -143,63 +108,76 @@ func (r *userRepositoryImpl) Update(ctx context.Context, user *entity.User) erro
 		ID:           user.ID,
 		Username:     user.Username,
 		Email:        user.Email,
+		PendingEmail: user.PendingEmail,
+		FullName:     user.FullName,
+		Phone:        user.Phone,
+		Locale:       user.Locale,
+		Timezone:     user.Timezone,
 		PasswordHash: user.PasswordHash,
 		Status:       user.Status,
 		UpdatedBy:    user.UpdatedBy,
+		// Written along, so a password change can invalidate the reset token
+		PasswordResetToken:     user.PasswordResetToken,
+		PasswordResetExpiresAt: user.PasswordResetExpiresAt,
 	}
 
-	query := `UPDATE user SET username = :username, email = :email, password_hash = :password_hash, 
-			  status = :status, updated_by = :updated_by, updated_at = NOW() WHERE id = :id AND deleted_at IS NULL`
+	query := `UPDATE user SET username = :username, email = :email, pending_email = :pending_email, 
+			  full_name = :full_name, phone = :phone, locale = :locale, timezone = :timezone, password_hash = :password_hash, 
+			  status = :status, password_reset_token = :password_reset_token, 
+			  password_reset_expires_at = :password_reset_expires_at, 
+			  updated_by = :updated_by, updated_at = NOW() WHERE id = :id AND deleted_at IS NULL`
 
 	_, err := r.db.NamedExecContext(ctx, query, userModel)
 	return err
 }
 
-func (r *userRepositoryImpl) Delete(ctx context.Context, id int) error {
+func (r *userRepositoryImpl) Delete(ctx context.Context, id int, deletedBy int) error {
 	query := `UPDATE user SET deleted_at = NOW(), deleted_by = ? WHERE id = ? AND deleted_at IS NULL`
-	_, err := r.db.ExecContext(ctx, query, constant.DefaultUpdatedBy, id)
+	_, err := r.db.ExecContext(ctx, query, deletedBy, id)
 	return err
 }
 
-func (r *userRepositoryImpl) GetAll(ctx context.Context, limit, offset int) ([]*entity.User, error) {
+func (r *userRepositoryImpl) GetAll(ctx context.Context, filter repository.UserFilter, opts query.Options) ([]*entity.User, error) {
 	var userModels []model.UserModel
 
-	query := `SELECT * FROM user WHERE deleted_at IS NULL ORDER BY created_at DESC LIMIT ? OFFSET ?`
-	err := r.db.SelectContext(ctx, &userModels, query, limit, offset)
+	stmt, args := r.filterQuery(filter).Select(`SELECT * FROM user`, "id", opts)
+	err := r.db.SelectContext(ctx, &userModels, stmt, args...)
 	if err != nil {
 		return nil, err
 	}
 
 	users := make([]*entity.User, len(userModels))
-	for i, userModel := range userModels {
-		users[i] = &entity.User{
-			ID:                 userModel.ID,
-			Username:           userModel.Username,
-			Email:              userModel.Email,
-			PasswordHash:       userModel.PasswordHash,
-			PasswordResetToken: userModel.PasswordResetToken,
-			Status:             userModel.Status,
-			VerificationToken:  userModel.VerificationToken,
-			AuthKey:            userModel.AuthKey,
-			CreatedAt:          userModel.CreatedAt,
-			UpdatedAt:          userModel.UpdatedAt,
-			DeletedAt:          userModel.DeletedAt,
-			CreatedBy:          userModel.CreatedBy,
-			UpdatedBy:          userModel.UpdatedBy,
-			DeletedBy:          userModel.DeletedBy,
-		}
+	for i := range userModels {
+		users[i] = r.modelToEntity(&userModels[i])
 	}
 
 	return users, nil
 }
 
-func (r *userRepositoryImpl) GetCount(ctx context.Context) (int, error) {
+func (r *userRepositoryImpl) GetCount(ctx context.Context, filter repository.UserFilter) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM user WHERE deleted_at IS NULL`
-	err := r.db.GetContext(ctx, &count, query)
+	stmt, args := r.filterQuery(filter).Count(`SELECT COUNT(*) FROM user`)
+	err := r.db.GetContext(ctx, &count, stmt, args...)
 	return count, err
 }
 
+// filterQuery builds the conditions shared by GetAll and GetCount
+func (r *userRepositoryImpl) filterQuery(filter repository.UserFilter) *query.Builder {
+	builder := query.NewBuilder()
+	if filter.Deleted {
+		builder.Where("deleted_at IS NOT NULL AND anonymized_at IS NULL")
+	} else {
+		builder.Where("deleted_at IS NULL")
+	}
+	if filter.Status != "" {
+		builder.Where("status = ?", filter.Status)
+	}
+	builder.WhereTime("created_at >= ?", filter.CreatedFrom)
+	builder.WhereTime("created_at <= ?", filter.CreatedTo)
+	builder.Search(filter.Search, "username", "email")
+	return builder
+}
+
 func (r *userRepositoryImpl) GetByVerificationToken(ctx context.Context, token string) (*entity.User, error) {
 	var userModel model.UserModel
 
@@ -209,22 +187,7 @@ func (r *userRepositoryImpl) GetByVerificationToken(ctx context.Context, token s
 		return nil, err
 	}
 
-	return &entity.User{
-		ID:                 userModel.ID,
-		Username:           userModel.Username,
-		Email:              userModel.Email,
-		PasswordHash:       userModel.PasswordHash,
-		PasswordResetToken: userModel.PasswordResetToken,
-		Status:             userModel.Status,
-		VerificationToken:  userModel.VerificationToken,
-		AuthKey:            userModel.AuthKey,
-		CreatedAt:          userModel.CreatedAt,
-		UpdatedAt:          userModel.UpdatedAt,
-		DeletedAt:          userModel.DeletedAt,
-		CreatedBy:          userModel.CreatedBy,
-		UpdatedBy:          userModel.UpdatedBy,
-		DeletedBy:          userModel.DeletedBy,
-	}, nil
+	return r.modelToEntity(&userModel), nil
 }
 
 func (r *userRepositoryImpl) UpdateVerificationToken(ctx context.Context, user *entity.User) error {
@@ -241,3 +204,210 @@ func (r *userRepositoryImpl) UpdateVerificationToken(ctx context.Context, user *
 	_, err := r.db.NamedExecContext(ctx, query, userModel)
 	return err
 }
+
+func (r *userRepositoryImpl) GetByPasswordResetToken(ctx context.Context, tokenHash string) (*entity.User, error) {
+	var userModel model.UserModel
+
+	query := `SELECT * FROM user WHERE password_reset_token = ? AND deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &userModel, query, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.modelToEntity(&userModel), nil
+}
+
+func (r *userRepositoryImpl) UpdatePasswordResetToken(ctx context.Context, user *entity.User) error {
+	userModel := &model.UserModel{
+		ID:                     user.ID,
+		PasswordResetToken:     user.PasswordResetToken,
+		PasswordResetExpiresAt: user.PasswordResetExpiresAt,
+		UpdatedBy:              user.UpdatedBy,
+	}
+
+	query := `UPDATE user SET password_reset_token = :password_reset_token, 
+			  password_reset_expires_at = :password_reset_expires_at, 
+			  updated_by = :updated_by, updated_at = NOW() 
+			  WHERE id = :id AND deleted_at IS NULL`
+
+	_, err := r.db.NamedExecContext(ctx, query, userModel)
+	return err
+}
+
+func (r *userRepositoryImpl) UpdateLoginAttempts(ctx context.Context, user *entity.User) error {
+	userModel := &model.UserModel{
+		ID:                  user.ID,
+		FailedLoginAttempts: user.FailedLoginAttempts,
+		LockedUntil:         user.LockedUntil,
+	}
+
+	// Not a change of the account itself, so updated_at/updated_by stay untouched
+	query := `UPDATE user SET failed_login_attempts = :failed_login_attempts, locked_until = :locked_until 
+			  WHERE id = :id AND deleted_at IS NULL`
+
+	_, err := r.db.NamedExecContext(ctx, query, userModel)
+	return err
+}
+
+func (r *userRepositoryImpl) IncrementFailedLogins(ctx context.Context, user *entity.User, maxAttempts int, lockout time.Duration) error {
+	now := time.Now()
+
+	// MySQL assigns left to right, so locked_until sees the new failed_login_attempts.
+	// Counting starts over once a lock has ended.
+	query := `UPDATE user SET 
+			  failed_login_attempts = CASE WHEN locked_until IS NOT NULL AND locked_until <= ? THEN 1 ELSE failed_login_attempts + 1 END, 
+			  locked_until = CASE 
+				WHEN ? > 0 AND failed_login_attempts >= ? THEN ? 
+				WHEN locked_until IS NOT NULL AND locked_until <= ? THEN NULL 
+				ELSE locked_until END 
+			  WHERE id = ? AND deleted_at IS NULL`
+	if _, err := r.db.ExecContext(ctx, query, now, maxAttempts, maxAttempts, now.Add(lockout), now, user.ID); err != nil {
+		return err
+	}
+
+	var userModel model.UserModel
+	query = `SELECT failed_login_attempts, locked_until FROM user WHERE id = ?`
+	if err := r.db.GetContext(ctx, &userModel, query, user.ID); err != nil {
+		return err
+	}
+
+	user.FailedLoginAttempts = userModel.FailedLoginAttempts
+	user.LockedUntil = userModel.LockedUntil
+	return nil
+}
+
+func (r *userRepositoryImpl) UpdatePasswordHash(ctx context.Context, user *entity.User) error {
+	// The password itself did not change, so updated_at/updated_by stay untouched
+	query := `UPDATE user SET password_hash = ? WHERE id = ? AND deleted_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, user.PasswordHash, user.ID)
+	return err
+}
+
+func (r *userRepositoryImpl) GetPasswordHistory(ctx context.Context, userID, limit int) ([]string, error) {
+	hashes := []string{}
+
+	query := `SELECT password_hash FROM user_password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?`
+	if err := r.db.SelectContext(ctx, &hashes, query, userID, limit); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+func (r *userRepositoryImpl) AddPasswordHistory(ctx context.Context, userID int, passwordHash string, keep int) error {
+	query := `INSERT INTO user_password_history (user_id, password_hash, created_at) VALUES (?, ?, NOW())`
+	if _, err := r.db.ExecContext(ctx, query, userID, passwordHash); err != nil {
+		return err
+	}
+
+	// MySQL does not allow LIMIT in an IN subquery, hence the derived table
+	query = `DELETE FROM user_password_history WHERE user_id = ? AND id NOT IN (
+			  SELECT id FROM (SELECT id FROM user_password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?) AS newest)`
+	_, err := r.db.ExecContext(ctx, query, userID, userID, keep)
+	return err
+}
+
+func (r *userRepositoryImpl) GetDeletedByID(ctx context.Context, id int) (*entity.User, error) {
+	var userModel model.UserModel
+
+	query := `SELECT * FROM user WHERE id = ? AND deleted_at IS NOT NULL AND anonymized_at IS NULL`
+	err := r.db.GetContext(ctx, &userModel, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.modelToEntity(&userModel), nil
+}
+
+func (r *userRepositoryImpl) Restore(ctx context.Context, id int, restoredBy int) error {
+	query := `UPDATE user SET deleted_at = NULL, deleted_by = NULL, updated_by = ?, updated_at = NOW() 
+			  WHERE id = ? AND deleted_at IS NOT NULL AND anonymized_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, restoredBy, id)
+	return err
+}
+
+func (r *userRepositoryImpl) GetPurgeable(ctx context.Context, deletedBefore time.Time, limit int) ([]int, error) {
+	ids := []int{}
+
+	query := `SELECT id FROM user WHERE deleted_at IS NOT NULL AND deleted_at < ? AND anonymized_at IS NULL 
+			  ORDER BY deleted_at, id LIMIT ?`
+	if err := r.db.SelectContext(ctx, &ids, query, deletedBefore, limit); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// userPurgeCascade lists the statements removing the data of a purged user from other tables.
+// The audit log is kept, it records what happened and not who someone is.
+var userPurgeCascade = []string{
+	`DELETE FROM user_sessions WHERE user_id = ?`,
+	`DELETE FROM refresh_token WHERE user_id = ?`,
+	`DELETE FROM revoked_token WHERE user_id = ?`,
+	`DELETE FROM user_token_revocation WHERE user_id = ?`,
+	`DELETE FROM user_role WHERE user_id = ?`,
+	`DELETE FROM user_recovery_code WHERE user_id = ?`,
+	`DELETE FROM user_mfa WHERE user_id = ?`,
+	`DELETE FROM user_password_history WHERE user_id = ?`,
+}
+
+func (r *userRepositoryImpl) Purge(ctx context.Context, id int, anonymize bool) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The user goes first, so a user restored in the meantime keeps its data
+	query := `DELETE FROM user WHERE id = ? AND deleted_at IS NOT NULL AND anonymized_at IS NULL`
+	if anonymize {
+		query = `UPDATE user SET username = CONCAT('deleted-', id), email = CONCAT('deleted-', id, '@deleted.invalid'), 
+				  pending_email = NULL, full_name = '', phone = '', locale = '', timezone = '', password_hash = '', 
+				  auth_key = '', verification_token = NULL, password_reset_token = NULL, password_reset_expires_at = NULL, 
+				  anonymized_at = NOW() WHERE id = ? AND deleted_at IS NOT NULL AND anonymized_at IS NULL`
+	}
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	for _, stmt := range userPurgeCascade {
+		if _, err := tx.ExecContext(ctx, stmt, id); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *userRepositoryImpl) modelToEntity(userModel *model.UserModel) *entity.User {
+	return &entity.User{
+		ID:                     userModel.ID,
+		Username:               userModel.Username,
+		Email:                  userModel.Email,
+		PendingEmail:           userModel.PendingEmail,
+		FullName:               userModel.FullName,
+		Phone:                  userModel.Phone,
+		Locale:                 userModel.Locale,
+		Timezone:               userModel.Timezone,
+		PasswordHash:           userModel.PasswordHash,
+		PasswordResetToken:     userModel.PasswordResetToken,
+		PasswordResetExpiresAt: userModel.PasswordResetExpiresAt,
+		Status:                 userModel.Status,
+		FailedLoginAttempts:    userModel.FailedLoginAttempts,
+		LockedUntil:            userModel.LockedUntil,
+		VerificationToken:      userModel.VerificationToken,
+		AuthKey:                userModel.AuthKey,
+		CreatedAt:              userModel.CreatedAt,
+		UpdatedAt:              userModel.UpdatedAt,
+		DeletedAt:              userModel.DeletedAt,
+		CreatedBy:              userModel.CreatedBy,
+		UpdatedBy:              userModel.UpdatedBy,
+		DeletedBy:              userModel.DeletedBy,
+	}
+}
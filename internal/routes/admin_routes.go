@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"go-rest-api-template/internal/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SetupAdminRoutes sets up administration routes, restricted to admin users
+func SetupAdminRoutes(app *fiber.App, config *RouteConfig) {
+	apiKeyHandler := config.ApiKeyHandler
+	roleHandler := config.RoleHandler
+	userHandler := config.UserHandler
+	auditLogHandler := config.AuditLogHandler
+	personalDataHandler := config.PersonalDataHandler
+	userBulkHandler := config.UserBulkHandler
+	impersonationHandler := config.ImpersonationHandler
+
+	// API versioning
+	v1 := app.Group("/api/v1")
+
+	// Private middleware - requires API key + private JWT token
+	privateMiddleware := middleware.PrivateMiddleware(config.ApiKeyService, config.JWTService, config.SessionService)
+
+	// H2H API keys must additionally sign every request with their auth key
+	h2hSignatureMiddleware := middleware.H2HSignatureMiddleware(config.RequestSignatureService)
+
+	// Only users listed in admin.user_ids get past this point
+	adminMiddleware := middleware.AdminMiddleware(config.AdminUserIDs, config.RoleService)
+
+	// Requests per API key are limited by the limits stored on the key
+	rateLimitMiddleware := middleware.RateLimitMiddleware(config.RateLimitService)
+
+	// Impersonation tokens never reach the admin endpoints, not even those of an admin's own account
+	denyImpersonation := middleware.DenyImpersonation()
+
+	admin := v1.Group("/admin", privateMiddleware, denyImpersonation, rateLimitMiddleware, h2hSignatureMiddleware, adminMiddleware)
+
+	// Compliance: admins must have two-factor authentication enabled
+	if config.RequireAdminMFA {
+		admin.Use(middleware.RequireMFAMiddleware(config.MFAService))
+	}
+
+	// API key management
+	apiKeys := admin.Group("/api-keys")
+	apiKeys.Get("/", apiKeyHandler.GetApiKeys)
+	apiKeys.Post("/", apiKeyHandler.CreateApiKey)
+	apiKeys.Get("/:id", apiKeyHandler.GetApiKey)
+	apiKeys.Put("/:id", apiKeyHandler.UpdateApiKey)
+	apiKeys.Post("/:id/rotate", apiKeyHandler.RotateApiKey)
+	apiKeys.Delete("/:id", apiKeyHandler.RevokeApiKey)
+
+	// Role assignment
+	admin.Get("/roles", roleHandler.GetRoles)
+	users := admin.Group("/users")
+	users.Get("/:id/roles", roleHandler.GetUserRoles)
+	users.Post("/:id/roles", roleHandler.AssignRole)
+	users.Delete("/:id/roles/:role", roleHandler.RevokeRole)
+
+	// Lockouts after failed logins
+	users.Post("/:id/unlock", userHandler.UnlockUser)
+
+	// Deleted users can be restored until they are purged (user_retention.*)
+	users.Get("/deleted", userHandler.GetDeletedUsers)
+	users.Post("/:id/restore", userHandler.RestoreUser)
+
+	// Support staff act as a user with a short-lived token to reproduce issues
+	users.Post("/:id/impersonate", impersonationHandler.Impersonate)
+
+	// Erasure requests received outside the API
+	users.Post("/:id/erase", personalDataHandler.EraseUser)
+
+	// Bulk import and export run as jobs, polled until they finished
+	users.Post("/import", userBulkHandler.ImportUsers)
+	users.Post("/export", userBulkHandler.ExportUsers)
+	users.Get("/jobs/:id", userBulkHandler.GetJob)
+	users.Get("/jobs/:id/download", userBulkHandler.DownloadExport)
+
+	// Who did what, filterable by actor, action and entity
+	admin.Get("/audit-logs", auditLogHandler.GetAuditLogs)
+}
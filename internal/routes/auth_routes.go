@@ -1,38 +1,84 @@
 package routes
 
 import (
-	"go-rest-api-template/internal/handler"
+	"go-rest-api-template/internal/constant"
 	"go-rest-api-template/internal/middleware"
-	"go-rest-api-template/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // SetupAuthRoutes sets up authentication routes
-func SetupAuthRoutes(app *fiber.App, authHandler *handler.AuthHandler, apiKeyService service.ApiKeyService, jwtService service.JWTService) {
+func SetupAuthRoutes(app *fiber.App, config *RouteConfig) {
+	authHandler := config.AuthHandler
+	userHandler := config.UserHandler
+	mfaHandler := config.MFAHandler
+	personalDataHandler := config.PersonalDataHandler
+
 	// API versioning
 	v1 := app.Group("/api/v1")
 
 	// API Key Only Middleware - following industry best practices for register/login endpoints
 	// This approach is used by major platforms like Strapi, GitHub, Twitter/X
-	apiKeyOnlyMiddleware := middleware.ApiKeyOnlyMiddleware(apiKeyService)
+	apiKeyOnlyMiddleware := middleware.ApiKeyOnlyMiddleware(config.ApiKeyService)
+
+	// H2H API keys must additionally sign every request with their auth key
+	h2hSignatureMiddleware := middleware.H2HSignatureMiddleware(config.RequestSignatureService)
+
+	// Requests per API key are limited by the limits stored on the key
+	rateLimitMiddleware := middleware.RateLimitMiddleware(config.RateLimitService)
 
 	// Public endpoints - only require API key (no JWT tokens needed)
-	public := v1.Group("/public", apiKeyOnlyMiddleware)
+	public := v1.Group("/public", apiKeyOnlyMiddleware, rateLimitMiddleware, h2hSignatureMiddleware)
 
-	// Auth endpoints - simplified authentication following industry standards
+	// Login and register are additionally limited per client IP against credential stuffing
+	loginIPLimit := middleware.IPRateLimitMiddleware(config.RateLimitService, "login")
+	registerIPLimit := middleware.IPRateLimitMiddleware(config.RateLimitService, "register")
+	passwordResetIPLimit := middleware.IPRateLimitMiddleware(config.RateLimitService, "password_reset")
+	resendVerificationIPLimit := middleware.IPRateLimitMiddleware(config.RateLimitService, "resend_verification")
+	mfaVerifyIPLimit := middleware.IPRateLimitMiddleware(config.RateLimitService, "mfa_verify")
+
+	// Auth endpoints - simplified authentication following industry standards.
+	// Each endpoint requires the matching scope on the API key
 	auth := public.Group("/auth")
-	auth.Get("/token", authHandler.GetPublicToken)  // GET /api/v1/public/auth/token - Get public token
-	auth.Post("/register", authHandler.Register)    // POST /api/v1/public/auth/register - Register new user
-	auth.Post("/login", authHandler.Login)          // POST /api/v1/public/auth/login - Login (get private token)
-	auth.Post("/refresh", authHandler.RefreshToken) // POST /api/v1/public/auth/refresh - Refresh token
-	auth.Post("/logout", authHandler.Logout)        // POST /api/v1/public/auth/logout - Logout
+	auth.Get("/token", authHandler.GetPublicToken)                                                                            // GET /api/v1/public/auth/token - Get public token
+	auth.Post("/register", registerIPLimit, middleware.RequireScopes(constant.ApiKeyScopeAuthRegister), authHandler.Register) // POST /api/v1/public/auth/register - Register new user
+	auth.Post("/login", loginIPLimit, middleware.RequireScopes(constant.ApiKeyScopeAuthLogin), authHandler.Login)             // POST /api/v1/public/auth/login - Login (get private token)
+	auth.Post("/refresh", middleware.RequireScopes(constant.ApiKeyScopeAuthLogin), authHandler.RefreshToken)                  // POST /api/v1/public/auth/refresh - Refresh token
+
+	// Password reset - the user has no private token, so these only need an API key with the login scope
+	auth.Post("/forgot-password", passwordResetIPLimit, middleware.RequireScopes(constant.ApiKeyScopeAuthLogin), userHandler.ForgotPassword) // POST /api/v1/public/auth/forgot-password - Mail a reset token
+	auth.Post("/reset-password", passwordResetIPLimit, middleware.RequireScopes(constant.ApiKeyScopeAuthLogin), userHandler.ResetPassword)   // POST /api/v1/public/auth/reset-password - Set a new password
+
+	// Email verification of new accounts (auth.require_email_verification)
+	auth.Post("/verify-email", middleware.RequireScopes(constant.ApiKeyScopeAuthRegister), authHandler.VerifyEmail)                                          // POST /api/v1/public/auth/verify-email - Activate a pending account
+	auth.Post("/resend-verification", resendVerificationIPLimit, middleware.RequireScopes(constant.ApiKeyScopeAuthRegister), authHandler.ResendVerification) // POST /api/v1/public/auth/resend-verification - Mail a new verification link
+
+	// Second login step of users with two-factor authentication, exchanges the MFA token from login
+	auth.Post("/mfa/verify", mfaVerifyIPLimit, middleware.RequireScopes(constant.ApiKeyScopeAuthLogin), authHandler.VerifyMFA) // POST /api/v1/public/auth/mfa/verify - Check a TOTP or recovery code (get private token)
 
 	// Private endpoints - require API key + private JWT token
-	// privateMiddleware := middleware.PrivateMiddleware(apiKeyService, jwtService)
-	// private := v1.Group("/private", privateMiddleware)
+	privateMiddleware := middleware.PrivateMiddleware(config.ApiKeyService, config.JWTService, config.SessionService)
+
+	// Requests of admins impersonating the user are audited, sensitive ones refused
+	impersonationAudit := middleware.ImpersonationAuditMiddleware(config.AuditService)
+	denyImpersonation := middleware.DenyImpersonation()
+
+	private := v1.Group("/private", privateMiddleware, impersonationAudit, rateLimitMiddleware, h2hSignatureMiddleware)
+
+	// Private auth endpoints
+	privateAuth := private.Group("/auth")
+	privateAuth.Post("/logout", authHandler.Logout) // POST /api/v1/private/auth/logout - Logout (revokes token)
+
+	// Two-factor authentication of the current user
+	privateAuth.Post("/mfa/enroll", denyImpersonation, mfaHandler.Enroll)   // POST /api/v1/private/auth/mfa/enroll - Create a TOTP secret
+	privateAuth.Post("/mfa/confirm", denyImpersonation, mfaHandler.Confirm) // POST /api/v1/private/auth/mfa/confirm - Enable 2FA with a first code
+	privateAuth.Post("/mfa/disable", denyImpersonation, mfaHandler.Disable) // POST /api/v1/private/auth/mfa/disable - Disable 2FA with a code
+
+	// Profile of the current user
+	private.Get("/me", userHandler.GetProfile)                         // GET /api/v1/private/me - Get own profile
+	private.Patch("/me", denyImpersonation, userHandler.UpdateProfile) // PATCH /api/v1/private/me - Update own profile, email changes need verification
 
-	// Private auth endpoints (if needed) - commented out until method is implemented
-	// privateAuth := private.Group("/auth")
-	// privateAuth.Get("/me", authHandler.GetCurrentUser) // GET /api/v1/private/auth/me - Get current user info
+	// Data subject requests of the current user
+	private.Get("/me/export", personalDataHandler.ExportMyData)                      // GET /api/v1/private/me/export - Download all personal data as JSON
+	private.Post("/me/erase", denyImpersonation, personalDataHandler.EraseMyAccount) // POST /api/v1/private/me/erase - Anonymize own account, confirmed with the password
 }
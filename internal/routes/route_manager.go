@@ -8,12 +8,33 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-// RouteConfig holds all handlers needed for route setup
+// RouteConfig holds all handlers and services needed for route setup
 type RouteConfig struct {
-	UserHandler   *handler.UserHandler
-	AuthHandler   *handler.AuthHandler
-	JWTService    service.JWTService
-	ApiKeyService service.ApiKeyService
+	UserHandler          *handler.UserHandler
+	AuthHandler          *handler.AuthHandler
+	SessionHandler       *handler.SessionHandler
+	ApiKeyHandler        *handler.ApiKeyHandler
+	RoleHandler          *handler.RoleHandler
+	MFAHandler           *handler.MFAHandler
+	AuditLogHandler      *handler.AuditLogHandler
+	PersonalDataHandler  *handler.PersonalDataHandler
+	UserBulkHandler      *handler.UserBulkHandler
+	ImpersonationHandler *handler.ImpersonationHandler
+
+	// Services used by route middleware
+	JWTService              service.JWTService
+	ApiKeyService           service.ApiKeyService
+	SessionService          service.SessionService
+	RequestSignatureService service.RequestSignatureService
+	RateLimitService        service.RateLimitService
+	RoleService             service.RoleService
+	MFAService              service.MFAService
+	AuditService            service.AuditService
+
+	// Users allowed to call the admin endpoints
+	AdminUserIDs []int
+	// Admin endpoints refuse users without two-factor authentication (mfa.required_for_admins)
+	RequireAdminMFA bool
 	// ProductHandler *handler.ProductHandler  // Future
 	// OrderHandler   *handler.OrderHandler    // Future
 }
@@ -32,18 +53,28 @@ func SetupAllRoutes(app *fiber.App, config *RouteConfig) {
 		})
 	})
 
+	// Public verification keys for downstream services (no middleware required).
+	// Served as a bare JWK Set, not wrapped in the response envelope.
+	app.Get("/.well-known/jwks.json", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+		return c.JSON(config.JWTService.JWKS())
+	})
+
 	// Setup authentication routes
-	SetupAuthRoutes(app, config.AuthHandler, config.ApiKeyService, config.JWTService)
+	SetupAuthRoutes(app, config)
 
 	// Setup user routes
-	setupUserRoutes(app, config.UserHandler, config.ApiKeyService, config.JWTService)
+	setupUserRoutes(app, config)
+
+	// Setup admin routes
+	SetupAdminRoutes(app, config)
 	// setupProductRoutes(app, config.ProductHandler)  // Future
 	// setupOrderRoutes(app, config.OrderHandler)      // Future
 }
 
 // setupUserRoutes sets up user-related routes
-func setupUserRoutes(app *fiber.App, userHandler *handler.UserHandler, apiKeyService service.ApiKeyService, jwtService service.JWTService) {
-	SetupUserRoutes(app, userHandler, apiKeyService, jwtService)
+func setupUserRoutes(app *fiber.App, config *RouteConfig) {
+	SetupUserRoutes(app, config)
 }
 
 // Future route setups (examples)
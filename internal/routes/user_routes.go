@@ -1,32 +1,62 @@
 package routes
 
 import (
-	"go-rest-api-template/internal/handler"
+	"go-rest-api-template/internal/constant"
 	"go-rest-api-template/internal/middleware"
-	"go-rest-api-template/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // SetupUserRoutes sets up user-related routes with Private JWT middleware
-func SetupUserRoutes(app *fiber.App, userHandler *handler.UserHandler, apiKeyService service.ApiKeyService, jwtService service.JWTService) {
+func SetupUserRoutes(app *fiber.App, config *RouteConfig) {
+	userHandler := config.UserHandler
+	sessionHandler := config.SessionHandler
+
 	// API versioning
 	v1 := app.Group("/api/v1")
 
 	// Private middleware - requires API key + private JWT token
-	privateMiddleware := middleware.PrivateMiddleware(apiKeyService, jwtService)
+	privateMiddleware := middleware.PrivateMiddleware(config.ApiKeyService, config.JWTService, config.SessionService)
+
+	// H2H API keys must additionally sign every request with their auth key
+	h2hSignatureMiddleware := middleware.H2HSignatureMiddleware(config.RequestSignatureService)
+
+	// Requests per API key are limited by the limits stored on the key
+	rateLimitMiddleware := middleware.RateLimitMiddleware(config.RateLimitService)
+
+	// Requests of admins impersonating the user are audited
+	impersonationAudit := middleware.ImpersonationAuditMiddleware(config.AuditService)
+
+	// Permissions granted by the roles in the private token
+	permissionMiddleware := middleware.PermissionMiddleware(config.RoleService)
 
 	// Create user routes group with private middleware
-	userGroup := v1.Group("/users", privateMiddleware)
+	userGroup := v1.Group("/users", privateMiddleware, impersonationAudit, rateLimitMiddleware, h2hSignatureMiddleware, permissionMiddleware)
+
+	// API key scopes required per route
+	readScope := middleware.RequireScopes(constant.ApiKeyScopeUsersRead)
+	writeScope := middleware.RequireScopes(constant.ApiKeyScopeUsersWrite)
+	loginScope := middleware.RequireScopes(constant.ApiKeyScopeAuthLogin)
+
+	// Admins impersonating the user can neither change or delete the account, nor its password or sessions
+	denyImpersonation := middleware.DenyImpersonation()
+
+	// Users may only act on their own account unless they can manage other users
+	ownerOrAdmin := middleware.OwnerOrAdmin("id")
+	canRead := middleware.Authorize(constant.PermissionUsersRead)
+	canUpdate := middleware.Authorize(constant.PermissionUsersUpdate, ownerOrAdmin)
+	canDelete := middleware.Authorize(constant.PermissionUsersDelete, ownerOrAdmin)
+
+	// Session routes of the current user (registered before /:id)
+	userGroup.Get("/me/sessions", loginScope, sessionHandler.GetSessions)
+	userGroup.Delete("/me/sessions/:id", denyImpersonation, loginScope, sessionHandler.TerminateSession)
 
 	// User CRUD routes
-	userGroup.Get("/", userHandler.GetAllUsers)
-	userGroup.Get("/:id", userHandler.GetUserByID)
-	userGroup.Put("/:id", userHandler.UpdateUser)
-	userGroup.Delete("/:id", userHandler.DeleteUser)
-
-	// Password management routes
-	userGroup.Post("/forgot-password", userHandler.ForgotPassword)
-	userGroup.Post("/reset-password", userHandler.ResetPassword)
-	userGroup.Post("/:id/change-password", userHandler.ChangePassword)
+	userGroup.Get("/", readScope, canRead, userHandler.GetAllUsers)
+	userGroup.Get("/:id", readScope, canRead, userHandler.GetUserByID)
+	userGroup.Put("/:id", denyImpersonation, writeScope, canUpdate, userHandler.UpdateUser)
+	userGroup.Delete("/:id", denyImpersonation, writeScope, canDelete, userHandler.DeleteUser)
+
+	// Password management routes, forgot and reset password are public auth routes
+	userGroup.Post("/:id/change-password", denyImpersonation, writeScope, canUpdate, userHandler.ChangePassword)
 }
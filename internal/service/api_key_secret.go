@@ -0,0 +1,115 @@
+package service
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// ApiKeyPrefixLength is the number of leading characters of an API key stored in clear for lookup
+const ApiKeyPrefixLength = 8
+
+// ApiKeySecretManager protects API key secrets at rest.
+// API keys are stored as a prefix plus a keyed hash. Auth keys are the HMAC secret of
+// H2H request signing, so the server must be able to read them: they are encrypted instead.
+type ApiKeySecretManager struct {
+	hashKey []byte
+	aead    cipher.AEAD
+}
+
+// NewApiKeySecretManager derives the hashing and encryption keys from the configured secret
+func NewApiKeySecretManager(secret string) (*ApiKeySecretManager, error) {
+	if secret == "" {
+		return nil, errors.New("api key secret is required")
+	}
+
+	aead, err := newAEAD(secret, "api-key-encryption")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ApiKeySecretManager{
+		hashKey: deriveKey(secret, "api-key-hash"),
+		aead:    aead,
+	}, nil
+}
+
+// Prefix returns the lookup prefix of an API key
+func (m *ApiKeySecretManager) Prefix(apiKey string) string {
+	if len(apiKey) < ApiKeyPrefixLength {
+		return apiKey
+	}
+	return apiKey[:ApiKeyPrefixLength]
+}
+
+// Hash returns the hex encoded keyed hash of an API key
+func (m *ApiKeySecretManager) Hash(apiKey string) string {
+	mac := hmac.New(sha256.New, m.hashKey)
+	mac.Write([]byte(apiKey))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Matches compares an API key against a stored hash in constant time
+func (m *ApiKeySecretManager) Matches(apiKey, storedHash string) bool {
+	return hmac.Equal([]byte(m.Hash(apiKey)), []byte(storedHash))
+}
+
+// Encrypt encrypts an auth key with AES-GCM, returning base64(nonce + ciphertext)
+func (m *ApiKeySecretManager) Encrypt(authKey string) (string, error) {
+	return sealString(m.aead, authKey)
+}
+
+// Decrypt reverses Encrypt
+func (m *ApiKeySecretManager) Decrypt(encrypted string) (string, error) {
+	return openString(m.aead, encrypted)
+}
+
+// newAEAD returns an AES-GCM cipher keyed for a single purpose
+func newAEAD(secret, purpose string) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveKey(secret, purpose))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealString encrypts plain with a random nonce, returning base64(nonce + ciphertext)
+func sealString(aead cipher.AEAD, plain string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openString reverses sealString
+func openString(aead cipher.AEAD, encrypted string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// deriveKey derives a 32 byte key for a single purpose from the configured secret
+func deriveKey(secret, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
@@ -2,59 +2,107 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"go-rest-api-template/internal/constant"
 	"go-rest-api-template/internal/domain/entity"
 	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/pkg/ipfilter"
+	"strconv"
+	"sync"
+	"time"
 )
 
-// ApiKeyService handles API key business logic (read-only for JWT middleware)
+// API key management errors
+var (
+	ErrApiKeyNotFound = errors.New("api key not found")
+	ErrApiKeyRevoked  = errors.New("api key has been revoked")
+	ErrInvalidIPList  = errors.New("invalid ip list")
+)
+
+// ApiKeyService handles API key business logic
 type ApiKeyService interface {
-	// Core validation methods for JWT middleware
+	// Core validation method for JWT middleware
 	ValidateApiKey(ctx context.Context, apiKey string) (*entity.ApiKey, error)
-	ValidateAuthKey(ctx context.Context, authKey string) (*entity.ApiKey, error)
 
 	// Admin methods for management
 	GetApiKeyByID(ctx context.Context, id int) (*entity.ApiKey, error)
 	GetAllApiKeys(ctx context.Context, limit, offset int) ([]*entity.ApiKey, error)
 	GetActiveApiKeys(ctx context.Context, limit, offset int) ([]*entity.ApiKey, error)
+	GetApiKeyCount(ctx context.Context) (int, error)
+
+	// CreateApiKey generates the secrets of a new key and stores them hashed/encrypted.
+	// The plaintext ApiKey and AuthKey are only available on the returned entity.
+	CreateApiKey(ctx context.Context, apiKey *entity.ApiKey) error
+	UpdateApiKey(ctx context.Context, apiKey *entity.ApiKey) error
+	// RotateApiKey issues new secrets. The old ones keep working for gracePeriod,
+	// so clients can switch over without downtime. Zero invalidates them immediately.
+	RotateApiKey(ctx context.Context, id int, gracePeriod time.Duration, updatedBy *int) (*entity.ApiKey, error)
+	RevokeApiKey(ctx context.Context, id int, updatedBy *int) error
+
+	// HashPlaintextKeys converts keys stored before hashing was introduced
+	HashPlaintextKeys(ctx context.Context) (int, error)
 
 	// Logging method
 	LogApiKeyAccess(ctx context.Context, id int) error
 }
 
+// apiKeyUsageAuditInterval is how often the use of a key from one IP is audited,
+// every request would flood the audit log
+const apiKeyUsageAuditInterval = time.Hour
+
 type apiKeyService struct {
-	apiKeyRepo repository.ApiKeyRepository
+	apiKeyRepo   repository.ApiKeyRepository
+	secrets      *ApiKeySecretManager
+	auditService AuditService
+
+	usageMu      sync.Mutex
+	usageAudited map[string]time.Time // key id and IP -> last audit
 }
 
 // NewApiKeyService creates a new API key service
-func NewApiKeyService(apiKeyRepo repository.ApiKeyRepository) ApiKeyService {
+func NewApiKeyService(apiKeyRepo repository.ApiKeyRepository, secrets *ApiKeySecretManager, auditService AuditService) ApiKeyService {
 	return &apiKeyService{
-		apiKeyRepo: apiKeyRepo,
+		apiKeyRepo:   apiKeyRepo,
+		secrets:      secrets,
+		auditService: auditService,
+		usageAudited: make(map[string]time.Time),
 	}
 }
 
 func (s *apiKeyService) ValidateApiKey(ctx context.Context, apiKey string) (*entity.ApiKey, error) {
-	key, err := s.apiKeyRepo.GetByApiKey(ctx, apiKey)
+	candidates, err := s.apiKeyRepo.GetByPrefix(ctx, s.secrets.Prefix(apiKey))
 	if err != nil {
 		return nil, err
 	}
 
-	if key == nil || !key.IsActive() {
-		return nil, nil // Invalid or inactive key
-	}
-
-	return key, nil
-}
-
-func (s *apiKeyService) ValidateAuthKey(ctx context.Context, authKey string) (*entity.ApiKey, error) {
-	key, err := s.apiKeyRepo.GetByAuthKey(ctx, authKey)
-	if err != nil {
-		return nil, err
+	var key *entity.ApiKey
+	authKeyEncrypted := ""
+	for _, candidate := range candidates {
+		if s.secrets.Matches(apiKey, candidate.ApiKeyHash) {
+			key, authKeyEncrypted = candidate, candidate.AuthKeyEncrypted
+			break
+		}
+		// Clients still using the secrets of a rotated key sign with the old auth key
+		if candidate.IsPreviousKeyValid() && s.secrets.Matches(apiKey, candidate.PreviousApiKeyHash) {
+			key, authKeyEncrypted = candidate, candidate.PreviousAuthKeyEncrypted
+			break
+		}
 	}
 
 	if key == nil || !key.IsActive() {
 		return nil, nil // Invalid or inactive key
 	}
 
+	// The auth key is needed to verify H2H request signatures
+	if authKeyEncrypted != "" {
+		authKey, err := s.secrets.Decrypt(authKeyEncrypted)
+		if err != nil {
+			return nil, err
+		}
+		key.AuthKey = authKey
+	}
+
 	return key, nil
 }
 
@@ -70,6 +118,234 @@ func (s *apiKeyService) GetActiveApiKeys(ctx context.Context, limit, offset int)
 	return s.apiKeyRepo.GetByStatus(ctx, "active", limit, offset)
 }
 
+func (s *apiKeyService) GetApiKeyCount(ctx context.Context) (int, error) {
+	return s.apiKeyRepo.GetCount(ctx)
+}
+
+func (s *apiKeyService) CreateApiKey(ctx context.Context, apiKey *entity.ApiKey) error {
+	plainApiKey, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+	plainAuthKey, err := randomHex(32)
+	if err != nil {
+		return err
+	}
+
+	apiKey.ApiKey = plainApiKey
+	apiKey.AuthKey = plainAuthKey
+	if apiKey.Status == "" {
+		apiKey.Status = "active"
+	}
+	if apiKey.H2H == "" {
+		apiKey.H2H = "N"
+	}
+	if apiKey.Scopes == nil {
+		apiKey.Scopes = []string{constant.ApiKeyScopeAll}
+	}
+	if err := validateIPLists(apiKey); err != nil {
+		return err
+	}
+
+	if err := s.protectSecrets(apiKey); err != nil {
+		return err
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, AuditEntry{
+		Action:     constant.AuditActionApiKeyCreated,
+		EntityType: constant.AuditEntityApiKey,
+		EntityID:   apiKey.ID,
+		After:      apiKey,
+	})
+	return nil
+}
+
+func (s *apiKeyService) UpdateApiKey(ctx context.Context, apiKey *entity.ApiKey) error {
+	existing, err := s.apiKeyRepo.GetByID(ctx, apiKey.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrApiKeyNotFound
+	}
+	// A revoked key stays revoked, re-enabling it would bring back leaked secrets
+	if existing.IsRevoked() {
+		return ErrApiKeyRevoked
+	}
+	if err := validateIPLists(apiKey); err != nil {
+		return err
+	}
+
+	if err := s.apiKeyRepo.Update(ctx, apiKey); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, AuditEntry{
+		Action:     constant.AuditActionApiKeyUpdated,
+		EntityType: constant.AuditEntityApiKey,
+		EntityID:   apiKey.ID,
+		Before:     existing,
+		After:      apiKey,
+	})
+	return nil
+}
+
+func (s *apiKeyService) RotateApiKey(ctx context.Context, id int, gracePeriod time.Duration, updatedBy *int) (*entity.ApiKey, error) {
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey == nil {
+		return nil, ErrApiKeyNotFound
+	}
+	if apiKey.IsRevoked() {
+		return nil, ErrApiKeyRevoked
+	}
+
+	plainApiKey, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	plainAuthKey, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey.ApiKey = plainApiKey
+	apiKey.AuthKey = plainAuthKey
+	apiKey.UpdatedBy = updatedBy
+	if err := s.protectSecrets(apiKey); err != nil {
+		return nil, err
+	}
+
+	previousExpiresAt := time.Now().Add(gracePeriod)
+	if err := s.apiKeyRepo.Rotate(ctx, apiKey, previousExpiresAt); err != nil {
+		return nil, err
+	}
+	if gracePeriod > 0 {
+		apiKey.PreviousKeyExpiresAt = &previousExpiresAt
+	} else {
+		apiKey.PreviousKeyExpiresAt = nil
+	}
+
+	s.auditService.Record(ctx, AuditEntry{
+		Action:     constant.AuditActionApiKeyRotated,
+		EntityType: constant.AuditEntityApiKey,
+		EntityID:   apiKey.ID,
+		Details: map[string]interface{}{
+			"grace_period_seconds": int(gracePeriod.Seconds()),
+		},
+	})
+	return apiKey, nil
+}
+
+func (s *apiKeyService) RevokeApiKey(ctx context.Context, id int, updatedBy *int) error {
+	apiKey, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if apiKey == nil {
+		return ErrApiKeyNotFound
+	}
+
+	if err := s.apiKeyRepo.Revoke(ctx, id, updatedBy); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, AuditEntry{
+		Action:     constant.AuditActionApiKeyRevoked,
+		EntityType: constant.AuditEntityApiKey,
+		EntityID:   id,
+	})
+	return nil
+}
+
+func (s *apiKeyService) HashPlaintextKeys(ctx context.Context) (int, error) {
+	keys, err := s.apiKeyRepo.GetWithPlaintextSecrets(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, key := range keys {
+		if err := s.protectSecrets(key); err != nil {
+			return i, err
+		}
+		if err := s.apiKeyRepo.UpdateSecrets(ctx, key); err != nil {
+			return i, err
+		}
+	}
+
+	return len(keys), nil
+}
+
 func (s *apiKeyService) LogApiKeyAccess(ctx context.Context, id int) error {
-	return s.apiKeyRepo.UpdateLastAccess(ctx, id)
+	if err := s.apiKeyRepo.UpdateLastAccess(ctx, id); err != nil {
+		return err
+	}
+
+	actor := AuditActorFromContext(ctx)
+	if s.shouldAuditUsage(id, actor.IP) {
+		s.auditService.Record(ctx, AuditEntry{
+			Action:     constant.AuditActionApiKeyUsed,
+			EntityType: constant.AuditEntityApiKey,
+			EntityID:   id,
+		})
+	}
+	return nil
+}
+
+// shouldAuditUsage reports if the use of a key from ip was not audited within apiKeyUsageAuditInterval
+func (s *apiKeyService) shouldAuditUsage(id int, ip string) bool {
+	s.usageMu.Lock()
+	defer s.usageMu.Unlock()
+
+	now := time.Now()
+	key := strconv.Itoa(id) + "|" + ip
+	if last, ok := s.usageAudited[key]; ok && now.Sub(last) < apiKeyUsageAuditInterval {
+		return false
+	}
+
+	// Forget expired entries so the map does not grow with every client ever seen
+	for k, last := range s.usageAudited {
+		if now.Sub(last) >= apiKeyUsageAuditInterval {
+			delete(s.usageAudited, k)
+		}
+	}
+	s.usageAudited[key] = now
+	return true
+}
+
+// validateIPLists rejects lists the middleware could not parse
+func validateIPLists(apiKey *entity.ApiKey) error {
+	for _, list := range []*string{apiKey.IPWhitelist, apiKey.IPDenylist} {
+		if list == nil {
+			continue
+		}
+		if _, err := ipfilter.Parse(*list); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidIPList, err)
+		}
+	}
+	return nil
+}
+
+// protectSecrets fills the stored forms of the plaintext ApiKey and AuthKey
+func (s *apiKeyService) protectSecrets(apiKey *entity.ApiKey) error {
+	if apiKey.ApiKey != "" {
+		apiKey.ApiKeyPrefix = s.secrets.Prefix(apiKey.ApiKey)
+		apiKey.ApiKeyHash = s.secrets.Hash(apiKey.ApiKey)
+	}
+
+	if apiKey.AuthKey != "" {
+		encrypted, err := s.secrets.Encrypt(apiKey.AuthKey)
+		if err != nil {
+			return err
+		}
+		apiKey.AuthKeyEncrypted = encrypted
+	}
+
+	return nil
 }
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/pkg/logger"
+	"go-rest-api-template/pkg/query"
+	"reflect"
+	"time"
+)
+
+// AuditActor is who performs an action: the authenticated user, the API key and the client IP.
+// Zero values mean unknown, e.g. no user before login or the system in CLI commands.
+type AuditActor struct {
+	UserID   int
+	ApiKeyID int
+	IP       string
+
+	// ImpersonatorID is the admin acting as UserID with an impersonation token
+	ImpersonatorID int
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor returns a context carrying the actor, for work outside a request
+// like CLI commands and background jobs
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActorFromContext returns the actor of ctx. Handlers pass c.Context() to the services,
+// whose Value returns the request locals, so the actor is read from what the middlewares
+// stored there ("user_id", "api_key_id", "client_ip", "impersonator_id").
+func AuditActorFromContext(ctx context.Context) AuditActor {
+	if actor, ok := ctx.Value(auditActorKey{}).(AuditActor); ok {
+		return actor
+	}
+
+	var actor AuditActor
+	actor.UserID, _ = ctx.Value("user_id").(int)
+	actor.ApiKeyID, _ = ctx.Value("api_key_id").(int)
+	actor.IP, _ = ctx.Value("client_ip").(string)
+	actor.ImpersonatorID, _ = ctx.Value("impersonator_id").(int)
+	return actor
+}
+
+// auditIgnoredFields change along with every update and would only add noise to the diffs
+var auditIgnoredFields = []string{"updated_at", "updated_by", "last_access"}
+
+// AuditEntry describes an audited action for AuditService.Record
+type AuditEntry struct {
+	Action     string // constant.AuditAction*
+	EntityType string // constant.AuditEntity*
+	EntityID   int    // 0 when there is no target entity
+
+	// ActorUserID replaces the user of the request, for actions like login
+	// that happen before the actor is authenticated
+	ActorUserID int
+
+	// States of the entity before and after the action, structs or maps. Their JSON
+	// form is compared and only the changed fields are stored, so secrets must be
+	// hidden with json:"-". Before is nil for creations, After for deletions.
+	Before interface{}
+	After  interface{}
+
+	Details map[string]interface{}
+}
+
+// AuditService records security-relevant and data-changing actions
+type AuditService interface {
+	// Record writes an entry with the actor of ctx. A failure is logged and does not
+	// fail the action, which already happened.
+	Record(ctx context.Context, entry AuditEntry)
+
+	GetAll(ctx context.Context, filter repository.AuditLogFilter, opts query.Options) ([]*entity.AuditLog, error)
+	GetCount(ctx context.Context, filter repository.AuditLogFilter) (int, error)
+}
+
+type auditService struct {
+	repo repository.AuditLogRepository
+}
+
+// NewAuditService creates a new audit service
+func NewAuditService(repo repository.AuditLogRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+func (s *auditService) Record(ctx context.Context, entry AuditEntry) {
+	actor := AuditActorFromContext(ctx)
+	if entry.ActorUserID != 0 {
+		actor.UserID = entry.ActorUserID
+	}
+
+	before, after, err := auditDiff(entry.Before, entry.After)
+	if err != nil {
+		logger.Error("Failed to record audit log %s: %v", entry.Action, err)
+		return
+	}
+
+	// Everything done with an impersonation token names the admin behind it
+	details := entry.Details
+	if actor.ImpersonatorID != 0 {
+		details = make(map[string]interface{}, len(entry.Details)+1)
+		for key, value := range entry.Details {
+			details[key] = value
+		}
+		details["impersonator_id"] = actor.ImpersonatorID
+	}
+
+	log := &entity.AuditLog{
+		ActorUserID: optionalID(actor.UserID),
+		ApiKeyID:    optionalID(actor.ApiKeyID),
+		IP:          actor.IP,
+		Action:      entry.Action,
+		EntityType:  entry.EntityType,
+		EntityID:    optionalID(entry.EntityID),
+		Before:      before,
+		After:       after,
+		Details:     details,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.repo.Create(ctx, log); err != nil {
+		logger.Error("Failed to record audit log %s: %v", entry.Action, err)
+	}
+}
+
+func (s *auditService) GetAll(ctx context.Context, filter repository.AuditLogFilter, opts query.Options) ([]*entity.AuditLog, error) {
+	return s.repo.GetAll(ctx, filter, opts)
+}
+
+func (s *auditService) GetCount(ctx context.Context, filter repository.AuditLogFilter) (int, error) {
+	return s.repo.GetCount(ctx, filter)
+}
+
+// auditDiff returns the fields of before and after whose values differ.
+// A missing side contributes no fields, so creations and deletions keep the whole state.
+func auditDiff(before, after interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	if beforeFields == nil || afterFields == nil {
+		return beforeFields, afterFields, nil
+	}
+
+	changedBefore := make(map[string]interface{})
+	changedAfter := make(map[string]interface{})
+	for key, value := range beforeFields {
+		if other, ok := afterFields[key]; !ok || !reflect.DeepEqual(value, other) {
+			changedBefore[key] = value
+		}
+	}
+	for key, value := range afterFields {
+		if other, ok := beforeFields[key]; !ok || !reflect.DeepEqual(value, other) {
+			changedAfter[key] = value
+		}
+	}
+	return changedBefore, changedAfter, nil
+}
+
+// auditFields converts a state to its JSON fields, without the ignored ones
+func auditFields(state interface{}) (map[string]interface{}, error) {
+	if state == nil || (reflect.ValueOf(state).Kind() == reflect.Ptr && reflect.ValueOf(state).IsNil()) {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, key := range auditIgnoredFields {
+		delete(fields, key)
+	}
+	return fields, nil
+}
+
+// optionalID turns the zero id into NULL
+func optionalID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
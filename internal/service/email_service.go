@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/pkg/i18n"
+	"go-rest-api-template/pkg/mailer"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// EmailConfig holds the links put into emails
+type EmailConfig struct {
+	// PasswordResetURL is the page of the client app that resets the password,
+	// "{token}" is replaced with the reset token (password_reset.url)
+	PasswordResetURL string
+
+	// EmailVerificationURL is the page of the client app that verifies an email
+	// address, "{token}" is replaced with the verification token (email_verification.url)
+	EmailVerificationURL string
+}
+
+// EmailService sends the localized emails of the user flows
+type EmailService interface {
+	// SendPasswordReset sends the reset token of a user, valid for expiry
+	SendPasswordReset(ctx context.Context, user *entity.User, token string, expiry time.Duration, lang string) error
+
+	// SendEmailVerification sends the link a new user confirms their email address with
+	SendEmailVerification(ctx context.Context, user *entity.User, token string, expiry time.Duration, lang string) error
+
+	// SendEmailChangeVerification sends the link that confirms a new address of the profile, to that address
+	SendEmailChangeVerification(ctx context.Context, user *entity.User, newEmail, token string, expiry time.Duration, lang string) error
+
+	// SendInvitation sends an imported user the password reset token they choose their password with
+	SendInvitation(ctx context.Context, user *entity.User, token string, expiry time.Duration, lang string) error
+}
+
+type emailService struct {
+	mailer      mailer.Mailer
+	i18nManager *i18n.Manager
+	config      EmailConfig
+}
+
+// NewEmailService creates a new email service
+func NewEmailService(mailer mailer.Mailer, i18nManager *i18n.Manager, config EmailConfig) EmailService {
+	return &emailService{
+		mailer:      mailer,
+		i18nManager: i18nManager,
+		config:      config,
+	}
+}
+
+func (s *emailService) SendPasswordReset(ctx context.Context, user *entity.User, token string, expiry time.Duration, lang string) error {
+	return s.send(ctx, user.Email, "mail.password_reset", lang, map[string]interface{}{
+		"Username":      user.Username,
+		"Token":         token,
+		"URL":           tokenURL(s.config.PasswordResetURL, token),
+		"ExpiryMinutes": int(expiry.Minutes()),
+	})
+}
+
+func (s *emailService) SendEmailVerification(ctx context.Context, user *entity.User, token string, expiry time.Duration, lang string) error {
+	return s.send(ctx, user.Email, "mail.email_verification", lang, map[string]interface{}{
+		"Username":    user.Username,
+		"Token":       token,
+		"URL":         tokenURL(s.config.EmailVerificationURL, token),
+		"ExpiryHours": int(expiry.Hours()),
+	})
+}
+
+func (s *emailService) SendEmailChangeVerification(ctx context.Context, user *entity.User, newEmail, token string, expiry time.Duration, lang string) error {
+	return s.send(ctx, newEmail, "mail.email_change", lang, map[string]interface{}{
+		"Username":    user.Username,
+		"Token":       token,
+		"URL":         tokenURL(s.config.EmailVerificationURL, token),
+		"ExpiryHours": int(expiry.Hours()),
+	})
+}
+
+func (s *emailService) SendInvitation(ctx context.Context, user *entity.User, token string, expiry time.Duration, lang string) error {
+	return s.send(ctx, user.Email, "mail.invitation", lang, map[string]interface{}{
+		"Username":    user.Username,
+		"Token":       token,
+		"URL":         tokenURL(s.config.PasswordResetURL, token),
+		"ExpiryHours": int(expiry.Hours()),
+	})
+}
+
+// send mails the translated <key>.subject and <key>.body messages to an address
+func (s *emailService) send(ctx context.Context, to, key, lang string, data map[string]interface{}) error {
+	return s.mailer.Send(ctx, &mailer.Message{
+		To:      to,
+		Subject: s.i18nManager.Translate(lang, key+".subject", data),
+		Body:    s.i18nManager.Translate(lang, key+".body", data),
+	})
+}
+
+// tokenURL fills the {token} placeholder of a configured link, empty when no link is configured
+func tokenURL(link, token string) string {
+	if link == "" {
+		return ""
+	}
+	return strings.ReplaceAll(link, "{token}", url.QueryEscape(token))
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTKeyConfig describes one key of the key set as configured in jwt.keys
+type JWTKeyConfig struct {
+	KeyID          string // kid header value
+	Algorithm      string // defaults to the configured signing method
+	PrivateKeyFile string // only required for the signing key
+	PublicKeyFile  string // derived from the private key when empty
+}
+
+// jwtKey is a loaded verification key, optionally able to sign
+type jwtKey struct {
+	id         string
+	method     jwt.SigningMethod
+	privateKey crypto.PrivateKey
+	publicKey  crypto.PublicKey
+}
+
+// JWTKeySet holds the signing key and every key tokens may still be verified with.
+// Keeping retired public keys in the set allows key rotation without invalidating live tokens.
+type JWTKeySet struct {
+	hmacSecret []byte // HS256 mode only
+	signingKey *jwtKey
+	keys       map[string]*jwtKey
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is the JSON Web Key Set served on /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewHMACKeySet creates a key set signing with HS256 and a shared secret
+func NewHMACKeySet(secret string) *JWTKeySet {
+	return &JWTKeySet{
+		hmacSecret: []byte(secret),
+		keys:       make(map[string]*jwtKey),
+	}
+}
+
+// LoadJWTKeySet loads PEM keys from disk for asymmetric signing (RS*, PS*, ES*, EdDSA)
+func LoadJWTKeySet(algorithm, signingKeyID string, configs []JWTKeyConfig) (*JWTKeySet, error) {
+	if signingKeyID == "" {
+		return nil, errors.New("jwt.signing_key_id is required for asymmetric signing")
+	}
+
+	keySet := &JWTKeySet{keys: make(map[string]*jwtKey)}
+	for _, cfg := range configs {
+		if cfg.Algorithm == "" {
+			cfg.Algorithm = algorithm
+		}
+
+		key, err := loadJWTKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", cfg.KeyID, err)
+		}
+		if _, exists := keySet.keys[key.id]; exists {
+			return nil, fmt.Errorf("duplicate jwt key id %q", key.id)
+		}
+		keySet.keys[key.id] = key
+	}
+
+	signingKey, ok := keySet.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q is not configured in jwt.keys", signingKeyID)
+	}
+	if signingKey.privateKey == nil {
+		return nil, fmt.Errorf("signing key %q has no private key", signingKeyID)
+	}
+	keySet.signingKey = signingKey
+
+	return keySet, nil
+}
+
+// Sign signs the claims with the active key and sets the kid header
+func (ks *JWTKeySet) Sign(claims jwt.Claims) (string, error) {
+	if ks.signingKey == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.hmacSecret)
+	}
+
+	token := jwt.NewWithClaims(ks.signingKey.method, claims)
+	token.Header["kid"] = ks.signingKey.id
+	return token.SignedString(ks.signingKey.privateKey)
+}
+
+// Keyfunc resolves the verification key for a token by its kid header
+func (ks *JWTKeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if ks.signingKey == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return ks.hmacSecret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	// The algorithm is pinned per key, never taken from the token alone
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+
+	return key.publicKey, nil
+}
+
+// JWKS returns the public verification keys. Shared HMAC secrets are never published.
+func (ks *JWTKeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		jwk, err := toJWK(key)
+		if err != nil {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
+
+func loadJWTKey(cfg JWTKeyConfig) (*jwtKey, error) {
+	if cfg.KeyID == "" {
+		return nil, errors.New("kid is required")
+	}
+
+	method := jwt.GetSigningMethod(cfg.Algorithm)
+	if method == nil {
+		return nil, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		return nil, errors.New("HMAC algorithms cannot be used with key files, use jwt.secret instead")
+	}
+
+	key := &jwtKey{id: cfg.KeyID, method: method}
+
+	if cfg.PrivateKeyFile != "" {
+		pemData, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := parsePrivateKeyPEM(method, pemData)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("private key cannot sign")
+		}
+		key.privateKey = privateKey
+		key.publicKey = signer.Public()
+	}
+
+	if cfg.PublicKeyFile != "" {
+		pemData, err := os.ReadFile(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		publicKey, err := parsePublicKeyPEM(method, pemData)
+		if err != nil {
+			return nil, err
+		}
+		key.publicKey = publicKey
+	}
+
+	if key.publicKey == nil {
+		return nil, errors.New("private_key_file or public_key_file is required")
+	}
+
+	return key, nil
+}
+
+func parsePrivateKeyPEM(method jwt.SigningMethod, pemData []byte) (crypto.PrivateKey, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		return jwt.ParseRSAPrivateKeyFromPEM(pemData)
+	case *jwt.SigningMethodECDSA:
+		return jwt.ParseECPrivateKeyFromPEM(pemData)
+	case *jwt.SigningMethodEd25519:
+		return jwt.ParseEdPrivateKeyFromPEM(pemData)
+	}
+	return nil, fmt.Errorf("unsupported algorithm %q", method.Alg())
+}
+
+func parsePublicKeyPEM(method jwt.SigningMethod, pemData []byte) (crypto.PublicKey, error) {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		return jwt.ParseRSAPublicKeyFromPEM(pemData)
+	case *jwt.SigningMethodECDSA:
+		return jwt.ParseECPublicKeyFromPEM(pemData)
+	case *jwt.SigningMethodEd25519:
+		return jwt.ParseEdPublicKeyFromPEM(pemData)
+	}
+	return nil, fmt.Errorf("unsupported algorithm %q", method.Alg())
+}
+
+func toJWK(key *jwtKey) (JWK, error) {
+	jwk := JWK{Use: "sig", Kid: key.id, Alg: key.method.Alg()}
+	encode := base64.RawURLEncoding.EncodeToString
+
+	switch pub := key.publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encode(pub.N.Bytes())
+		jwk.E = encode(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = encode(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = encode(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encode(pub)
+	default:
+		return jwk, fmt.Errorf("unsupported key type %T", pub)
+	}
+
+	return jwk, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -18,46 +19,94 @@ type PublicJWTClaims struct {
 
 // PrivateJWTClaims for private endpoints - contains API key and user information
 type PrivateJWTClaims struct {
-	ApiKeyID   int    `json:"api_key_id"`
-	ApiKeyName string `json:"api_key_name"`
-	UserID     int    `json:"user_id"`
-	Username   string `json:"username"`
-	Email      string `json:"email"`
+	ApiKeyID   int      `json:"api_key_id"`
+	ApiKeyName string   `json:"api_key_name"`
+	UserID     int      `json:"user_id"`
+	Username   string   `json:"username"`
+	Email      string   `json:"email"`
+	SessionID  string   `json:"sid,omitempty"`   // login session the token belongs to
+	Roles      []string `json:"roles,omitempty"` // roles of the user when the token was issued
+
+	// Act is set on tokens an admin obtained to act as the user (RFC 8693 actor claim)
+	Act *ImpersonationActor `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ImpersonationActor identifies the admin impersonating the user of a private token
+type ImpersonationActor struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+}
+
+// MFAPendingClaims for the short-lived token of a login that still needs a second factor.
+// It is only accepted by the MFA verify endpoint.
+type MFAPendingClaims struct {
+	ApiKeyID int `json:"api_key_id"`
+	UserID   int `json:"user_id"`
 	jwt.RegisteredClaims
 }
 
+// MFAPendingTokenExpiry is how long a user has to enter the code after the password
+const MFAPendingTokenExpiry = 5 * time.Minute
+
+// mfaPendingSubject marks MFA pending tokens, which no other endpoint accepts
+const mfaPendingSubject = "mfa-pending"
+
 // JWTService interface for JWT operations
 type JWTService interface {
 	GeneratePublicToken(apiKey *entity.ApiKey) (string, error)
-	GeneratePrivateToken(apiKey *entity.ApiKey, user *entity.User) (string, error)
+	GeneratePrivateToken(apiKey *entity.ApiKey, user *entity.User, sessionID string) (string, error)
+	// GenerateImpersonationToken issues a private token of user carrying impersonator in the act claim.
+	// It belongs to no login session and expires after expiry.
+	GenerateImpersonationToken(apiKey *entity.ApiKey, user *entity.User, impersonator *entity.User, expiry time.Duration) (string, error)
 	ValidatePublicToken(tokenString string) (*PublicJWTClaims, *entity.ApiKey, error)
 	ValidatePrivateToken(tokenString string) (*PrivateJWTClaims, *entity.ApiKey, *entity.User, error)
+
+	// Two-step login: the password step issues an MFA pending token, exchanged for a private token with a code
+	GenerateMFAPendingToken(apiKey *entity.ApiKey, user *entity.User) (string, error)
+	ValidateMFAPendingToken(tokenString string) (*MFAPendingClaims, error)
+	RevokeMFAPendingToken(ctx context.Context, claims *MFAPendingClaims) error // MFA pending tokens are single use
+
+	// JWKS returns the public keys downstream services can verify tokens with
+	JWKS() JWKS
+
+	// Revocation
+	RevokeToken(ctx context.Context, claims *PrivateJWTClaims) error
+	RevokeAllUserTokens(ctx context.Context, userID int) error
 }
 
 // jwtService implements JWTService
 type jwtService struct {
-	secretKey              string
+	keySet                 *JWTKeySet
 	publicTokenExpiration  time.Duration
 	privateTokenExpiration time.Duration
 	apiKeyService          ApiKeyService
+	revocationRepo         repository.TokenRevocationRepository
 }
 
 // NewJWTService creates a new JWT service instance
-func NewJWTService(secretKey string, publicExpHours, privateExpHours int, apiKeyService ApiKeyService) JWTService {
+func NewJWTService(keySet *JWTKeySet, publicExpHours, privateExpHours int, apiKeyService ApiKeyService, revocationRepo repository.TokenRevocationRepository) JWTService {
 	return &jwtService{
-		secretKey:              secretKey,
+		keySet:                 keySet,
 		publicTokenExpiration:  time.Duration(publicExpHours) * time.Hour,
 		privateTokenExpiration: time.Duration(privateExpHours) * time.Hour,
 		apiKeyService:          apiKeyService,
+		revocationRepo:         revocationRepo,
 	}
 }
 
 // GeneratePublicToken generates JWT token for public endpoints (API key only)
 func (j *jwtService) GeneratePublicToken(apiKey *entity.ApiKey) (string, error) {
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
 	claims := PublicJWTClaims{
 		ApiKeyID:   apiKey.ID,
 		ApiKeyName: apiKey.Name,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.publicTokenExpiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -66,19 +115,26 @@ func (j *jwtService) GeneratePublicToken(apiKey *entity.ApiKey) (string, error)
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+	return j.keySet.Sign(claims)
 }
 
 // GeneratePrivateToken generates JWT token for private endpoints (API key + user)
-func (j *jwtService) GeneratePrivateToken(apiKey *entity.ApiKey, user *entity.User) (string, error) {
+func (j *jwtService) GeneratePrivateToken(apiKey *entity.ApiKey, user *entity.User, sessionID string) (string, error) {
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
 	claims := PrivateJWTClaims{
 		ApiKeyID:   apiKey.ID,
 		ApiKeyName: apiKey.Name,
 		UserID:     user.ID,
 		Username:   user.Username,
 		Email:      user.Email,
+		SessionID:  sessionID,
+		Roles:      user.Roles,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.privateTokenExpiration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -87,18 +143,43 @@ func (j *jwtService) GeneratePrivateToken(apiKey *entity.ApiKey, user *entity.Us
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+	return j.keySet.Sign(claims)
+}
+
+// GenerateImpersonationToken generates a private token of user for an impersonating admin
+func (j *jwtService) GenerateImpersonationToken(apiKey *entity.ApiKey, user *entity.User, impersonator *entity.User, expiry time.Duration) (string, error) {
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	claims := PrivateJWTClaims{
+		ApiKeyID:   apiKey.ID,
+		ApiKeyName: apiKey.Name,
+		UserID:     user.ID,
+		Username:   user.Username,
+		Email:      user.Email,
+		Roles:      user.Roles,
+		Act: &ImpersonationActor{
+			UserID:   impersonator.ID,
+			Username: impersonator.Username,
+		},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "go-rest-api",
+			Subject:   "private-access",
+		},
+	}
+
+	return j.keySet.Sign(claims)
 }
 
 // ValidatePublicToken validates public JWT token and returns API key entity
 func (j *jwtService) ValidatePublicToken(tokenString string) (*PublicJWTClaims, *entity.ApiKey, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &PublicJWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(j.secretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &PublicJWTClaims{}, j.keySet.Keyfunc)
 
 	if err != nil {
 		return nil, nil, err
@@ -113,6 +194,11 @@ func (j *jwtService) ValidatePublicToken(tokenString string) (*PublicJWTClaims,
 		return nil, nil, errors.New("invalid token claims")
 	}
 
+	// MFA pending tokens carry the API key too, but must not open public endpoints
+	if claims.Subject == mfaPendingSubject {
+		return nil, nil, errors.New("token is not a public token")
+	}
+
 	// Get API key entity from service
 	ctx := context.Background()
 	apiKey, err := j.apiKeyService.GetApiKeyByID(ctx, claims.ApiKeyID)
@@ -125,12 +211,7 @@ func (j *jwtService) ValidatePublicToken(tokenString string) (*PublicJWTClaims,
 
 // ValidatePrivateToken validates private JWT token and returns API key + user entities
 func (j *jwtService) ValidatePrivateToken(tokenString string) (*PrivateJWTClaims, *entity.ApiKey, *entity.User, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &PrivateJWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(j.secretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &PrivateJWTClaims{}, j.keySet.Keyfunc)
 
 	if err != nil {
 		return nil, nil, nil, err
@@ -150,8 +231,21 @@ func (j *jwtService) ValidatePrivateToken(tokenString string) (*PrivateJWTClaims
 		return nil, nil, nil, errors.New("token is not a private token")
 	}
 
-	// Get API key entity from service
+	// Reject tokens revoked by logout
 	ctx := context.Background()
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	revoked, err := j.revocationRepo.IsRevoked(ctx, claims.ID, claims.UserID, issuedAt)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if revoked {
+		return nil, nil, nil, errors.New("token has been revoked")
+	}
+
+	// Get API key entity from service
 	apiKey, err := j.apiKeyService.GetApiKeyByID(ctx, claims.ApiKeyID)
 	if err != nil {
 		return nil, nil, nil, errors.New("api key not found")
@@ -162,7 +256,105 @@ func (j *jwtService) ValidatePrivateToken(tokenString string) (*PrivateJWTClaims
 		ID:       claims.UserID,
 		Username: claims.Username,
 		Email:    claims.Email,
+		Roles:    claims.Roles,
 	}
 
 	return claims, apiKey, user, nil
 }
+
+// GenerateMFAPendingToken generates the token of a login whose password was checked but whose second factor was not
+func (j *jwtService) GenerateMFAPendingToken(apiKey *entity.ApiKey, user *entity.User) (string, error) {
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	claims := MFAPendingClaims{
+		ApiKeyID: apiKey.ID,
+		UserID:   user.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(MFAPendingTokenExpiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			Issuer:    "go-rest-api",
+			Subject:   mfaPendingSubject,
+		},
+	}
+
+	return j.keySet.Sign(claims)
+}
+
+// ValidateMFAPendingToken validates an MFA pending token and returns its claims
+func (j *jwtService) ValidateMFAPendingToken(tokenString string) (*MFAPendingClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &MFAPendingClaims{}, j.keySet.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.New("token is not valid")
+	}
+
+	claims, ok := token.Claims.(*MFAPendingClaims)
+	if !ok || claims.Subject != mfaPendingSubject || claims.UserID == 0 {
+		return nil, errors.New("token is not an mfa pending token")
+	}
+
+	// Reject tokens already exchanged or dropped after too many wrong codes
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	revoked, err := j.revocationRepo.IsRevoked(context.Background(), claims.ID, claims.UserID, issuedAt)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, errors.New("token has been revoked")
+	}
+
+	return claims, nil
+}
+
+// RevokeMFAPendingToken revokes an MFA pending token until it expires
+func (j *jwtService) RevokeMFAPendingToken(ctx context.Context, claims *MFAPendingClaims) error {
+	if claims.ID == "" {
+		return errors.New("token has no jti claim")
+	}
+
+	expiresAt := time.Now().Add(MFAPendingTokenExpiry)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	return j.revocationRepo.RevokeToken(ctx, claims.ID, claims.UserID, expiresAt)
+}
+
+// RevokeToken revokes a single private token until it expires
+func (j *jwtService) RevokeToken(ctx context.Context, claims *PrivateJWTClaims) error {
+	if claims.ID == "" {
+		return errors.New("token has no jti claim")
+	}
+
+	expiresAt := time.Now().Add(j.privateTokenExpiration)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+
+	return j.revocationRepo.RevokeToken(ctx, claims.ID, claims.UserID, expiresAt)
+}
+
+// RevokeAllUserTokens revokes every private token issued to the user so far.
+// The iat claim only has second precision, so the cut-off is truncated to the
+// second: tokens issued from this second on, e.g. by a login right after the
+// revocation, stay valid.
+func (j *jwtService) RevokeAllUserTokens(ctx context.Context, userID int) error {
+	now := time.Now().Truncate(time.Second)
+	return j.revocationRepo.RevokeUserTokens(ctx, userID, now, now.Add(j.privateTokenExpiration))
+}
+
+// JWKS returns the public verification keys of the configured key set
+func (j *jwtService) JWKS() JWKS {
+	return j.keySet.JWKS()
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"time"
+)
+
+// Login protection errors, used by the auth handler to pick the response message
+var (
+	ErrAccountLocked        = errors.New("account is locked after too many failed logins")
+	ErrAccountBanned        = errors.New("account is banned")
+	ErrTooManyLoginAttempts = errors.New("too many failed logins from this address")
+)
+
+// LoginAttemptConfig holds the brute-force protection settings (login.*)
+type LoginAttemptConfig struct {
+	MaxAttempts     int           // failed logins before an account is locked
+	LockoutDuration time.Duration // how long a locked account stays locked
+	MaxIPAttempts   int           // failed logins per client IP within IPWindow, for any account
+	IPWindow        time.Duration
+	BaseDelay       time.Duration // delay after the first failed login, doubled with every further one
+	MaxDelay        time.Duration
+}
+
+// LoginAttemptService tracks failed logins per account and per client IP
+type LoginAttemptService interface {
+	// CheckIP returns ErrTooManyLoginAttempts while the client IP is blocked
+	CheckIP(ctx context.Context, ip string) error
+
+	// RecordFailure counts a failed login from ip, user is nil for unknown usernames.
+	// It returns how long to delay the response.
+	RecordFailure(ctx context.Context, user *entity.User, ip string) (time.Duration, error)
+
+	// RecordSuccess clears the failed login count of the user
+	RecordSuccess(ctx context.Context, user *entity.User) error
+
+	// Unlock lifts the lock of an account before it ends by itself
+	Unlock(ctx context.Context, userID int) error
+}
+
+type loginAttemptService struct {
+	userRepo repository.UserRepository
+	store    repository.RateLimitRepository
+	config   LoginAttemptConfig
+}
+
+// NewLoginAttemptService creates a new login attempt service. Per IP counts live in the rate limit store.
+func NewLoginAttemptService(userRepo repository.UserRepository, store repository.RateLimitRepository, config LoginAttemptConfig) LoginAttemptService {
+	if config.LockoutDuration <= 0 {
+		config.LockoutDuration = 15 * time.Minute
+	}
+	if config.IPWindow <= 0 {
+		config.IPWindow = 15 * time.Minute
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = 5 * time.Second
+	}
+
+	return &loginAttemptService{
+		userRepo: userRepo,
+		store:    store,
+		config:   config,
+	}
+}
+
+func (s *loginAttemptService) CheckIP(ctx context.Context, ip string) error {
+	if s.config.MaxIPAttempts <= 0 {
+		return nil
+	}
+
+	count, err := s.store.Count(ctx, ipFailureKey(ip))
+	if err != nil {
+		return err
+	}
+	if count >= s.config.MaxIPAttempts {
+		return ErrTooManyLoginAttempts
+	}
+	return nil
+}
+
+func (s *loginAttemptService) RecordFailure(ctx context.Context, user *entity.User, ip string) (time.Duration, error) {
+	ipFailures, err := s.store.Increment(ctx, ipFailureKey(ip), time.Now().Add(s.config.IPWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	// Unknown usernames are slowed down by the failures of the IP
+	if user == nil {
+		return s.delay(ipFailures), nil
+	}
+
+	// Counted in the store, parallel failures would otherwise overwrite each other
+	if err := s.userRepo.IncrementFailedLogins(ctx, user, s.config.MaxAttempts, s.config.LockoutDuration); err != nil {
+		return 0, err
+	}
+	return s.delay(user.FailedLoginAttempts), nil
+}
+
+func (s *loginAttemptService) RecordSuccess(ctx context.Context, user *entity.User) error {
+	if user.FailedLoginAttempts == 0 && user.LockedUntil == nil {
+		return nil
+	}
+
+	user.ResetFailedLogins()
+	return s.userRepo.UpdateLoginAttempts(ctx, user)
+}
+
+func (s *loginAttemptService) Unlock(ctx context.Context, userID int) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	user.ResetFailedLogins()
+	return s.userRepo.UpdateLoginAttempts(ctx, user)
+}
+
+// delay returns the progressive delay after the given number of failures
+func (s *loginAttemptService) delay(failures int) time.Duration {
+	if s.config.BaseDelay <= 0 || failures < 1 {
+		return 0
+	}
+
+	delay := s.config.BaseDelay
+	for i := 1; i < failures && delay < s.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, s.config.MaxDelay)
+}
+
+func ipFailureKey(ip string) string {
+	return "login_failures:" + ip
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"crypto/cipher"
+	"errors"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/pkg/totp"
+	"strings"
+	"time"
+)
+
+// RecoveryCodeCount is the number of one-time recovery codes issued when 2FA is enabled
+const RecoveryCodeCount = 10
+
+// Two-factor authentication errors
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("two-factor authentication was not enrolled")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidMFACode    = errors.New("invalid two-factor authentication code")
+)
+
+// MFAEnrollment is what an authenticator app needs to add an account
+type MFAEnrollment struct {
+	Secret string // base32 secret, for entering by hand
+	URI    string // otpauth:// URI, usually shown as a QR code
+}
+
+// MFAService manages TOTP two-factor authentication
+type MFAService interface {
+	// Enroll creates a new secret for the user. It is not used for login until Confirm.
+	Enroll(ctx context.Context, user *entity.User) (*MFAEnrollment, error)
+
+	// Confirm enables 2FA with a first valid code and returns the plain recovery codes,
+	// which are shown once
+	Confirm(ctx context.Context, userID int, code string) ([]string, error)
+
+	// Verify checks a TOTP code or an unused recovery code. Every code is accepted once.
+	Verify(ctx context.Context, userID int, code string) error
+
+	// IsEnabled checks if the user has to pass a second factor on login
+	IsEnabled(ctx context.Context, userID int) (bool, error)
+
+	// Disable turns 2FA off after checking a code, and drops the recovery codes
+	Disable(ctx context.Context, userID int, code string) error
+}
+
+type mfaService struct {
+	repo   repository.MFARepository
+	aead   cipher.AEAD
+	issuer string
+}
+
+// NewMFAService creates a new MFA service. TOTP secrets are encrypted with a key derived from secret,
+// issuer is the account name authenticator apps show (mfa.issuer).
+func NewMFAService(repo repository.MFARepository, secret, issuer string) (MFAService, error) {
+	if secret == "" {
+		return nil, errors.New("mfa secret is required")
+	}
+
+	aead, err := newAEAD(secret, "mfa-secret-encryption")
+	if err != nil {
+		return nil, err
+	}
+
+	return &mfaService{
+		repo:   repo,
+		aead:   aead,
+		issuer: issuer,
+	}, nil
+}
+
+func (s *mfaService) Enroll(ctx context.Context, user *entity.User) (*MFAEnrollment, error) {
+	existing, err := s.repo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.IsEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := sealString(s.aead, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enrolling again replaces an unconfirmed secret
+	now := time.Now()
+	if err := s.repo.Save(ctx, &entity.UserMFA{
+		UserID:    user.ID,
+		Secret:    encrypted,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.issuer, user.Email, secret),
+	}, nil
+}
+
+func (s *mfaService) Confirm(ctx context.Context, userID int, code string) ([]string, error) {
+	mfa, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if mfa.IsEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, err := s.checkTOTP(mfa, code)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		code, err := randomHex(5)
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code[:5] + "-" + code[5:]
+		hashes[i] = hashToken(normalizeRecoveryCode(codes[i]))
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	mfa.EnabledAt = &now
+	mfa.LastUsedStep = step
+	mfa.UpdatedAt = now
+	if err := s.repo.Save(ctx, mfa); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *mfaService) Verify(ctx context.Context, userID int, code string) error {
+	mfa, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if mfa == nil || !mfa.IsEnabled() {
+		return ErrMFANotEnabled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) != totp.Digits {
+		used, err := s.repo.UseRecoveryCode(ctx, userID, hashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidMFACode
+		}
+		return nil
+	}
+
+	step, err := s.checkTOTP(mfa, code)
+	if err != nil {
+		return err
+	}
+
+	// A code seen before, or one older than the last accepted code, could have been intercepted
+	accepted, err := s.repo.UpdateLastUsedStep(ctx, userID, step)
+	if err != nil {
+		return err
+	}
+	if !accepted {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *mfaService) IsEnabled(ctx context.Context, userID int) (bool, error) {
+	mfa, err := s.repo.GetByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return mfa != nil && mfa.IsEnabled(), nil
+}
+
+func (s *mfaService) Disable(ctx context.Context, userID int, code string) error {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, userID)
+}
+
+// checkTOTP decrypts the secret of an enrollment and returns the time step the code matched
+func (s *mfaService) checkTOTP(mfa *entity.UserMFA, code string) (int64, error) {
+	secret, err := openString(s.aead, mfa.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := totp.Validate(secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return 0, ErrInvalidMFACode
+	}
+	return step, nil
+}
+
+// normalizeRecoveryCode makes recovery codes match however the user typed the separator and case
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
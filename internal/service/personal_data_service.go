@@ -0,0 +1,318 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"time"
+)
+
+// personalDataFormatVersion is raised when the layout of the export changes incompatibly
+const personalDataFormatVersion = 1
+
+// PersonalDataContributor adds what one module stores about a user to the export.
+// Every module holding personal data registers one with the personal data service.
+type PersonalDataContributor interface {
+	// Section is the key of the data in the export, e.g. "sessions"
+	Section() string
+	// Export returns the data of the user in a form encoding/json can marshal, secrets left out
+	Export(ctx context.Context, userID int) (interface{}, error)
+}
+
+// PersonalDataEraser is implemented by contributors whose data outlives the erasure
+// of the user row and its cascade (sessions, tokens, roles, MFA, password history)
+type PersonalDataEraser interface {
+	Erase(ctx context.Context, userID int) error
+}
+
+// PersonalDataExport is the archive of everything stored about a user
+type PersonalDataExport struct {
+	FormatVersion int                    `json:"format_version"`
+	UserID        int                    `json:"user_id"`
+	GeneratedAt   time.Time              `json:"generated_at"`
+	Data          map[string]interface{} `json:"data"` // by contributor section
+}
+
+// PersonalDataService answers data subject requests: access (export) and erasure
+type PersonalDataService interface {
+	Export(ctx context.Context, userID int) (*PersonalDataExport, error)
+
+	// Erase anonymizes the user row, which stays for the audit log and other records pointing
+	// at it, then runs the erasers of the contributors. Soft-deleted users can be erased too,
+	// users already anonymized give ErrUserNotFound. An eraser failing leaves a partial erase.
+	Erase(ctx context.Context, userID int) error
+	// EraseAccount is the erasure requested by users themselves, confirmed with their password
+	EraseAccount(ctx context.Context, userID int, currentPassword string) error
+}
+
+type personalDataService struct {
+	userRepo     repository.UserRepository
+	auditService AuditService
+	contributors []PersonalDataContributor
+}
+
+// NewPersonalDataService creates a new personal data service exporting the sections of contributors, in order
+func NewPersonalDataService(userRepo repository.UserRepository, auditService AuditService, contributors ...PersonalDataContributor) PersonalDataService {
+	return &personalDataService{
+		userRepo:     userRepo,
+		auditService: auditService,
+		contributors: contributors,
+	}
+}
+
+func (s *personalDataService) Export(ctx context.Context, userID int) (*PersonalDataExport, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	export := &PersonalDataExport{
+		FormatVersion: personalDataFormatVersion,
+		UserID:        userID,
+		GeneratedAt:   time.Now().UTC(),
+		Data:          make(map[string]interface{}, len(s.contributors)),
+	}
+	for _, contributor := range s.contributors {
+		data, err := contributor.Export(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", contributor.Section(), err)
+		}
+		export.Data[contributor.Section()] = data
+	}
+	return export, nil
+}
+
+func (s *personalDataService) Erase(ctx context.Context, userID int) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	live := user != nil
+	if !live {
+		if user, err = s.userRepo.GetDeletedByID(ctx, userID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if user == nil {
+			return ErrUserNotFound
+		}
+	}
+
+	// Anonymizing goes through the purge of deleted users, which cascades to the other tables
+	actor := AuditActorFromContext(ctx)
+	if live {
+		deletedBy := actor.UserID
+		if deletedBy == 0 {
+			deletedBy = constant.DefaultUpdatedBy
+		}
+		if err := s.userRepo.Delete(ctx, userID, deletedBy); err != nil {
+			return err
+		}
+	}
+	if err := s.userRepo.Purge(ctx, userID, true); err != nil {
+		// Anonymized by a concurrent erasure or purge since it was loaded
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	// The erasers run after the purge, so a failing purge leaves all data in place.
+	// They do not share its transaction: when one fails, the user is anonymized but
+	// the data of the remaining erasers is left over.
+	for _, contributor := range s.contributors {
+		if eraser, ok := contributor.(PersonalDataEraser); ok {
+			if err := eraser.Erase(ctx, userID); err != nil {
+				return fmt.Errorf("failed to erase %s: %w", contributor.Section(), err)
+			}
+		}
+	}
+
+	// The IP of users erasing themselves is personal data as well
+	if actor.UserID == userID {
+		actor.IP = ""
+		ctx = WithAuditActor(ctx, actor)
+	}
+	s.auditService.Record(ctx, AuditEntry{
+		Action:     constant.AuditActionUserErased,
+		EntityType: constant.AuditEntityUser,
+		EntityID:   userID,
+	})
+	return nil
+}
+
+func (s *personalDataService) EraseAccount(ctx context.Context, userID int, currentPassword string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if !user.CheckPassword(currentPassword) {
+		return ErrInvalidCurrentPassword
+	}
+
+	return s.Erase(ctx, userID)
+}
+
+// profileDataContributor exports the user row
+type profileDataContributor struct {
+	userRepo repository.UserRepository
+}
+
+// NewProfileDataContributor exports the account and profile of the user
+func NewProfileDataContributor(userRepo repository.UserRepository) PersonalDataContributor {
+	return &profileDataContributor{userRepo: userRepo}
+}
+
+func (c *profileDataContributor) Section() string { return "profile" }
+
+func (c *profileDataContributor) Export(ctx context.Context, userID int) (interface{}, error) {
+	return c.userRepo.GetByID(ctx, userID)
+}
+
+// roleDataContributor exports the role assignments
+type roleDataContributor struct {
+	roleRepo repository.RoleRepository
+}
+
+// NewRoleDataContributor exports the names of the roles assigned to the user
+func NewRoleDataContributor(roleRepo repository.RoleRepository) PersonalDataContributor {
+	return &roleDataContributor{roleRepo: roleRepo}
+}
+
+func (c *roleDataContributor) Section() string { return "roles" }
+
+func (c *roleDataContributor) Export(ctx context.Context, userID int) (interface{}, error) {
+	return c.roleRepo.GetUserRoles(ctx, userID)
+}
+
+// mfaDataContributor exports the two-factor enrollment, without the secret
+type mfaDataContributor struct {
+	mfaRepo repository.MFARepository
+}
+
+// NewMFADataContributor exports whether and since when the user has two-factor authentication
+func NewMFADataContributor(mfaRepo repository.MFARepository) PersonalDataContributor {
+	return &mfaDataContributor{mfaRepo: mfaRepo}
+}
+
+func (c *mfaDataContributor) Section() string { return "two_factor" }
+
+func (c *mfaDataContributor) Export(ctx context.Context, userID int) (interface{}, error) {
+	mfa, err := c.mfaRepo.GetByUserID(ctx, userID)
+	if err != nil || mfa == nil {
+		return nil, err
+	}
+	return mfa, nil
+}
+
+// sessionDataContributor exports the login sessions
+type sessionDataContributor struct {
+	sessionRepo repository.SessionRepository
+}
+
+// NewSessionDataContributor exports every session of the user, with devices and IP addresses
+func NewSessionDataContributor(sessionRepo repository.SessionRepository) PersonalDataContributor {
+	return &sessionDataContributor{sessionRepo: sessionRepo}
+}
+
+func (c *sessionDataContributor) Section() string { return "sessions" }
+
+func (c *sessionDataContributor) Export(ctx context.Context, userID int) (interface{}, error) {
+	return c.sessionRepo.GetByUserID(ctx, userID)
+}
+
+// ApiKeyActivity summarizes the use of one API key (client application) by a user
+type ApiKeyActivity struct {
+	ApiKeyID    int        `json:"api_key_id"`
+	ApiKeyName  string     `json:"api_key_name"`
+	Sessions    int        `json:"sessions"`
+	FirstSeenAt time.Time  `json:"first_seen_at"`
+	LastSeenAt  *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// apiKeyActivityContributor exports which API keys the user signed in with
+type apiKeyActivityContributor struct {
+	sessionRepo repository.SessionRepository
+	apiKeyRepo  repository.ApiKeyRepository
+}
+
+// NewApiKeyActivityContributor exports the client applications (API keys) the user signed in
+// with, from the sessions. Actions through them are in the audit log section.
+func NewApiKeyActivityContributor(sessionRepo repository.SessionRepository, apiKeyRepo repository.ApiKeyRepository) PersonalDataContributor {
+	return &apiKeyActivityContributor{sessionRepo: sessionRepo, apiKeyRepo: apiKeyRepo}
+}
+
+func (c *apiKeyActivityContributor) Section() string { return "api_key_activity" }
+
+func (c *apiKeyActivityContributor) Export(ctx context.Context, userID int) (interface{}, error) {
+	sessions, err := c.sessionRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	activities := []*ApiKeyActivity{}
+	byKey := make(map[int]*ApiKeyActivity)
+	for _, session := range sessions {
+		activity, ok := byKey[session.ApiKeyID]
+		if !ok {
+			activity = &ApiKeyActivity{ApiKeyID: session.ApiKeyID, FirstSeenAt: session.CreatedAt}
+			if apiKey, err := c.apiKeyRepo.GetByID(ctx, session.ApiKeyID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			} else if apiKey != nil {
+				activity.ApiKeyName = apiKey.Name
+			}
+			byKey[session.ApiKeyID] = activity
+			activities = append(activities, activity)
+		}
+
+		activity.Sessions++
+		if session.CreatedAt.Before(activity.FirstSeenAt) {
+			activity.FirstSeenAt = session.CreatedAt
+		}
+		lastSeen := session.CreatedAt
+		if session.LastSeenAt != nil {
+			lastSeen = *session.LastSeenAt
+		}
+		if activity.LastSeenAt == nil || lastSeen.After(*activity.LastSeenAt) {
+			activity.LastSeenAt = &lastSeen
+		}
+	}
+	return activities, nil
+}
+
+// auditDataContributor exports and erases the audit log entries of the user
+type auditDataContributor struct {
+	auditRepo repository.AuditLogRepository
+}
+
+// NewAuditDataContributor exports the audit log entries of actions by or on the user. On erasure
+// the entries stay, without the IP addresses and the recorded states of the user.
+func NewAuditDataContributor(auditRepo repository.AuditLogRepository) PersonalDataContributor {
+	return &auditDataContributor{auditRepo: auditRepo}
+}
+
+func (c *auditDataContributor) Section() string { return "audit_log" }
+
+func (c *auditDataContributor) Export(ctx context.Context, userID int) (interface{}, error) {
+	logs, err := c.auditRepo.GetByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if logs == nil {
+		logs = []*entity.AuditLog{}
+	}
+	return logs, nil
+}
+
+func (c *auditDataContributor) Erase(ctx context.Context, userID int) error {
+	return c.auditRepo.EraseUserData(ctx, userID)
+}
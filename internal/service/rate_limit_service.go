@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"strconv"
+	"time"
+)
+
+// RateLimitConfig holds the limits used when an API key has none of its own
+type RateLimitConfig struct {
+	DefaultPerSecond int // requests per second per API key
+	DefaultBurst     int
+	IPPerMinute      int // requests per minute per client IP on login, register and password reset
+	IPBurst          int
+
+	// IPActions overrides the per client IP limit of single actions, e.g. resend_verification
+	IPActions map[string]IPLimit
+}
+
+// IPLimit is the per client IP limit of an action
+type IPLimit struct {
+	PerHour int
+	Burst   int
+}
+
+// RateLimitDecision tells the middleware whether to serve a request and what to report in the headers
+type RateLimitDecision struct {
+	Allowed       bool
+	QuotaExceeded bool // the daily quota, not the request rate, was exhausted
+	Limit         int
+	Remaining     int
+	ResetAfter    time.Duration
+	RetryAfter    time.Duration
+
+	// Daily quota, only reported for keys with a quota
+	QuotaLimit     int
+	QuotaRemaining int
+}
+
+// RateLimitService applies request rate limits and daily quotas
+type RateLimitService interface {
+	// AllowApiKey counts a request of the API key against its rate limit and daily quota
+	AllowApiKey(ctx context.Context, apiKey *entity.ApiKey) (*RateLimitDecision, error)
+	// AllowIP counts a request of the client IP for an action such as login
+	AllowIP(ctx context.Context, action, ip string) (*RateLimitDecision, error)
+}
+
+type rateLimitService struct {
+	store  repository.RateLimitRepository
+	config RateLimitConfig
+}
+
+// NewRateLimitService creates a new rate limit service
+func NewRateLimitService(store repository.RateLimitRepository, config RateLimitConfig) RateLimitService {
+	// A bucket without refill would block forever
+	if config.DefaultPerSecond <= 0 {
+		config.DefaultPerSecond = 10
+	}
+	if config.IPPerMinute <= 0 {
+		config.IPPerMinute = 10
+	}
+	if config.IPBurst <= 0 {
+		config.IPBurst = 5
+	}
+
+	return &rateLimitService{
+		store:  store,
+		config: config,
+	}
+}
+
+func (s *rateLimitService) AllowApiKey(ctx context.Context, apiKey *entity.ApiKey) (*RateLimitDecision, error) {
+	rate := apiKey.RateLimitPerSecond
+	if rate <= 0 {
+		rate = s.config.DefaultPerSecond
+	}
+	burst := apiKey.RateLimitBurst
+	if burst <= 0 {
+		burst = max(s.config.DefaultBurst, rate)
+	}
+
+	keyID := strconv.Itoa(apiKey.ID)
+	decision, err := s.take(ctx, "api_key:"+keyID, float64(rate), burst)
+	if err != nil || !decision.Allowed || apiKey.DailyQuota <= 0 {
+		return decision, err
+	}
+
+	// Rejected requests are not counted against the quota
+	now := time.Now().UTC()
+	windowEnd := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	count, err := s.store.Increment(ctx, "quota:"+keyID+":"+now.Format("20060102"), windowEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	decision.QuotaLimit = apiKey.DailyQuota
+	decision.QuotaRemaining = max(apiKey.DailyQuota-count, 0)
+	if count > apiKey.DailyQuota {
+		decision.Allowed = false
+		decision.QuotaExceeded = true
+		decision.RetryAfter = windowEnd.Sub(now)
+	}
+
+	return decision, nil
+}
+
+func (s *rateLimitService) AllowIP(ctx context.Context, action, ip string) (*RateLimitDecision, error) {
+	if limit, ok := s.config.IPActions[action]; ok && limit.PerHour > 0 {
+		return s.take(ctx, "ip:"+action+":"+ip, float64(limit.PerHour)/3600, max(limit.Burst, 1))
+	}
+	return s.take(ctx, "ip:"+action+":"+ip, float64(s.config.IPPerMinute)/60, s.config.IPBurst)
+}
+
+func (s *rateLimitService) take(ctx context.Context, key string, rate float64, burst int) (*RateLimitDecision, error) {
+	status, err := s.store.Take(ctx, key, rate, burst)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimitDecision{
+		Allowed:    status.Allowed,
+		Limit:      burst,
+		Remaining:  status.Remaining,
+		ResetAfter: status.ResetAfter,
+		RetryAfter: status.RetryAfter,
+	}, nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"time"
+)
+
+// Refresh token errors, used by handlers to pick the response message
+var (
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired = errors.New("refresh token has expired")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+)
+
+// RefreshTokenService handles issuing and rotating opaque refresh tokens
+type RefreshTokenService interface {
+	// Issue creates a new refresh token family for the user, API key and login session
+	Issue(ctx context.Context, userID, apiKeyID int, sessionToken string) (string, error)
+
+	// Rotate exchanges a refresh token for a new one in the same family.
+	// Presenting an already rotated token revokes the whole family.
+	Rotate(ctx context.Context, refreshToken string, apiKeyID int) (*entity.RefreshToken, string, error)
+
+	// Revoke revokes the family the given refresh token belongs to
+	Revoke(ctx context.Context, refreshToken string, userID int) error
+
+	// RevokeAllForUser revokes every refresh token issued to the user
+	RevokeAllForUser(ctx context.Context, userID int) error
+
+	// Expiry returns the configured refresh token lifetime
+	Expiry() time.Duration
+}
+
+type refreshTokenService struct {
+	refreshTokenRepo repository.RefreshTokenRepository
+	expiration       time.Duration
+}
+
+// NewRefreshTokenService creates a new refresh token service
+func NewRefreshTokenService(refreshTokenRepo repository.RefreshTokenRepository, expHours int) RefreshTokenService {
+	return &refreshTokenService{
+		refreshTokenRepo: refreshTokenRepo,
+		expiration:       time.Duration(expHours) * time.Hour,
+	}
+}
+
+func (s *refreshTokenService) Issue(ctx context.Context, userID, apiKeyID int, sessionToken string) (string, error) {
+	familyID, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	plain, _, err := s.create(ctx, userID, apiKeyID, familyID, sessionToken)
+	return plain, err
+}
+
+func (s *refreshTokenService) Rotate(ctx context.Context, refreshToken string, apiKeyID int) (*entity.RefreshToken, string, error) {
+	current, err := s.refreshTokenRepo.GetByTokenHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return nil, "", err
+	}
+	if current == nil {
+		return nil, "", ErrRefreshTokenInvalid
+	}
+
+	// A spent or revoked token being presented again means it has leaked:
+	// kill the whole family so neither party can keep using it
+	if current.IsRotated() || current.IsRevoked() {
+		if err := s.refreshTokenRepo.RevokeFamily(ctx, current.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	if current.IsExpired() {
+		return nil, "", ErrRefreshTokenExpired
+	}
+
+	// Refresh tokens are bound to the API key they were issued for
+	if current.ApiKeyID != apiKeyID {
+		return nil, "", ErrRefreshTokenInvalid
+	}
+
+	plain, next, err := s.create(ctx, current.UserID, current.ApiKeyID, current.FamilyID, current.SessionToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rotated, err := s.refreshTokenRepo.MarkRotated(ctx, current.ID, next.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !rotated {
+		// Lost the race against another request using the same token
+		if err := s.refreshTokenRepo.RevokeFamily(ctx, current.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	return next, plain, nil
+}
+
+func (s *refreshTokenService) Revoke(ctx context.Context, refreshToken string, userID int) error {
+	token, err := s.refreshTokenRepo.GetByTokenHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	// Unknown tokens and tokens of other users are silently ignored
+	if token == nil || token.UserID != userID {
+		return nil
+	}
+
+	return s.refreshTokenRepo.RevokeFamily(ctx, token.FamilyID)
+}
+
+func (s *refreshTokenService) RevokeAllForUser(ctx context.Context, userID int) error {
+	return s.refreshTokenRepo.RevokeByUserID(ctx, userID)
+}
+
+func (s *refreshTokenService) Expiry() time.Duration {
+	return s.expiration
+}
+
+// create stores a new token in the given family and returns its plain value
+func (s *refreshTokenService) create(ctx context.Context, userID, apiKeyID int, familyID, sessionToken string) (string, *entity.RefreshToken, error) {
+	plain, err := randomHex(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	token := &entity.RefreshToken{
+		UserID:       userID,
+		ApiKeyID:     apiKeyID,
+		FamilyID:     familyID,
+		SessionToken: sessionToken,
+		TokenHash:    hashToken(plain),
+		ExpiresAt:    now.Add(s.expiration),
+		CreatedAt:    now,
+	}
+
+	if err := s.refreshTokenRepo.Create(ctx, token); err != nil {
+		return "", nil, err
+	}
+
+	return plain, token, nil
+}
+
+// hashToken returns the hex encoded SHA-256 of a refresh or password reset token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	bytes := make([]byte, n)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request signature errors, used by the H2H middleware to pick the response message
+var (
+	ErrSignatureRequired = errors.New("request signature is required")
+	ErrSignatureInvalid  = errors.New("request signature is invalid")
+	ErrSignatureExpired  = errors.New("request timestamp is outside the allowed window")
+	ErrNonceReused       = errors.New("request nonce has already been used")
+)
+
+// SignedRequest holds the parts of an HTTP request covered by the H2H signature
+type SignedRequest struct {
+	Method    string
+	Path      string // path including query string, as sent by the client
+	Timestamp string // unix seconds
+	Nonce     string
+	Signature string // hex encoded HMAC-SHA256
+	Body      []byte
+}
+
+// RequestSignatureService verifies HMAC signed requests of host-to-host API keys
+type RequestSignatureService interface {
+	Verify(ctx context.Context, apiKey *entity.ApiKey, req SignedRequest) error
+}
+
+type requestSignatureService struct {
+	nonceRepo repository.RequestNonceRepository
+	maxSkew   time.Duration
+}
+
+// NewRequestSignatureService creates a new request signature service
+func NewRequestSignatureService(nonceRepo repository.RequestNonceRepository, maxSkewSeconds int) RequestSignatureService {
+	return &requestSignatureService{
+		nonceRepo: nonceRepo,
+		maxSkew:   time.Duration(maxSkewSeconds) * time.Second,
+	}
+}
+
+func (s *requestSignatureService) Verify(ctx context.Context, apiKey *entity.ApiKey, req SignedRequest) error {
+	if req.Timestamp == "" || req.Nonce == "" || req.Signature == "" {
+		return ErrSignatureRequired
+	}
+	if len(req.Nonce) > 64 {
+		return ErrSignatureInvalid
+	}
+
+	unix, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	timestamp := time.Unix(unix, 0)
+	if skew := time.Since(timestamp); skew > s.maxSkew || skew < -s.maxSkew {
+		return ErrSignatureExpired
+	}
+
+	signature, err := hex.DecodeString(strings.ToLower(req.Signature))
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	expected := computeRequestSignature(apiKey.AuthKey, req)
+	if !hmac.Equal(signature, expected) {
+		return ErrSignatureInvalid
+	}
+
+	// Only remember nonces of correctly signed requests, so they cannot be burned by others.
+	// A nonce must stay remembered as long as its timestamp is accepted.
+	fresh, err := s.nonceRepo.Use(ctx, apiKey.ID, req.Nonce, timestamp.Add(s.maxSkew))
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrNonceReused
+	}
+
+	return nil
+}
+
+// SignRequest returns the hex encoded signature a client sends in X-Signature.
+// The signed string is METHOD, path, timestamp, nonce and the hex SHA-256 of the body, joined by newlines.
+func SignRequest(authKey, method, path, timestamp, nonce string, body []byte) string {
+	return hex.EncodeToString(computeRequestSignature(authKey, SignedRequest{
+		Method:    method,
+		Path:      path,
+		Timestamp: timestamp,
+		Nonce:     nonce,
+		Body:      body,
+	}))
+}
+
+func computeRequestSignature(authKey string, req SignedRequest) []byte {
+	bodyHash := sha256.Sum256(req.Body)
+	payload := strings.Join([]string{
+		strings.ToUpper(req.Method),
+		req.Path,
+		req.Timestamp,
+		req.Nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	mac := hmac.New(sha256.New, []byte(authKey))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
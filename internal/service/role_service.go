@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+)
+
+// Role errors, used by the handlers to pick the status code
+var (
+	ErrRoleNotFound = errors.New("role not found")
+)
+
+// RoleService manages roles of users and the permissions they grant
+type RoleService interface {
+	GetRoles(ctx context.Context) ([]*entity.Role, error)
+
+	// GetUserRoles returns the role names to put in the private token of the user
+	GetUserRoles(ctx context.Context, userID int) ([]string, error)
+	AssignRole(ctx context.Context, userID int, roleName string, createdBy *int) error
+	RevokeRole(ctx context.Context, userID int, roleName string) error
+
+	// AssignDefaultRole gives a new user the role through which users manage their own account
+	AssignDefaultRole(ctx context.Context, userID int) error
+
+	// GetPermissions resolves the permissions granted by the roles of a token
+	GetPermissions(ctx context.Context, roles []string) (entity.PermissionSet, error)
+}
+
+type roleService struct {
+	roleRepo repository.RoleRepository
+	userRepo repository.UserRepository
+}
+
+// NewRoleService creates a new role service
+func NewRoleService(roleRepo repository.RoleRepository, userRepo repository.UserRepository) RoleService {
+	return &roleService{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+	}
+}
+
+func (s *roleService) GetRoles(ctx context.Context) ([]*entity.Role, error) {
+	return s.roleRepo.GetAll(ctx)
+}
+
+func (s *roleService) GetUserRoles(ctx context.Context, userID int) ([]string, error) {
+	return s.roleRepo.GetUserRoles(ctx, userID)
+}
+
+func (s *roleService) AssignRole(ctx context.Context, userID int, roleName string, createdBy *int) error {
+	role, err := s.resolve(ctx, userID, roleName)
+	if err != nil {
+		return err
+	}
+	return s.roleRepo.AssignRole(ctx, userID, role.ID, createdBy)
+}
+
+func (s *roleService) RevokeRole(ctx context.Context, userID int, roleName string) error {
+	role, err := s.resolve(ctx, userID, roleName)
+	if err != nil {
+		return err
+	}
+	return s.roleRepo.RevokeRole(ctx, userID, role.ID)
+}
+
+func (s *roleService) AssignDefaultRole(ctx context.Context, userID int) error {
+	return s.AssignRole(ctx, userID, constant.RoleUser, nil)
+}
+
+func (s *roleService) GetPermissions(ctx context.Context, roles []string) (entity.PermissionSet, error) {
+	permissions, err := s.roleRepo.GetPermissions(ctx, roles)
+	if err != nil {
+		return nil, err
+	}
+	return entity.NewPermissionSet(permissions), nil
+}
+
+// resolve checks that both the user and the role exist
+func (s *roleService) resolve(ctx context.Context, userID int, roleName string) (*entity.Role, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	role, err := s.roleRepo.GetByName(ctx, roleName)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	return role, nil
+}
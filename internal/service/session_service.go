@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"time"
+)
+
+// Session errors, used by handlers and middleware to pick the response message
+var (
+	ErrSessionNotFound   = errors.New("session not found")
+	ErrSessionTerminated = errors.New("session has been terminated")
+)
+
+// SessionService tracks login sessions ("active devices") of users
+type SessionService interface {
+	// Start records a new login session
+	Start(ctx context.Context, userID, apiKeyID int, ipAddress, userAgent string) (*entity.Session, error)
+
+	// Validate returns the session for a token's sid claim if it is still active
+	Validate(ctx context.Context, sessionToken string) (*entity.Session, error)
+
+	// Touch updates the last seen time of the session
+	Touch(ctx context.Context, sessionID int) error
+
+	// Extend pushes the session expiry forward, e.g. after a token refresh
+	Extend(ctx context.Context, sessionID int) error
+
+	GetActiveSessions(ctx context.Context, userID int) ([]*entity.Session, error)
+
+	// Terminate ends one session of the user and revokes its refresh tokens
+	Terminate(ctx context.Context, userID, sessionID int) error
+
+	// TerminateAll ends every session of the user
+	TerminateAll(ctx context.Context, userID int) error
+}
+
+type sessionService struct {
+	sessionRepo      repository.SessionRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	expiration       time.Duration
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(sessionRepo repository.SessionRepository, refreshTokenRepo repository.RefreshTokenRepository, expHours int) SessionService {
+	return &sessionService{
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		expiration:       time.Duration(expHours) * time.Hour,
+	}
+}
+
+func (s *sessionService) Start(ctx context.Context, userID, apiKeyID int, ipAddress, userAgent string) (*entity.Session, error) {
+	sessionToken, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep user agent within column size
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+
+	now := time.Now()
+	session := &entity.Session{
+		UserID:       userID,
+		ApiKeyID:     apiKeyID,
+		SessionToken: sessionToken,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
+		ExpiresAt:    now.Add(s.expiration),
+		LastSeenAt:   &now,
+		CreatedAt:    now,
+	}
+
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *sessionService) Validate(ctx context.Context, sessionToken string) (*entity.Session, error) {
+	session, err := s.sessionRepo.GetBySessionToken(ctx, sessionToken)
+	if err != nil {
+		return nil, err
+	}
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+	if !session.IsActive() {
+		return nil, ErrSessionTerminated
+	}
+
+	return session, nil
+}
+
+func (s *sessionService) Touch(ctx context.Context, sessionID int) error {
+	return s.sessionRepo.Touch(ctx, sessionID)
+}
+
+func (s *sessionService) Extend(ctx context.Context, sessionID int) error {
+	return s.sessionRepo.Extend(ctx, sessionID, time.Now().Add(s.expiration))
+}
+
+func (s *sessionService) GetActiveSessions(ctx context.Context, userID int) ([]*entity.Session, error) {
+	return s.sessionRepo.GetActiveByUserID(ctx, userID)
+}
+
+func (s *sessionService) Terminate(ctx context.Context, userID, sessionID int) error {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	// Do not reveal sessions of other users
+	if session == nil || session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	if err := s.sessionRepo.Terminate(ctx, session.ID); err != nil {
+		return err
+	}
+
+	return s.refreshTokenRepo.RevokeBySessionToken(ctx, session.SessionToken)
+}
+
+func (s *sessionService) TerminateAll(ctx context.Context, userID int) error {
+	if err := s.sessionRepo.TerminateByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.refreshTokenRepo.RevokeByUserID(ctx, userID)
+}
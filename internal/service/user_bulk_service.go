@@ -0,0 +1,704 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/pkg/logger"
+	"go-rest-api-template/pkg/query"
+	"go-rest-api-template/pkg/validator"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Bulk user errors, used by the handlers to pick the status code
+var (
+	ErrUnsupportedFileFormat = errors.New("unsupported file format, use csv or ndjson")
+	ErrInvalidImportFile     = errors.New("invalid import file")
+	ErrUserJobNotFound       = errors.New("user job not found")
+	ErrUserJobNotReady       = errors.New("user job has no file to download")
+)
+
+const (
+	// userExportPageSize is how many users Export reads at a time
+	userExportPageSize = 500
+	// userJobTimeout bounds a background import or export
+	userJobTimeout = time.Hour
+	// maxImportLineSize is the longest line of an NDJSON file
+	maxImportLineSize = 1 << 20
+)
+
+// userExportColumns are the columns of exported CSV files. Imports skip id and created_at,
+// so an export can be imported into another installation.
+var userExportColumns = []string{"id", "username", "email", "full_name", "phone", "locale", "timezone", "status", "created_at"}
+
+// UserBulkConfig holds the settings of bulk imports and exports (user_bulk.*)
+type UserBulkConfig struct {
+	InvitationExpiry time.Duration // how long invited users can choose their password
+	JobRetention     time.Duration // finished jobs and their files are dropped after it
+}
+
+// UserImportRow is one user of an import file. CSV files name the columns in their header row,
+// NDJSON files hold one object per line.
+type UserImportRow struct {
+	Username     string `json:"username" validate:"required,min=3,max=50,alphanum"`
+	Email        string `json:"email" validate:"required,email,max=100"`
+	FullName     string `json:"full_name" validate:"max=100"`
+	Phone        string `json:"phone" validate:"phone"`
+	Locale       string `json:"locale" validate:"max=35,locale"`
+	Timezone     string `json:"timezone" validate:"max=64,iana_timezone"`
+	Status       string `json:"status" validate:"omitempty,oneof=active pending inactive suspended banned"`
+	PasswordHash string `json:"password_hash" validate:"password_hash"`
+}
+
+// UserExportRow is one user of an export file
+type UserExportRow struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	FullName  string    `json:"full_name"`
+	Phone     string    `json:"phone"`
+	Locale    string    `json:"locale"`
+	Timezone  string    `json:"timezone"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserImportOptions tell how to import a file
+type UserImportOptions struct {
+	Format    string // constant.UserFileFormat*
+	Passwords string // constant.UserImportPasswords*
+	DryRun    bool   // validate every row, create nobody
+	Lang      string // of the invitation emails
+}
+
+// UserImportRowError lists why a row was not imported
+type UserImportRowError struct {
+	Line     int                         `json:"line"` // in the file, the CSV header is line 1
+	Username string                      `json:"username,omitempty"`
+	Errors   []validator.ValidationError `json:"errors"`
+}
+
+// UserImportReport sums up an import. Created users whose invitation could not be sent
+// are listed in Errors as well, a password reset mails them a new token.
+type UserImportReport struct {
+	DryRun  bool                 `json:"dry_run"`
+	Rows    int                  `json:"rows"`
+	Created int                  `json:"created"` // in a dry run, the users that would be created
+	Failed  int                  `json:"failed"`
+	Errors  []UserImportRowError `json:"errors"`
+}
+
+// UserJob is an import or export running in the background
+type UserJob struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`   // constant.UserJobType*
+	Status     string            `json:"status"` // constant.UserJobStatus*
+	Format     string            `json:"format"`
+	CreatedBy  int               `json:"created_by,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	Report     *UserImportReport `json:"report,omitempty"`   // of imports
+	Exported   *int              `json:"exported,omitempty"` // users in the file of exports
+	Error      string            `json:"error,omitempty"`
+
+	file string // the uploaded file of imports, the exported file of exports
+}
+
+// UserBulkService imports and exports users in CSV or NDJSON files
+type UserBulkService interface {
+	// Import creates the users of a file row by row. Rows failing validation are reported and
+	// skipped, the other rows are imported. An error is returned for unreadable files only.
+	Import(ctx context.Context, r io.Reader, opts UserImportOptions) (*UserImportReport, error)
+	// Export writes the users matching the filter ordered by id and returns how many it wrote
+	Export(ctx context.Context, w io.Writer, format string, filter repository.UserFilter) (int, error)
+
+	// StartImport and StartExport run Import and Export in the background. Jobs are kept in
+	// memory, with the exported file, until JobRetention after they finished.
+	StartImport(ctx context.Context, r io.Reader, opts UserImportOptions) (*UserJob, error)
+	StartExport(ctx context.Context, format string, filter repository.UserFilter) (*UserJob, error)
+	GetJob(id string) (*UserJob, error)
+	// ExportFile returns the exported file of a completed export job
+	ExportFile(id string) (string, *UserJob, error)
+}
+
+type userBulkService struct {
+	userRepo     repository.UserRepository
+	roleService  RoleService
+	emailService EmailService
+	auditService AuditService
+	config       UserBulkConfig
+
+	mu   sync.Mutex
+	jobs map[string]*UserJob
+}
+
+// NewUserBulkService creates a new bulk user service
+func NewUserBulkService(userRepo repository.UserRepository, roleService RoleService, emailService EmailService, auditService AuditService, config UserBulkConfig) UserBulkService {
+	return &userBulkService{
+		userRepo:     userRepo,
+		roleService:  roleService,
+		emailService: emailService,
+		auditService: auditService,
+		config:       config,
+		jobs:         make(map[string]*UserJob),
+	}
+}
+
+// UserFileFormat returns the format of a file by its extension, empty when it is unknown
+func UserFileFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return constant.UserFileFormatCSV
+	case ".ndjson", ".jsonl":
+		return constant.UserFileFormatNDJSON
+	}
+	return ""
+}
+
+func (s *userBulkService) Import(ctx context.Context, r io.Reader, opts UserImportOptions) (*UserImportReport, error) {
+	if opts.Passwords != constant.UserImportPasswordsHashed && opts.Passwords != constant.UserImportPasswordsInvite {
+		return nil, fmt.Errorf("unknown passwords option %q", opts.Passwords)
+	}
+	rows, err := newUserRowReader(r, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UserImportReport{DryRun: opts.DryRun, Errors: []UserImportRowError{}}
+	// Earlier lines of the file taking a username or email address, lower-cased
+	usernames := make(map[string]int)
+	emails := make(map[string]int)
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		line, row, err := rows.next()
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+		var rowErr *importRowError
+		if errors.As(err, &rowErr) {
+			report.Rows++
+			report.fail(line, "", []validator.ValidationError{rowErr.validationError()})
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Rows++
+
+		errs, err := s.validateRow(ctx, row, opts, usernames, emails)
+		if err != nil {
+			return report, err
+		}
+		if len(errs) > 0 {
+			report.fail(line, row.Username, errs)
+			continue
+		}
+		usernames[strings.ToLower(row.Username)] = line
+		emails[strings.ToLower(row.Email)] = line
+
+		if opts.DryRun {
+			report.Created++
+			continue
+		}
+
+		user, err := s.createUser(ctx, row, opts)
+		if err != nil {
+			return report, err
+		}
+		report.Created++
+
+		if opts.Passwords == constant.UserImportPasswordsInvite {
+			if err := s.invite(ctx, user, opts.Lang); err != nil {
+				logger.Error("Failed to invite imported user %d: %v", user.ID, err)
+				report.Errors = append(report.Errors, UserImportRowError{Line: line, Username: row.Username, Errors: []validator.ValidationError{{
+					Field:   "email",
+					Tag:     "invitation",
+					Message: "user created, but the invitation email could not be sent",
+				}}})
+			}
+		}
+	}
+}
+
+// validateRow checks a row with the validator tags of UserImportRow and looks for users
+// with the same username or email address, in the database and earlier in the file
+func (s *userBulkService) validateRow(ctx context.Context, row *UserImportRow, opts UserImportOptions, usernames, emails map[string]int) ([]validator.ValidationError, error) {
+	row.trim()
+
+	var errs []validator.ValidationError
+	if err := validator.ValidateStruct(row); err != nil {
+		errs = validator.FormatValidationErrors(err)
+	}
+	if opts.Passwords == constant.UserImportPasswordsHashed && row.PasswordHash == "" {
+		errs = append(errs, validator.ValidationError{Field: "password_hash", Tag: "required", Message: "password_hash is required"})
+	}
+
+	for _, e := range errs {
+		if e.Field == "username" || e.Field == "email" {
+			return errs, nil
+		}
+	}
+
+	if line, ok := usernames[strings.ToLower(row.Username)]; ok {
+		errs = append(errs, duplicateError("username", line))
+	} else if existing, err := s.userRepo.GetByUsername(ctx, row.Username); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	} else if existing != nil {
+		errs = append(errs, validator.ValidationError{Field: "username", Tag: "unique", Message: "username already exists"})
+	}
+
+	if line, ok := emails[strings.ToLower(row.Email)]; ok {
+		errs = append(errs, duplicateError("email", line))
+	} else if existing, err := s.userRepo.GetByEmail(ctx, row.Email); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	} else if existing != nil {
+		errs = append(errs, validator.ValidationError{Field: "email", Tag: "unique", Message: "email already exists"})
+	}
+	return errs, nil
+}
+
+// createUser stores the user of a valid row
+func (s *userBulkService) createUser(ctx context.Context, row *UserImportRow, opts UserImportOptions) (*entity.User, error) {
+	user := &entity.User{
+		Username: row.Username,
+		Email:    row.Email,
+		FullName: row.FullName,
+		Phone:    row.Phone,
+		Locale:   row.Locale,
+		Timezone: row.Timezone,
+		Status:   row.Status,
+	}
+	if user.Status == "" {
+		user.Status = constant.UserStatusActive
+	}
+	// Invited users have no password until they used the invitation
+	if opts.Passwords == constant.UserImportPasswordsHashed {
+		user.PasswordHash = row.PasswordHash
+	}
+	if actor := AuditActorFromContext(ctx); actor.UserID != 0 {
+		user.SetCreatedBy(actor.UserID)
+	}
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// Imported users get the default role, like users that registered
+	if err := s.roleService.AssignDefaultRole(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, AuditEntry{
+		Action:     constant.AuditActionUserCreated,
+		EntityType: constant.AuditEntityUser,
+		EntityID:   user.ID,
+		After:      user,
+		Details: map[string]interface{}{
+			"source": "import",
+		},
+	})
+	return user, nil
+}
+
+// invite mails an imported user a password reset token, valid for InvitationExpiry
+func (s *userBulkService) invite(ctx context.Context, user *entity.User, lang string) error {
+	token, err := randomHex(32)
+	if err != nil {
+		return err
+	}
+	user.SetPasswordResetToken(hashToken(token), time.Now().Add(s.config.InvitationExpiry))
+	if err := s.userRepo.UpdatePasswordResetToken(ctx, user); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, emailTimeout)
+	defer cancel()
+	return s.emailService.SendInvitation(ctx, user, token, s.config.InvitationExpiry, lang)
+}
+
+func (s *userBulkService) Export(ctx context.Context, w io.Writer, format string, filter repository.UserFilter) (int, error) {
+	rows, err := newUserRowWriter(w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	opts := query.Options{Limit: userExportPageSize, Sort: query.Sort{Field: "id"}}
+	for {
+		users, err := s.userRepo.GetAll(ctx, filter, opts)
+		if err != nil {
+			return exported, err
+		}
+
+		for _, user := range users {
+			if err := rows.write(&UserExportRow{
+				ID:        user.ID,
+				Username:  user.Username,
+				Email:     user.Email,
+				FullName:  user.FullName,
+				Phone:     user.Phone,
+				Locale:    user.Locale,
+				Timezone:  user.Timezone,
+				Status:    user.Status,
+				CreatedAt: user.CreatedAt,
+			}); err != nil {
+				return exported, err
+			}
+			exported++
+		}
+
+		if len(users) < opts.Limit {
+			return exported, rows.flush()
+		}
+		last := users[len(users)-1]
+		opts.After = query.NewCursor(opts.Sort, last.ID, last.ID)
+	}
+}
+
+func (s *userBulkService) StartImport(ctx context.Context, r io.Reader, opts UserImportOptions) (*UserJob, error) {
+	if opts.Format != constant.UserFileFormatCSV && opts.Format != constant.UserFileFormatNDJSON {
+		return nil, ErrUnsupportedFileFormat
+	}
+
+	// The upload is gone once the request returned
+	file, err := os.CreateTemp("", "user-import-*."+opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if _, err := io.Copy(file, r); err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	job, err := s.newJob(ctx, constant.UserJobTypeImport, opts.Format, file.Name())
+	if err != nil {
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	go s.run(AuditActorFromContext(ctx), job, func(ctx context.Context) error {
+		defer os.Remove(job.file)
+
+		f, err := os.Open(job.file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		report, err := s.Import(ctx, f, opts)
+		s.mu.Lock()
+		job.Report = report
+		s.mu.Unlock()
+		return err
+	})
+	return s.GetJob(job.ID)
+}
+
+func (s *userBulkService) StartExport(ctx context.Context, format string, filter repository.UserFilter) (*UserJob, error) {
+	if format != constant.UserFileFormatCSV && format != constant.UserFileFormatNDJSON {
+		return nil, ErrUnsupportedFileFormat
+	}
+
+	file, err := os.CreateTemp("", "user-export-*."+format)
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.newJob(ctx, constant.UserJobTypeExport, format, file.Name())
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+
+	go s.run(AuditActorFromContext(ctx), job, func(ctx context.Context) error {
+		defer file.Close()
+
+		exported, err := s.Export(ctx, file, format, filter)
+		s.mu.Lock()
+		job.Exported = &exported
+		s.mu.Unlock()
+		return err
+	})
+	return s.GetJob(job.ID)
+}
+
+func (s *userBulkService) GetJob(id string) (*UserJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrUserJobNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (s *userBulkService) ExportFile(id string) (string, *UserJob, error) {
+	job, err := s.GetJob(id)
+	if err != nil {
+		return "", nil, err
+	}
+	if job.Type != constant.UserJobTypeExport || job.Status != constant.UserJobStatusCompleted {
+		return "", job, ErrUserJobNotReady
+	}
+	return job.file, job, nil
+}
+
+// newJob registers a running job of the actor of ctx, dropping the expired jobs
+func (s *userBulkService) newJob(ctx context.Context, jobType, format, file string) (*UserJob, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	job := &UserJob{
+		ID:        id,
+		Type:      jobType,
+		Status:    constant.UserJobStatusRunning,
+		Format:    format,
+		CreatedBy: AuditActorFromContext(ctx).UserID,
+		CreatedAt: time.Now(),
+		file:      file,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, expired := range s.jobs {
+		if expired.FinishedAt != nil && time.Since(*expired.FinishedAt) > s.config.JobRetention {
+			if expired.Type == constant.UserJobTypeExport {
+				os.Remove(expired.file)
+			}
+			delete(s.jobs, id)
+		}
+	}
+	s.jobs[job.ID] = job
+	return job, nil
+}
+
+// run does the work of a job in the background, as the actor of the request that started it.
+// The actor is read before, the request context is recycled once the handler returned.
+func (s *userBulkService) run(actor AuditActor, job *UserJob, work func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(WithAuditActor(context.Background(), actor), userJobTimeout)
+	defer cancel()
+
+	err := work(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = constant.UserJobStatusCompleted
+	if err != nil {
+		logger.Error("User %s job %s failed: %v", job.Type, job.ID, err)
+		job.Status = constant.UserJobStatusFailed
+		job.Error = err.Error()
+	}
+}
+
+// fail records a row that was not imported
+func (r *UserImportReport) fail(line int, username string, errs []validator.ValidationError) {
+	r.Failed++
+	r.Errors = append(r.Errors, UserImportRowError{Line: line, Username: username, Errors: errs})
+}
+
+// trim removes the spaces spreadsheets tend to leave around values
+func (r *UserImportRow) trim() {
+	for _, field := range []*string{&r.Username, &r.Email, &r.FullName, &r.Phone, &r.Locale, &r.Timezone, &r.Status, &r.PasswordHash} {
+		*field = strings.TrimSpace(*field)
+	}
+}
+
+// duplicateError reports a username or email address taken by an earlier line of the file
+func duplicateError(field string, line int) validator.ValidationError {
+	return validator.ValidationError{
+		Field:   field,
+		Tag:     "unique",
+		Message: fmt.Sprintf("%s is already used on line %d", field, line),
+	}
+}
+
+// importRowError is a row that could not be parsed, the import goes on with the next one
+type importRowError struct {
+	reason string
+}
+
+func (e *importRowError) Error() string { return e.reason }
+
+func (e *importRowError) validationError() validator.ValidationError {
+	return validator.ValidationError{Field: "row", Tag: "format", Message: e.reason}
+}
+
+// userRowReader reads the rows of an import file one at a time
+type userRowReader interface {
+	// next returns the line and the next row, io.EOF after the last one
+	// and an *importRowError for a row that could not be parsed
+	next() (int, *UserImportRow, error)
+}
+
+func newUserRowReader(r io.Reader, format string) (userRowReader, error) {
+	switch format {
+	case constant.UserFileFormatCSV:
+		return newCSVUserRowReader(r)
+	case constant.UserFileFormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxImportLineSize)
+		return &ndjsonUserRowReader{scanner: scanner}, nil
+	}
+	return nil, ErrUnsupportedFileFormat
+}
+
+// csvUserRowReader reads CSV files with a header row naming the columns
+type csvUserRowReader struct {
+	reader  *csv.Reader
+	columns map[string]int // column name to index
+}
+
+func newCSVUserRowReader(r io.Reader) (*csvUserRowReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidImportFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheets save UTF-8 files with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, required := range []string{"username", "email"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: the header has no %s column", ErrInvalidImportFile, required)
+		}
+	}
+	return &csvUserRowReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvUserRowReader) next() (int, *UserImportRow, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return parseErr.StartLine, nil, &importRowError{reason: parseErr.Err.Error()}
+		}
+		return 0, nil, err
+	}
+	line, _ := r.reader.FieldPos(0)
+
+	value := func(column string) string {
+		if i, ok := r.columns[column]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+	return line, &UserImportRow{
+		Username:     value("username"),
+		Email:        value("email"),
+		FullName:     value("full_name"),
+		Phone:        value("phone"),
+		Locale:       value("locale"),
+		Timezone:     value("timezone"),
+		Status:       value("status"),
+		PasswordHash: value("password_hash"),
+	}, nil
+}
+
+// ndjsonUserRowReader reads one JSON object per line, blank lines are skipped
+type ndjsonUserRowReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonUserRowReader) next() (int, *UserImportRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := strings.TrimSpace(r.scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		var row UserImportRow
+		if err := json.Unmarshal([]byte(data), &row); err != nil {
+			return r.line, nil, &importRowError{reason: "the line is not a valid user object"}
+		}
+		return r.line, &row, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return 0, nil, fmt.Errorf("%w: line %d: %v", ErrInvalidImportFile, r.line+1, err)
+	}
+	return 0, nil, io.EOF
+}
+
+// userRowWriter writes the rows of an export file
+type userRowWriter interface {
+	write(row *UserExportRow) error
+	flush() error
+}
+
+func newUserRowWriter(w io.Writer, format string) (userRowWriter, error) {
+	switch format {
+	case constant.UserFileFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(userExportColumns); err != nil {
+			return nil, err
+		}
+		return &csvUserRowWriter{writer: writer}, nil
+	case constant.UserFileFormatNDJSON:
+		buffered := bufio.NewWriter(w)
+		return &ndjsonUserRowWriter{writer: buffered, encoder: json.NewEncoder(buffered)}, nil
+	}
+	return nil, ErrUnsupportedFileFormat
+}
+
+// csvUserRowWriter writes the columns of userExportColumns
+type csvUserRowWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvUserRowWriter) write(row *UserExportRow) error {
+	return w.writer.Write([]string{
+		fmt.Sprint(row.ID), row.Username, row.Email, row.FullName, row.Phone,
+		row.Locale, row.Timezone, row.Status, row.CreatedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (w *csvUserRowWriter) flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// ndjsonUserRowWriter writes one JSON object per line
+type ndjsonUserRowWriter struct {
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (w *ndjsonUserRowWriter) write(row *UserExportRow) error {
+	return w.encoder.Encode(row)
+}
+
+func (w *ndjsonUserRowWriter) flush() error {
+	return w.writer.Flush()
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/repository"
+	"time"
+)
+
+// userPurgeBatchSize is how many users PurgeDeleted looks up at a time
+const userPurgeBatchSize = 100
+
+// UserRetentionConfig holds how long soft-deleted users are kept (user_retention.*)
+type UserRetentionConfig struct {
+	PurgeAfter time.Duration // zero keeps deleted users until they are restored
+	Mode       string        // constant.UserPurgeMode*
+}
+
+// UserRetentionService purges users that stayed deleted longer than the retention period
+type UserRetentionService interface {
+	// PurgeDeleted anonymizes or deletes, depending on the mode, the users deleted more
+	// than PurgeAfter ago, with their sessions and tokens. It returns how many were purged.
+	PurgeDeleted(ctx context.Context) (int, error)
+}
+
+type userRetentionService struct {
+	userRepo     repository.UserRepository
+	auditService AuditService
+	config       UserRetentionConfig
+}
+
+// NewUserRetentionService creates a new user retention service
+func NewUserRetentionService(userRepo repository.UserRepository, auditService AuditService, config UserRetentionConfig) UserRetentionService {
+	if config.Mode == "" {
+		config.Mode = constant.UserPurgeModeAnonymize
+	}
+
+	return &userRetentionService{
+		userRepo:     userRepo,
+		auditService: auditService,
+		config:       config,
+	}
+}
+
+func (s *userRetentionService) PurgeDeleted(ctx context.Context) (int, error) {
+	if s.config.PurgeAfter <= 0 {
+		return 0, nil
+	}
+
+	deletedBefore := time.Now().Add(-s.config.PurgeAfter)
+	anonymize := s.config.Mode == constant.UserPurgeModeAnonymize
+	purged := 0
+	for {
+		ids, err := s.userRepo.GetPurgeable(ctx, deletedBefore, userPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, id := range ids {
+			// Restored since it was looked up
+			if err := s.userRepo.Purge(ctx, id, anonymize); errors.Is(err, sql.ErrNoRows) {
+				continue
+			} else if err != nil {
+				return purged, err
+			}
+
+			purged++
+			s.auditService.Record(ctx, AuditEntry{
+				Action:     constant.AuditActionUserPurged,
+				EntityType: constant.AuditEntityUser,
+				EntityID:   id,
+				Details: map[string]interface{}{
+					"mode": s.config.Mode,
+				},
+			})
+		}
+
+		if len(ids) < userPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
@@ -4,26 +4,78 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"go-rest-api-template/internal/constant"
 	"go-rest-api-template/internal/domain/entity"
 	"go-rest-api-template/internal/domain/repository"
 	"go-rest-api-template/internal/domain/usecase"
+	"go-rest-api-template/pkg/logger"
+	"go-rest-api-template/pkg/password"
+	"go-rest-api-template/pkg/query"
+	"strings"
+	"time"
 )
 
+// User management errors, used by the handlers to pick the status code
+var (
+	ErrUserNotFound             = errors.New("user not found")
+	ErrUsernameExists           = errors.New("username already exists")
+	ErrEmailExists              = errors.New("email already exists")
+	ErrInvalidCurrentPassword   = errors.New("invalid current password")
+	ErrInvalidVerificationToken = errors.New("invalid verification token")
+	ErrInvalidResetToken        = errors.New("invalid or expired password reset token")
+	ErrEmailNotVerified         = errors.New("email address is not verified")
+)
+
+// errInvalidCredentials is returned for unknown usernames and wrong passwords alike
+var errInvalidCredentials = errors.New("invalid credentials")
+
+// emailTimeout bounds sending an email, which happens after the request returned
+const emailTimeout = 30 * time.Second
+
+// UserServiceConfig holds the settings of the account flows
+type UserServiceConfig struct {
+	ResetTokenExpiry time.Duration // password_reset.token_expiry_minutes
+
+	// New users start as pending until they open the verification link (auth.require_email_verification)
+	RequireEmailVerification bool
+	VerificationSecret       string        // signs verification tokens
+	VerificationTokenExpiry  time.Duration // email_verification.token_expiry_hours
+}
+
 type userService struct {
-	userRepo   repository.UserRepository
-	jwtService JWTService
+	userRepo            repository.UserRepository
+	jwtService          JWTService
+	refreshTokenService RefreshTokenService
+	sessionService      SessionService
+	roleService         RoleService
+	emailService        EmailService
+	loginAttempts       LoginAttemptService
+	auditService        AuditService
+	config              UserServiceConfig
 }
 
 // NewUserService creates a new user service
-func NewUserService(userRepo repository.UserRepository, jwtService JWTService) usecase.UserUsecase {
+func NewUserService(userRepo repository.UserRepository, jwtService JWTService, refreshTokenService RefreshTokenService, sessionService SessionService, roleService RoleService, emailService EmailService, loginAttempts LoginAttemptService, auditService AuditService, config UserServiceConfig) usecase.UserUsecase {
 	return &userService{
-		userRepo:   userRepo,
-		jwtService: jwtService,
+		userRepo:            userRepo,
+		jwtService:          jwtService,
+		refreshTokenService: refreshTokenService,
+		sessionService:      sessionService,
+		roleService:         roleService,
+		emailService:        emailService,
+		loginAttempts:       loginAttempts,
+		auditService:        auditService,
+		config:              config,
 	}
 }
 
-// Login authenticates a user and returns user data with JWT token
-func (s *userService) Login(ctx context.Context, username, password string) (*entity.User, string, error) {
+// Login authenticates a user and returns user data with JWT token.
+// Failed logins are counted per account and per client IP.
+func (s *userService) Login(ctx context.Context, username, password, ip string) (*entity.User, string, error) {
+	if err := s.loginAttempts.CheckIP(ctx, ip); err != nil {
+		return nil, "", err
+	}
+
 	// Get user by username or email
 	var user *entity.User
 	var err error
@@ -33,25 +85,60 @@ func (s *userService) Login(ctx context.Context, username, password string) (*en
 	if err != nil || user == nil {
 		// If not found, try by email
 		user, err = s.userRepo.GetByEmail(ctx, username)
-		if err != nil {
-			return nil, "", errors.New("invalid credentials")
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, "", err
 		}
 	}
 
 	if user == nil {
-		return nil, "", errors.New("invalid credentials")
+		s.auditLoginFailed(ctx, nil, username, "unknown_user")
+		return nil, "", s.loginFailed(ctx, nil, ip)
+	}
+
+	// A locked account does not even get its password checked until the lock ends
+	if user.IsLocked() {
+		s.auditLoginFailed(ctx, user, username, "account_locked")
+		return nil, "", ErrAccountLocked
+	}
+
+	// Verify password
+	if !user.CheckPassword(password) {
+		s.auditLoginFailed(ctx, user, username, "invalid_password")
+		return nil, "", s.loginFailed(ctx, user, ip)
+	}
+
+	// Hashes made with an older algorithm or cost are upgraded while the plain password is at hand
+	if user.PasswordNeedsRehash() {
+		s.rehashPassword(ctx, user, password)
+	}
+
+	// Only reported after the password matched, so they do not reveal registered addresses
+	if user.IsBanned() {
+		s.auditLoginFailed(ctx, user, username, "account_banned")
+		return nil, "", ErrAccountBanned
+	}
+	if user.IsPending() {
+		s.auditLoginFailed(ctx, user, username, "email_not_verified")
+		return nil, "", ErrEmailNotVerified
 	}
 
 	// Check if user is active
 	if !user.IsActive() {
+		s.auditLoginFailed(ctx, user, username, "account_inactive")
 		return nil, "", errors.New("account is not active")
 	}
 
-	// Verify password
-	if !user.CheckPassword(password) {
-		return nil, "", errors.New("invalid credentials")
+	if err := s.loginAttempts.RecordSuccess(ctx, user); err != nil {
+		return nil, "", err
 	}
 
+	s.auditService.Record(ctx, AuditEntry{
+		Action:      constant.AuditActionLoginSucceeded,
+		EntityType:  constant.AuditEntityUser,
+		EntityID:    user.ID,
+		ActorUserID: user.ID,
+	})
+
 	// Note: For login, we don't generate JWT token here anymore
 	// The token generation should be handled by the login handler
 	// which will have access to the API key information
@@ -60,10 +147,37 @@ func (s *userService) Login(ctx context.Context, username, password string) (*en
 	return user, "", nil
 }
 
-// RefreshToken is no longer available in the new JWT system
-// Token refresh should be handled at the handler level with API key validation
-func (s *userService) RefreshToken(ctx context.Context, tokenString string) (string, error) {
-	return "", errors.New("refresh token not supported in new JWT system")
+// RefreshToken rotates the refresh token and returns the user it belongs to.
+// The private token itself is generated by the handler, as in Login.
+func (s *userService) RefreshToken(ctx context.Context, refreshToken string, apiKeyID int) (*entity.User, *entity.RefreshToken, string, error) {
+	token, newRefreshToken, err := s.refreshTokenService.Rotate(ctx, refreshToken, apiKeyID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, token.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, "", err
+	}
+	if user == nil || !user.IsActive() {
+		// The account is gone or disabled, so the session must not continue
+		_ = s.sessionService.TerminateAll(ctx, token.UserID)
+		return nil, nil, "", errors.New("account is not active")
+	}
+
+	// Tokens issued before session tracking have no session to keep alive
+	if token.SessionToken != "" {
+		session, err := s.sessionService.Validate(ctx, token.SessionToken)
+		if err != nil {
+			_ = s.refreshTokenService.Revoke(ctx, newRefreshToken, user.ID)
+			return nil, nil, "", err
+		}
+		if err := s.sessionService.Extend(ctx, session.ID); err != nil {
+			return nil, nil, "", err
+		}
+	}
+
+	return user, token, newRefreshToken, nil
 }
 
 func (s *userService) CreateUser(ctx context.Context, user *entity.User) error {
@@ -78,7 +192,7 @@ func (s *userService) CreateUser(ctx context.Context, user *entity.User) error {
 		return err
 	}
 	if existingUser != nil {
-		return errors.New("username already exists")
+		return ErrUsernameExists
 	}
 
 	// Check if email already exists
@@ -87,15 +201,107 @@ func (s *userService) CreateUser(ctx context.Context, user *entity.User) error {
 		return err
 	}
 	if existingUser != nil {
-		return errors.New("email already exists")
+		return ErrEmailExists
+	}
+
+	// Users created by an admin remember who created them
+	if user.CreatedBy == nil {
+		if actor := AuditActorFromContext(ctx); actor.UserID != 0 {
+			user.SetCreatedBy(actor.UserID)
+		}
 	}
 
 	// Create user
-	return s.userRepo.Create(ctx, user)
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return err
+	}
+
+	// Every user manages their own account through the default role
+	if err := s.roleService.AssignDefaultRole(ctx, user.ID); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, AuditEntry{
+		Action:     constant.AuditActionUserCreated,
+		EntityType: constant.AuditEntityUser,
+		EntityID:   user.ID,
+		After:      user,
+	})
+	return nil
+}
+
+func (s *userService) Register(ctx context.Context, user *entity.User, lang string) error {
+	user.Status = constant.UserStatusActive
+	if s.config.RequireEmailVerification {
+		user.Status = constant.UserStatusPending
+	}
+
+	if err := s.CreateUser(ctx, user); err != nil {
+		return err
+	}
+
+	if user.IsPending() {
+		s.sendVerificationEmail(user, lang)
+	}
+	return nil
+}
+
+func (s *userService) VerifyEmail(ctx context.Context, token string) error {
+	userID, expiresAt, ok := parseVerificationToken(token)
+	if !ok || time.Now().After(expiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidVerificationToken
+	}
+
+	// Tokens sent to a new address of the profile confirm the email change
+	if user.PendingEmail != nil && checkVerificationToken(s.config.VerificationSecret, token, *user.PendingEmail) {
+		return s.confirmEmailChange(ctx, user)
+	}
+	if !checkVerificationToken(s.config.VerificationSecret, token, user.Email) {
+		return ErrInvalidVerificationToken
+	}
+
+	// Opening the link again after the account was activated is fine
+	if !user.IsPending() {
+		return nil
+	}
+
+	user.Status = constant.UserStatusActive
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, AuditEntry{
+		Action:      constant.AuditActionEmailVerified,
+		EntityType:  constant.AuditEntityUser,
+		EntityID:    user.ID,
+		ActorUserID: user.ID,
+	})
+	return nil
+}
+
+func (s *userService) ResendVerification(ctx context.Context, email, lang string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	// Unknown and already verified addresses get the same answer
+	if user != nil && user.IsPending() {
+		s.sendVerificationEmail(user, lang)
+	}
+	return nil
 }
 
 func (s *userService) GetUserByID(ctx context.Context, id int) (*entity.User, error) {
-	return s.userRepo.GetByID(ctx, id)
+	return s.getUser(ctx, id)
 }
 
 func (s *userService) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
@@ -106,23 +312,20 @@ func (s *userService) GetUserByEmail(ctx context.Context, email string) (*entity
 	return s.userRepo.GetByEmail(ctx, email)
 }
 
-func (s *userService) GetAllUsers(ctx context.Context, limit, offset int) ([]*entity.User, error) {
-	return s.userRepo.GetAll(ctx, limit, offset)
+func (s *userService) GetAllUsers(ctx context.Context, filter repository.UserFilter, opts query.Options) ([]*entity.User, error) {
+	return s.userRepo.GetAll(ctx, filter, opts)
 }
 
-func (s *userService) GetUserCount(ctx context.Context) (int, error) {
-	return s.userRepo.GetCount(ctx)
+func (s *userService) GetUserCount(ctx context.Context, filter repository.UserFilter) (int, error) {
+	return s.userRepo.GetCount(ctx, filter)
 }
 
-func (s *userService) UpdateUser(ctx context.Context, user *entity.User) error {
+func (s *userService) UpdateUser(ctx context.Context, user *entity.User, newPassword string) error {
 	// Check if user exists
-	existingUser, err := s.userRepo.GetByID(ctx, user.ID)
+	existingUser, err := s.getUser(ctx, user.ID)
 	if err != nil {
 		return err
 	}
-	if existingUser == nil {
-		return errors.New("user not found")
-	}
 
 	// Check if username already exists (if changed)
 	if user.Username != existingUser.Username {
@@ -131,7 +334,7 @@ func (s *userService) UpdateUser(ctx context.Context, user *entity.User) error {
 			return err
 		}
 		if existingUser != nil {
-			return errors.New("username already exists")
+			return ErrUsernameExists
 		}
 	}
 
@@ -142,100 +345,450 @@ func (s *userService) UpdateUser(ctx context.Context, user *entity.User) error {
 			return err
 		}
 		if existingUser != nil {
-			return errors.New("email already exists")
+			return ErrEmailExists
 		}
 	}
 
-	// Update user
-	return s.userRepo.Update(ctx, user)
+	// Passwords only change through setPassword, which checks the policy and history.
+	// The new password is set after the checks above, so a refused update changes nothing.
+	user.PasswordHash = existingUser.PasswordHash
+	if newPassword != "" {
+		if err := s.setPassword(ctx, user, newPassword); err != nil {
+			return err
+		}
+		user.ClearPasswordResetToken()
+	}
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, AuditEntry{
+		Action:     constant.AuditActionUserUpdated,
+		EntityType: constant.AuditEntityUser,
+		EntityID:   user.ID,
+		Before:     existingUser,
+		After:      user,
+	})
+
+	if newPassword != "" {
+		// Set by someone managing users, without the current password
+		return s.passwordStored(ctx, user.ID, existingUser.PasswordHash, AuditEntry{
+			Action:     constant.AuditActionPasswordChanged,
+			EntityType: constant.AuditEntityUser,
+			EntityID:   user.ID,
+			Details: map[string]interface{}{
+				"current_password_checked": false,
+			},
+		})
+	}
+
+	// A banned user is logged out everywhere, not only refused at the next login
+	if user.IsBanned() && !existingUser.IsBanned() {
+		return s.sessionService.TerminateAll(ctx, user.ID)
+	}
+	return nil
+}
+
+func (s *userService) UpdateProfile(ctx context.Context, userID int, update usecase.ProfileUpdate, lang string) (*entity.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	before := *user
+
+	if update.FullName != nil {
+		user.FullName = strings.TrimSpace(*update.FullName)
+	}
+	if update.Phone != nil {
+		user.Phone = *update.Phone
+	}
+	if update.Locale != nil {
+		user.Locale = *update.Locale
+	}
+	if update.Timezone != nil {
+		user.Timezone = *update.Timezone
+	}
+
+	emailChanged := false
+	if update.Email != nil {
+		email := strings.TrimSpace(*update.Email)
+		if strings.EqualFold(email, user.Email) {
+			// Asking for the current address again cancels a pending change
+			user.PendingEmail = nil
+		} else {
+			existingUser, err := s.userRepo.GetByEmail(ctx, email)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return nil, err
+			}
+			if existingUser != nil {
+				return nil, ErrEmailExists
+			}
+			user.PendingEmail = &email
+			emailChanged = true
+		}
+	}
+
+	user.SetUpdatedBy(userID)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, AuditEntry{
+		Action:     constant.AuditActionProfileUpdated,
+		EntityType: constant.AuditEntityUser,
+		EntityID:   user.ID,
+		Before:     &before,
+		After:      user,
+	})
+
+	if emailChanged {
+		s.sendEmailChangeVerification(user, lang)
+	}
+	return user, nil
+}
+
+// confirmEmailChange makes the verified pending address the email of the user
+func (s *userService) confirmEmailChange(ctx context.Context, user *entity.User) error {
+	// Someone else may have registered the address while it was pending
+	existingUser, err := s.userRepo.GetByEmail(ctx, *user.PendingEmail)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if existingUser != nil && existingUser.ID != user.ID {
+		return ErrEmailExists
+	}
+
+	before := *user
+	user.Email = *user.PendingEmail
+	user.PendingEmail = nil
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, AuditEntry{
+		Action:      constant.AuditActionEmailChanged,
+		EntityType:  constant.AuditEntityUser,
+		EntityID:    user.ID,
+		ActorUserID: user.ID,
+		Before:      &before,
+		After:       user,
+	})
+	return nil
 }
 
 func (s *userService) DeleteUser(ctx context.Context, id int) error {
 	// Check if user exists
-	existingUser, err := s.userRepo.GetByID(ctx, id)
+	user, err := s.getUser(ctx, id)
 	if err != nil {
 		return err
 	}
-	if existingUser == nil {
-		return errors.New("user not found")
+
+	// Soft delete user, remembering who deleted it (the system when there is no user)
+	deletedBy := AuditActorFromContext(ctx).UserID
+	if deletedBy == 0 {
+		deletedBy = constant.DefaultUpdatedBy
 	}
+	if err := s.userRepo.Delete(ctx, id, deletedBy); err != nil {
+		return err
+	}
+
+	s.auditService.Record(ctx, AuditEntry{
+		Action:     constant.AuditActionUserDeleted,
+		EntityType: constant.AuditEntityUser,
+		EntityID:   id,
+		Before:     user,
+	})
 
-	// Soft delete user
-	return s.userRepo.Delete(ctx, id)
+	// A deleted user is logged out everywhere, its tokens must not outlive the account
+	if err := s.jwtService.RevokeAllUserTokens(ctx, id); err != nil {
+		return err
+	}
+	return s.sessionService.TerminateAll(ctx, id)
 }
 
 func (s *userService) ChangePassword(ctx context.Context, userID int, currentPassword, newPassword string) error {
 	// Get user
-	user, err := s.userRepo.GetByID(ctx, userID)
+	user, err := s.getUser(ctx, userID)
 	if err != nil {
 		return err
 	}
-	if user == nil {
-		return errors.New("user not found")
-	}
 
 	// Verify current password
 	if !user.CheckPassword(currentPassword) {
-		return errors.New("invalid current password")
-	}
-
-	// Hash new password
-	if err := user.HashPassword(newPassword); err != nil {
-		return err
+		return ErrInvalidCurrentPassword
 	}
 
-	// Update user
-	return s.userRepo.Update(ctx, user)
+	return s.storePassword(ctx, user, newPassword, AuditEntry{
+		Action:     constant.AuditActionPasswordChanged,
+		EntityType: constant.AuditEntityUser,
+		EntityID:   user.ID,
+	})
 }
 
-func (s *userService) ForgotPassword(ctx context.Context, email string) error {
+func (s *userService) ForgotPassword(ctx context.Context, email, lang string) error {
 	// Get user by email
 	user, err := s.userRepo.GetByEmail(ctx, email)
-	if err != nil {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return err
 	}
 	if user == nil {
-		return errors.New("user not found")
+		return ErrUserNotFound
 	}
 
-	// Generate verification token
-	if err := user.GenerateVerificationToken(); err != nil {
+	// Only the hash of the token is stored, the plain token is mailed to the user
+	token, err := randomHex(32)
+	if err != nil {
 		return err
 	}
+	user.SetPasswordResetToken(hashToken(token), time.Now().Add(s.config.ResetTokenExpiry))
 
-	// Update user with verification token
-	if err := s.userRepo.UpdateVerificationToken(ctx, user); err != nil {
+	if err := s.userRepo.UpdatePasswordResetToken(ctx, user); err != nil {
 		return err
 	}
 
-	// In a real application, you would send an email with the reset token here
-	// For now, we just return success
+	// Send in the background so the response time does not tell whether the account exists
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), emailTimeout)
+		defer cancel()
+		if err := s.emailService.SendPasswordReset(ctx, user, token, s.config.ResetTokenExpiry, lang); err != nil {
+			logger.Error("Failed to send password reset email to user %d: %v", user.ID, err)
+		}
+	}()
+
 	return nil
 }
 
 func (s *userService) ResetPassword(ctx context.Context, token, newPassword string) error {
-	// Get user by verification token
-	user, err := s.userRepo.GetByVerificationToken(ctx, token)
-	if err != nil {
+	tokenHash := hashToken(token)
+
+	// Get user by reset token
+	user, err := s.userRepo.GetByPasswordResetToken(ctx, tokenHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if user == nil || !user.IsPasswordResetTokenValid(tokenHash) {
+		return ErrInvalidResetToken
+	}
+
+	// The reset token proved who the actor is
+	return s.storePassword(ctx, user, newPassword, AuditEntry{
+		Action:      constant.AuditActionPasswordReset,
+		EntityType:  constant.AuditEntityUser,
+		EntityID:    user.ID,
+		ActorUserID: user.ID,
+	})
+}
+
+// storePassword replaces the password of the user, the one way every password change takes.
+// The new password is checked by setPassword, a pending reset token stops working, the old
+// hash goes to the password history and the user is logged out everywhere.
+func (s *userService) storePassword(ctx context.Context, user *entity.User, newPassword string, entry AuditEntry) error {
+	previousHash := user.PasswordHash
+	if err := s.setPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+
+	// A new password invalidates a pending reset token, which is also how reset tokens are single use
+	user.ClearPasswordResetToken()
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	return s.passwordStored(ctx, user.ID, previousHash, entry)
+}
+
+// passwordStored finishes a password change once the new hash is saved: the change is
+// audited, the old hash goes to the password history and the user is logged out everywhere.
+func (s *userService) passwordStored(ctx context.Context, userID int, previousHash string, entry AuditEntry) error {
+	s.auditService.Record(ctx, entry)
+	if err := s.recordPreviousPassword(ctx, userID, previousHash); err != nil {
+		return err
+	}
+
+	// Whoever knew the old password must not stay logged in
+	if err := s.jwtService.RevokeAllUserTokens(ctx, userID); err != nil {
+		return err
+	}
+	return s.sessionService.TerminateAll(ctx, userID)
+}
+
+// setPassword hashes a new password after checking it against the password policy
+// and the recent passwords of the user. Broken rules are returned as *password.PolicyError.
+func (s *userService) setPassword(ctx context.Context, user *entity.User, newPassword string) error {
+	if err := user.ValidatePassword(newPassword); err != nil {
 		return err
 	}
+
+	// The recent passwords are the current one and the previous ones kept in the history
+	historySize := password.Current().HistorySize
+	if historySize > 0 {
+		hashes, err := s.userRepo.GetPasswordHistory(ctx, user.ID, historySize-1)
+		if err != nil {
+			return err
+		}
+
+		for _, hash := range append(hashes, user.PasswordHash) {
+			previous := &entity.User{PasswordHash: hash}
+			if previous.CheckPassword(newPassword) {
+				return &password.PolicyError{Violations: []password.Violation{{Rule: password.RuleReused, Param: historySize}}}
+			}
+		}
+	}
+
+	return user.HashPassword(newPassword)
+}
+
+// rehashPassword stores a hash made with the configured hasher. The policy is not checked,
+// the password is already in use. Failures only cost the upgrade, the login goes on.
+func (s *userService) rehashPassword(ctx context.Context, user *entity.User, plain string) {
+	hash, err := password.CurrentHasher().Hash(plain)
+	if err != nil {
+		logger.Error("Failed to rehash password of user %d: %v", user.ID, err)
+		return
+	}
+
+	previousHash := user.PasswordHash
+	user.PasswordHash = hash
+	if err := s.userRepo.UpdatePasswordHash(ctx, user); err != nil {
+		user.PasswordHash = previousHash
+		logger.Error("Failed to store rehashed password of user %d: %v", user.ID, err)
+	}
+}
+
+// recordPreviousPassword keeps the hash of a replaced password in the password history
+func (s *userService) recordPreviousPassword(ctx context.Context, userID int, previousHash string) error {
+	historySize := password.Current().HistorySize
+	if historySize <= 1 || previousHash == "" {
+		return nil
+	}
+	return s.userRepo.AddPasswordHistory(ctx, userID, previousHash, historySize-1)
+}
+
+func (s *userService) RestoreUser(ctx context.Context, id int) (*entity.User, error) {
+	user, err := s.userRepo.GetDeletedByID(ctx, id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
 	if user == nil {
-		return errors.New("invalid verification token")
+		return nil, ErrUserNotFound
+	}
+
+	// The username and email may have been taken by a new account in the meantime
+	existingUser, err := s.userRepo.GetByUsername(ctx, user.Username)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if existingUser != nil {
+		return nil, ErrUsernameExists
+	}
+	existingUser, err = s.userRepo.GetByEmail(ctx, user.Email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if existingUser != nil {
+		return nil, ErrEmailExists
 	}
 
-	// Validate verification token
-	if !user.IsVerificationTokenValid(token) {
-		return errors.New("invalid verification token")
+	restoredBy := AuditActorFromContext(ctx).UserID
+	if restoredBy == 0 {
+		restoredBy = constant.DefaultUpdatedBy
 	}
+	if err := s.userRepo.Restore(ctx, id, restoredBy); err != nil {
+		return nil, err
+	}
+
+	s.auditService.Record(ctx, AuditEntry{
+		Action:     constant.AuditActionUserRestored,
+		EntityType: constant.AuditEntityUser,
+		EntityID:   id,
+	})
+
+	user.DeletedAt = nil
+	user.DeletedBy = nil
+	return user, nil
+}
+
+func (s *userService) UnlockUser(ctx context.Context, id int) error {
+	return s.loginAttempts.Unlock(ctx, id)
+}
+
+// auditLoginFailed records a failed login in the audit log, user is nil for unknown usernames
+func (s *userService) auditLoginFailed(ctx context.Context, user *entity.User, username, reason string) {
+	entry := AuditEntry{
+		Action:     constant.AuditActionLoginFailed,
+		EntityType: constant.AuditEntityUser,
+		Details: map[string]interface{}{
+			"username": username,
+			"reason":   reason,
+		},
+	}
+	if user != nil {
+		entry.EntityID = user.ID
+	}
+	s.auditService.Record(ctx, entry)
+}
 
-	// Hash new password
-	if err := user.HashPassword(newPassword); err != nil {
+// loginFailed records a failed login and delays the answer progressively.
+// It returns the error for the caller: invalid credentials, or ErrAccountLocked once the account got locked.
+func (s *userService) loginFailed(ctx context.Context, user *entity.User, ip string) error {
+	delay, err := s.loginAttempts.RecordFailure(ctx, user, ip)
+	if err != nil {
 		return err
 	}
 
-	// Clear verification token
-	user.ClearVerificationToken()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if user != nil && user.IsLocked() {
+		return ErrAccountLocked
+	}
+	return errInvalidCredentials
+}
+
+// sendVerificationEmail mails a new verification link in the background
+func (s *userService) sendVerificationEmail(user *entity.User, lang string) {
+	token := signVerificationToken(s.config.VerificationSecret, user.ID, user.Email, time.Now().Add(s.config.VerificationTokenExpiry))
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), emailTimeout)
+		defer cancel()
+		if err := s.emailService.SendEmailVerification(ctx, user, token, s.config.VerificationTokenExpiry, lang); err != nil {
+			logger.Error("Failed to send verification email to user %d: %v", user.ID, err)
+		}
+	}()
+}
+
+// sendEmailChangeVerification mails a verification link to the pending address of the user in the background
+func (s *userService) sendEmailChangeVerification(user *entity.User, lang string) {
+	newEmail := *user.PendingEmail
+	token := signVerificationToken(s.config.VerificationSecret, user.ID, newEmail, time.Now().Add(s.config.VerificationTokenExpiry))
 
-	// Update user
-	return s.userRepo.Update(ctx, user)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), emailTimeout)
+		defer cancel()
+		if err := s.emailService.SendEmailChangeVerification(ctx, user, newEmail, token, s.config.VerificationTokenExpiry, lang); err != nil {
+			logger.Error("Failed to send email change verification to user %d: %v", user.ID, err)
+		}
+	}()
+}
+
+// getUser loads a user, reporting a missing one as ErrUserNotFound
+func (s *userService) getUser(ctx context.Context, id int) (*entity.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
 }
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email verification tokens are not stored: they carry the user id and expiry
+// and are signed with a server secret together with the email address, so a
+// token stops working when the address changes.
+//
+// Format: <user id>.<expiry unix>.<base64url HMAC-SHA256>
+
+// signVerificationToken creates the email verification token of a user
+func signVerificationToken(secret string, userID int, email string, expiresAt time.Time) string {
+	payload := strconv.Itoa(userID) + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	return payload + "." + verificationSignature(secret, payload, email)
+}
+
+// parseVerificationToken returns the user id and expiry of a token without checking its signature,
+// which needs the email address of the user
+func parseVerificationToken(token string) (int, time.Time, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, time.Time{}, false
+	}
+
+	userID, err := strconv.Atoi(parts[0])
+	if err != nil || userID < 1 {
+		return 0, time.Time{}, false
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return userID, time.Unix(expires, 0), true
+}
+
+// checkVerificationToken verifies the signature of a token for the email address of its user.
+// Without a secret the signature could be computed by anyone, so no token is accepted.
+func checkVerificationToken(secret, token, email string) bool {
+	i := strings.LastIndex(token, ".")
+	if i < 0 || secret == "" {
+		return false
+	}
+	expected := verificationSignature(secret, token[:i], email)
+	return hmac.Equal([]byte(token[i+1:]), []byte(expected))
+}
+
+func verificationSignature(secret, payload, email string) string {
+	// The secret may be shared with other purposes, the signing key is only used for this one
+	mac := hmac.New(sha256.New, deriveKey(secret, "email-verification"))
+	mac.Write([]byte(payload + "." + strings.ToLower(email)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
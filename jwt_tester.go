@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/repository"
 	"go-rest-api-template/internal/service"
 	"os"
 	"strings"
+	"time"
 )
 
 // MockApiKeyService for manual testing
@@ -16,10 +18,6 @@ func (m *MockApiKeyService) ValidateApiKey(ctx context.Context, key string) (*en
 	return &entity.ApiKey{ID: 1, Name: "test-api-key"}, nil
 }
 
-func (m *MockApiKeyService) ValidateAuthKey(ctx context.Context, authKey string) (*entity.ApiKey, error) {
-	return &entity.ApiKey{ID: 1, Name: "test-api-key"}, nil
-}
-
 func (m *MockApiKeyService) GetApiKeyByID(ctx context.Context, id int) (*entity.ApiKey, error) {
 	return &entity.ApiKey{ID: id, Name: "test-api-key"}, nil
 }
@@ -32,6 +30,30 @@ func (m *MockApiKeyService) GetActiveApiKeys(ctx context.Context, limit, offset
 	return []*entity.ApiKey{{ID: 1, Name: "test-api-key"}}, nil
 }
 
+func (m *MockApiKeyService) GetApiKeyCount(ctx context.Context) (int, error) {
+	return 1, nil
+}
+
+func (m *MockApiKeyService) CreateApiKey(ctx context.Context, apiKey *entity.ApiKey) error {
+	return nil
+}
+
+func (m *MockApiKeyService) UpdateApiKey(ctx context.Context, apiKey *entity.ApiKey) error {
+	return nil
+}
+
+func (m *MockApiKeyService) RotateApiKey(ctx context.Context, id int, gracePeriod time.Duration, updatedBy *int) (*entity.ApiKey, error) {
+	return &entity.ApiKey{ID: id, Name: "test-api-key"}, nil
+}
+
+func (m *MockApiKeyService) RevokeApiKey(ctx context.Context, id int, updatedBy *int) error {
+	return nil
+}
+
+func (m *MockApiKeyService) HashPlaintextKeys(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
 func (m *MockApiKeyService) LogApiKeyAccess(ctx context.Context, apiKeyID int) error {
 	return nil
 }
@@ -54,7 +76,7 @@ func main() {
 
 	// Initialize services
 	mockApiKeyService := &MockApiKeyService{}
-	jwtService := service.NewJWTService("test-secret-key-for-manual-testing", 2, 24, mockApiKeyService)
+	jwtService := service.NewJWTService(service.NewHMACKeySet("test-secret-key-for-manual-testing"), 2, 24, mockApiKeyService, repository.NewInMemoryTokenRevocationRepository())
 
 	// Test data
 	apiKey := &entity.ApiKey{
@@ -107,7 +129,7 @@ func main() {
 	fmt.Printf("   API Key: ID=%d, Name=%s\n", apiKey.ID, apiKey.Name)
 	fmt.Printf("   User: ID=%d, Username=%s, Email=%s\n", user.ID, user.Username, user.Email)
 
-	privateToken, err := jwtService.GeneratePrivateToken(apiKey, user)
+	privateToken, err := jwtService.GeneratePrivateToken(apiKey, user, "")
 	if err != nil {
 		fmt.Printf("❌ Error: %v\n", err)
 		return
@@ -184,15 +184,16 @@ show_manual_commands() {
     echo "=== Step 5: Refresh Token ==="
     echo "curl -X POST \"$BASE_URL/api/v1/public/auth/refresh\" \\"
     echo "  -H \"X-API-Key: $API_KEY\" \\"
-    echo "  -H \"Authorization: Bearer YOUR_PRIVATE_TOKEN\" \\"
-    echo "  -H \"Content-Type: application/json\""
+    echo "  -H \"Content-Type: application/json\" \\"
+    echo "  -d '{\"refresh_token\": \"YOUR_REFRESH_TOKEN\"}'"
     echo ""
     
     echo "=== Step 6: Logout ==="
-    echo "curl -X POST \"$BASE_URL/api/v1/public/auth/logout\" \\"
+    echo "curl -X POST \"$BASE_URL/api/v1/private/auth/logout\" \\"
     echo "  -H \"X-API-Key: $API_KEY\" \\"
     echo "  -H \"Authorization: Bearer YOUR_PRIVATE_TOKEN\" \\"
-    echo "  -H \"Content-Type: application/json\""
+    echo "  -H \"Content-Type: application/json\" \\"
+    echo "  -d '{\"refresh_token\": \"YOUR_REFRESH_TOKEN\", \"all_devices\": false}'"
     echo ""
     
     echo "=== Error Testing ==="
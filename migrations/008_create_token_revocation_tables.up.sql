@@ -0,0 +1,16 @@
+CREATE TABLE `revoked_token` (
+  `jti` varchar(64) NOT NULL,
+  `user_id` int(11) unsigned NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`jti`),
+  KEY `idx_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+CREATE TABLE `user_token_revocation` (
+  `user_id` int(11) unsigned NOT NULL,
+  `revoked_before` datetime(6) NOT NULL,
+  `expires_at` datetime NOT NULL,
+  PRIMARY KEY (`user_id`),
+  KEY `idx_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
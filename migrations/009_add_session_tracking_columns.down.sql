@@ -0,0 +1,13 @@
+ALTER TABLE `refresh_token`
+  DROP KEY `idx_session_token`,
+  DROP COLUMN `session_token`;
+
+ALTER TABLE `user_sessions`
+  DROP COLUMN `terminated_at`,
+  DROP COLUMN `last_seen_at`,
+  DROP COLUMN `user_agent`,
+  DROP COLUMN `ip_address`,
+  DROP COLUMN `api_key_id`;
+
+ALTER TABLE `user_sessions`
+  ADD CONSTRAINT `user_sessions_ibfk_1` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`) ON DELETE CASCADE;
@@ -0,0 +1,14 @@
+-- The application stores users in the `user` table, so the foreign key created
+-- by 003 (pointing at `users`) would reject every session insert
+ALTER TABLE `user_sessions` DROP FOREIGN KEY `user_sessions_ibfk_1`;
+
+ALTER TABLE `user_sessions`
+  ADD COLUMN `api_key_id` int(11) unsigned DEFAULT NULL AFTER `user_id`,
+  ADD COLUMN `ip_address` varchar(45) DEFAULT NULL AFTER `session_token`,
+  ADD COLUMN `user_agent` varchar(512) DEFAULT NULL AFTER `ip_address`,
+  ADD COLUMN `last_seen_at` timestamp NULL DEFAULT NULL AFTER `expires_at`,
+  ADD COLUMN `terminated_at` timestamp NULL DEFAULT NULL AFTER `last_seen_at`;
+
+ALTER TABLE `refresh_token`
+  ADD COLUMN `session_token` varchar(255) DEFAULT NULL AFTER `family_id`,
+  ADD KEY `idx_session_token` (`session_token`);
@@ -0,0 +1,8 @@
+CREATE TABLE `request_nonce` (
+  `api_key_id` int(11) unsigned NOT NULL,
+  `nonce` varchar(64) NOT NULL,
+  `expires_at` datetime NOT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`api_key_id`, `nonce`),
+  KEY `idx_expires_at` (`expires_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
@@ -0,0 +1,14 @@
+-- Hashed keys cannot be turned back into plaintext: converted keys are deactivated
+-- and get a placeholder value, they have to be re-issued after rolling back.
+UPDATE `api_key` SET `status` = 'inactive', `api_key` = CONCAT('hashed_', `id`), `auth_key` = ''
+  WHERE `api_key` IS NULL;
+
+ALTER TABLE `api_key`
+  DROP INDEX `idx_api_key_hash`,
+  DROP INDEX `idx_api_key_prefix`,
+  MODIFY `api_key` varchar(32) NOT NULL,
+  MODIFY `auth_key` varchar(64) NOT NULL,
+  DROP COLUMN `auth_key_encrypted`,
+  DROP COLUMN `api_key_hash`,
+  DROP COLUMN `api_key_prefix`,
+  ADD UNIQUE KEY `idx_api_key` (`api_key`);
@@ -0,0 +1,12 @@
+-- API keys are stored as prefix + keyed hash, auth keys encrypted.
+-- Existing plaintext values are converted by `migrate-up` right after this migration,
+-- because hashing needs the configured api_key.secret.
+ALTER TABLE `api_key`
+  ADD COLUMN `api_key_prefix` varchar(16) DEFAULT NULL AFTER `description`,
+  ADD COLUMN `api_key_hash` char(64) DEFAULT NULL AFTER `api_key_prefix`,
+  ADD COLUMN `auth_key_encrypted` varchar(255) DEFAULT NULL AFTER `auth_key`,
+  MODIFY `api_key` varchar(64) DEFAULT NULL,
+  MODIFY `auth_key` varchar(128) DEFAULT NULL,
+  DROP INDEX `idx_api_key`,
+  ADD KEY `idx_api_key_prefix` (`api_key_prefix`),
+  ADD UNIQUE KEY `idx_api_key_hash` (`api_key_hash`);
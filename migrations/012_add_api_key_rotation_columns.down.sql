@@ -0,0 +1,6 @@
+ALTER TABLE `api_key`
+  DROP INDEX `idx_previous_api_key_prefix`,
+  DROP COLUMN `previous_key_expires_at`,
+  DROP COLUMN `previous_auth_key_encrypted`,
+  DROP COLUMN `previous_api_key_hash`,
+  DROP COLUMN `previous_api_key_prefix`;
@@ -0,0 +1,7 @@
+-- Keeps the previous secrets of a rotated key valid until previous_key_expires_at
+ALTER TABLE `api_key`
+  ADD COLUMN `previous_api_key_prefix` varchar(16) DEFAULT NULL AFTER `auth_key_encrypted`,
+  ADD COLUMN `previous_api_key_hash` char(64) DEFAULT NULL AFTER `previous_api_key_prefix`,
+  ADD COLUMN `previous_auth_key_encrypted` varchar(255) DEFAULT NULL AFTER `previous_api_key_hash`,
+  ADD COLUMN `previous_key_expires_at` datetime DEFAULT NULL AFTER `previous_auth_key_encrypted`,
+  ADD KEY `idx_previous_api_key_prefix` (`previous_api_key_prefix`);
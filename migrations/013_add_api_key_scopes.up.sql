@@ -0,0 +1,6 @@
+-- Comma separated scopes an API key may use, '*' grants every scope
+ALTER TABLE `api_key`
+  ADD COLUMN `scopes` varchar(1024) DEFAULT NULL AFTER `h2h`;
+
+-- Existing keys keep the access they had before scopes were introduced
+UPDATE `api_key` SET `scopes` = '*';
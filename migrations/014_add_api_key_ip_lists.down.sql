@@ -0,0 +1,3 @@
+ALTER TABLE `api_key`
+  DROP COLUMN `ip_denylist`,
+  MODIFY COLUMN `ip_whitelist` varchar(256) DEFAULT NULL;
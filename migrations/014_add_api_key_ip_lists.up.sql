@@ -0,0 +1,4 @@
+-- Whitelist and denylist hold comma separated IPv4/IPv6 addresses and CIDR ranges
+ALTER TABLE `api_key`
+  MODIFY COLUMN `ip_whitelist` varchar(1024) DEFAULT NULL,
+  ADD COLUMN `ip_denylist` varchar(1024) DEFAULT NULL AFTER `ip_whitelist`;
@@ -0,0 +1,4 @@
+ALTER TABLE `api_key`
+  DROP COLUMN `daily_quota`,
+  DROP COLUMN `rate_limit_burst`,
+  DROP COLUMN `rate_limit_per_second`;
@@ -0,0 +1,5 @@
+-- Per key rate limits, 0 falls back to rate_limit.default_* (daily_quota 0 means unlimited)
+ALTER TABLE `api_key`
+  ADD COLUMN `rate_limit_per_second` int(11) unsigned NOT NULL DEFAULT 0 AFTER `scopes`,
+  ADD COLUMN `rate_limit_burst` int(11) unsigned NOT NULL DEFAULT 0 AFTER `rate_limit_per_second`,
+  ADD COLUMN `daily_quota` int(11) unsigned NOT NULL DEFAULT 0 AFTER `rate_limit_burst`;
@@ -0,0 +1,57 @@
+CREATE TABLE `role` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(64) NOT NULL,
+  `description` varchar(255) DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+CREATE TABLE `permission` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(64) NOT NULL,
+  `description` varchar(255) DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+CREATE TABLE `role_permission` (
+  `role_id` int(11) unsigned NOT NULL,
+  `permission_id` int(11) unsigned NOT NULL,
+  PRIMARY KEY (`role_id`, `permission_id`),
+  KEY `idx_permission_id` (`permission_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+CREATE TABLE `user_role` (
+  `user_id` int(11) unsigned NOT NULL,
+  `role_id` int(11) unsigned NOT NULL,
+  `created_at` datetime NOT NULL,
+  `created_by` int(11) unsigned DEFAULT NULL,
+  PRIMARY KEY (`user_id`, `role_id`),
+  KEY `idx_role_id` (`role_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+INSERT INTO `role` (`name`, `description`, `created_at`) VALUES
+  ('admin', 'Manages every user, role and API key through the admin endpoints', NOW()),
+  ('user', 'Manages their own account', NOW());
+
+INSERT INTO `permission` (`name`, `description`, `created_at`) VALUES
+  ('users:read', 'Read user profiles', NOW()),
+  ('users:update', 'Update user profiles and passwords', NOW()),
+  ('users:delete', 'Delete users', NOW()),
+  ('users:manage', 'Act on users other than yourself', NOW());
+
+-- admin gets every permission, user only the self-service ones
+INSERT INTO `role_permission` (`role_id`, `permission_id`)
+  SELECT r.id, p.id FROM `role` r CROSS JOIN `permission` p WHERE r.name = 'admin';
+
+INSERT INTO `role_permission` (`role_id`, `permission_id`)
+  SELECT r.id, p.id FROM `role` r JOIN `permission` p
+    ON p.name IN ('users:read', 'users:update', 'users:delete')
+  WHERE r.name = 'user';
+
+-- Existing users keep managing their own account
+INSERT INTO `user_role` (`user_id`, `role_id`, `created_at`)
+  SELECT u.id, r.id, NOW() FROM `user` u JOIN `role` r ON r.name = 'user'
+  WHERE u.deleted_at IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE `user`
+  DROP COLUMN `password_reset_expires_at`;
@@ -0,0 +1,6 @@
+-- Reset tokens are stored as SHA-256 hashes and expire
+ALTER TABLE `user`
+  ADD COLUMN `password_reset_expires_at` datetime DEFAULT NULL AFTER `password_reset_token`;
+
+-- Tokens issued before this migration never expire, so they are dropped
+UPDATE `user` SET `password_reset_token` = NULL;
@@ -0,0 +1,4 @@
+-- Failed logins are counted per account, which is locked until locked_until after too many
+ALTER TABLE `user`
+  ADD COLUMN `failed_login_attempts` int NOT NULL DEFAULT 0 AFTER `status`,
+  ADD COLUMN `locked_until` datetime DEFAULT NULL AFTER `failed_login_attempts`;
@@ -0,0 +1,21 @@
+-- TOTP secrets are stored encrypted; enabled_at stays NULL until the user confirmed a first code
+CREATE TABLE `user_mfa` (
+  `user_id` int(11) unsigned NOT NULL,
+  `secret` varchar(255) NOT NULL,
+  `enabled_at` datetime DEFAULT NULL,
+  `last_used_step` bigint NOT NULL DEFAULT 0,
+  `created_at` datetime NOT NULL,
+  `updated_at` datetime NOT NULL,
+  PRIMARY KEY (`user_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
+
+-- One-time recovery codes, only their SHA-256 hash is stored
+CREATE TABLE `user_recovery_code` (
+  `id` int(11) unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` int(11) unsigned NOT NULL,
+  `code_hash` char(64) NOT NULL,
+  `used_at` datetime DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_user_code_hash` (`user_id`, `code_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
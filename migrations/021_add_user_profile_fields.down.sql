@@ -0,0 +1,6 @@
+ALTER TABLE `user`
+  DROP COLUMN `pending_email`,
+  DROP COLUMN `timezone`,
+  DROP COLUMN `locale`,
+  DROP COLUMN `phone`,
+  DROP COLUMN `full_name`;
@@ -0,0 +1,8 @@
+-- Profile fields users edit themselves. A changed email address waits in pending_email
+-- until the new address is verified.
+ALTER TABLE `user`
+  ADD COLUMN `full_name` varchar(100) NOT NULL DEFAULT '' AFTER `email`,
+  ADD COLUMN `phone` varchar(20) NOT NULL DEFAULT '' AFTER `full_name`,
+  ADD COLUMN `locale` varchar(35) NOT NULL DEFAULT '' AFTER `phone`,
+  ADD COLUMN `timezone` varchar(64) NOT NULL DEFAULT '' AFTER `locale`,
+  ADD COLUMN `pending_email` varchar(100) DEFAULT NULL AFTER `timezone`;
@@ -0,0 +1,21 @@
+-- Security-relevant and data-changing actions: who (user, API key, IP) did what to which entity.
+-- before/after only hold the fields that changed.
+CREATE TABLE `audit_log` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `actor_user_id` int(11) unsigned DEFAULT NULL,
+  `api_key_id` int(11) unsigned DEFAULT NULL,
+  `ip` varchar(45) NOT NULL DEFAULT '',
+  `action` varchar(64) NOT NULL,
+  `entity_type` varchar(32) NOT NULL DEFAULT '',
+  `entity_id` int(11) unsigned DEFAULT NULL,
+  `before` json DEFAULT NULL,
+  `after` json DEFAULT NULL,
+  `details` json DEFAULT NULL,
+  `created_at` datetime(6) NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_actor_user_id` (`actor_user_id`),
+  KEY `idx_api_key_id` (`api_key_id`),
+  KEY `idx_action` (`action`),
+  KEY `idx_entity` (`entity_type`, `entity_id`),
+  KEY `idx_created_at` (`created_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_general_ci;
@@ -0,0 +1,4 @@
+-- Purged users are either deleted or, to keep references to them, stripped of their
+-- personal data and marked with anonymized_at. Anonymized users cannot be restored.
+ALTER TABLE `user`
+  ADD COLUMN `anonymized_at` datetime DEFAULT NULL AFTER `deleted_by`;
@@ -0,0 +1,91 @@
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+)
+
+// List is a set of IPv4/IPv6 addresses and CIDR ranges
+type List struct {
+	nets []*net.IPNet
+}
+
+// cache holds parsed lists by their textual form, so stored lists are parsed once
+var cache sync.Map
+
+// Parse parses a comma separated list of addresses and CIDR ranges,
+// e.g. "203.0.113.7, 198.51.100.0/24, 2001:db8::/32"
+func Parse(spec string) (*List, error) {
+	list := &List{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			_, ipNet, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR range %q", entry)
+			}
+			list.nets = append(list.nets, ipNet)
+			continue
+		}
+
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", entry)
+		}
+		if v4 := ip.To4(); v4 != nil {
+			list.nets = append(list.nets, &net.IPNet{IP: v4, Mask: net.CIDRMask(32, 32)})
+		} else {
+			list.nets = append(list.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)})
+		}
+	}
+
+	return list, nil
+}
+
+// ParseCached is Parse with the result remembered for the same spec
+func ParseCached(spec string) (*List, error) {
+	if list, ok := cache.Load(spec); ok {
+		return list.(*List), nil
+	}
+
+	list, err := Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+	cache.Store(spec, list)
+	return list, nil
+}
+
+// IsEmpty reports whether the list has no entries
+func (l *List) IsEmpty() bool {
+	return len(l.nets) == 0
+}
+
+// Contains reports whether the address is in the list.
+// IPv4-mapped IPv6 addresses match IPv4 entries.
+func (l *List) Contains(address string) bool {
+	ip := net.ParseIP(strings.TrimSpace(address))
+	if ip == nil {
+		return false
+	}
+	return l.ContainsIP(ip)
+}
+
+// ContainsIP reports whether the parsed address is in the list
+func (l *List) ContainsIP(ip net.IP) bool {
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	for _, ipNet := range l.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,171 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Hashing algorithms, as configured in password.hasher
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+// ErrUnknownHash is returned for hashes in a format no hasher produces
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Hasher creates password hashes with one algorithm and its parameters
+type Hasher interface {
+	Hash(password string) (string, error)
+
+	// NeedsRehash reports if a hash was made with another algorithm or other parameters
+	NeedsRehash(hash string) bool
+}
+
+// BcryptHasher hashes passwords with bcrypt. Passwords longer than 72 bytes are rejected.
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher returns a bcrypt hasher, checking the cost is in the range bcrypt supports
+func NewBcryptHasher(cost int) (*BcryptHasher, error) {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	return &BcryptHasher{Cost: cost}, nil
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+// Argon2idHasher hashes passwords with argon2id and encodes them in the PHC string format
+// ($argon2id$v=19$m=65536,t=3,p=2$salt$key) used by other implementations
+type Argon2idHasher struct {
+	Memory      uint32 // in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// NewArgon2idHasher returns an argon2id hasher with 16 byte salts and 32 byte keys
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8) (*Argon2idHasher, error) {
+	if memory < 8*uint32(parallelism) || iterations < 1 || parallelism < 1 {
+		return nil, errors.New("argon2id needs at least one iteration, one thread and 8 KiB of memory per thread")
+	}
+	return &Argon2idHasher{
+		Memory:      memory,
+		Iterations:  iterations,
+		Parallelism: parallelism,
+		SaltLength:  16,
+		KeyLength:   32,
+	}, nil
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.Memory || params.Iterations != h.Iterations || params.Parallelism != h.Parallelism ||
+		uint32(len(salt)) != h.SaltLength || uint32(len(key)) != h.KeyLength
+}
+
+// decodeArgon2id splits a PHC string into its parameters, salt and key
+func decodeArgon2id(hash string) (*Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return nil, nil, nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, ErrUnknownHash
+	}
+
+	params := &Argon2idHasher{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return nil, nil, nil, ErrUnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, ErrUnknownHash
+	}
+	return params, salt, key, nil
+}
+
+// Verify checks a password against a hash of any supported algorithm, with the parameters
+// stored in the hash. This keeps old hashes working after the configured hasher changed.
+func Verify(hash, password string) bool {
+	if strings.HasPrefix(hash, "$"+AlgorithmArgon2id+"$") {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false
+		}
+		computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(computed, key) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// IsHash reports if a string is a hash Verify can check, e.g. one imported from another system
+func IsHash(hash string) bool {
+	if strings.HasPrefix(hash, "$"+AlgorithmArgon2id+"$") {
+		_, _, _, err := decodeArgon2id(hash)
+		return err == nil
+	}
+	// bcrypt hashes are always 60 characters, Cost only reads the prefix
+	_, err := bcrypt.Cost([]byte(hash))
+	return err == nil && len(hash) == 60
+}
+
+// currentHasher creates the password hashes of the application
+var currentHasher atomic.Pointer[Hasher]
+
+func init() {
+	SetHasher(&BcryptHasher{Cost: bcrypt.DefaultCost})
+}
+
+// SetHasher replaces the hasher of the application, called once at startup
+func SetHasher(h Hasher) {
+	currentHasher.Store(&h)
+}
+
+// CurrentHasher returns the hasher of the application
+func CurrentHasher() Hasher {
+	return *currentHasher.Load()
+}
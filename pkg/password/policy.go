@@ -0,0 +1,184 @@
+package password
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Rules a password can break. They are the last part of the message keys
+// of their localized messages (validation.password.<rule>).
+const (
+	RuleMinLength = "min_length"
+	RuleMaxLength = "max_length"
+	RuleUppercase = "uppercase"
+	RuleLowercase = "lowercase"
+	RuleDigit     = "digit"
+	RuleSymbol    = "symbol"
+	RuleCommon    = "common"
+	RuleReused    = "reused"
+)
+
+// Policy holds the password rules (password.*)
+type Policy struct {
+	MinLength        int // in characters
+	MaxLength        int // in characters, bcrypt only uses the first 72 bytes
+	RequireUppercase bool
+	RequireLowercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+
+	// HistorySize is the number of recent passwords, the current one included, a new
+	// password must differ from. The user service keeps the hashes of replaced ones.
+	HistorySize int
+
+	denylist map[string]struct{}
+}
+
+// Violation is a rule a password breaks, Param is the limit of length rules
+type Violation struct {
+	Rule  string
+	Param int
+}
+
+// Message describes the violation in English, for callers without translations
+func (v Violation) Message() string {
+	switch v.Rule {
+	case RuleMinLength:
+		return fmt.Sprintf("password must be at least %d characters long", v.Param)
+	case RuleMaxLength:
+		return fmt.Sprintf("password must be at most %d characters long", v.Param)
+	case RuleUppercase:
+		return "password must contain an uppercase letter"
+	case RuleLowercase:
+		return "password must contain a lowercase letter"
+	case RuleDigit:
+		return "password must contain a digit"
+	case RuleSymbol:
+		return "password must contain a symbol"
+	case RuleCommon:
+		return "password is too common"
+	case RuleReused:
+		return fmt.Sprintf("password must differ from the last %d passwords", v.Param)
+	default:
+		return "password is invalid"
+	}
+}
+
+// PolicyError lists every rule a password breaks
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	rules := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		rules[i] = v.Rule
+	}
+	return "password does not meet the policy: " + strings.Join(rules, ", ")
+}
+
+// DefaultPolicy returns the rules used when nothing is configured
+func DefaultPolicy() *Policy {
+	return &Policy{
+		MinLength: 8,
+		MaxLength: 72,
+	}
+}
+
+// LoadDenylist reads common passwords from a file, one per line. Empty lines
+// and lines starting with # are skipped, matching is case-insensitive.
+func (p *Policy) LoadDenylist(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open password denylist: %w", err)
+	}
+	defer file.Close()
+
+	denylist := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		denylist[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read password denylist: %w", err)
+	}
+
+	p.denylist = denylist
+	return nil
+}
+
+// Check returns a *PolicyError listing the broken rules, nil when the password is accepted.
+// Reuse of previous passwords is not checked here, it needs the stored hashes.
+func (p *Policy) Check(password string) error {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, Violation{Rule: RuleMinLength, Param: p.MinLength})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{Rule: RuleMaxLength, Param: p.MaxLength})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUppercase && !hasUpper {
+		violations = append(violations, Violation{Rule: RuleUppercase})
+	}
+	if p.RequireLowercase && !hasLower {
+		violations = append(violations, Violation{Rule: RuleLowercase})
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, Violation{Rule: RuleDigit})
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, Violation{Rule: RuleSymbol})
+	}
+
+	if _, ok := p.denylist[strings.ToLower(password)]; ok {
+		violations = append(violations, Violation{Rule: RuleCommon})
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// current is the policy of the application, shared by the validator tag,
+// the user entity and the user service
+var current atomic.Pointer[Policy]
+
+func init() {
+	current.Store(DefaultPolicy())
+}
+
+// SetPolicy replaces the policy of the application, called once at startup
+func SetPolicy(p *Policy) {
+	current.Store(p)
+}
+
+// Current returns the policy of the application
+func Current() *Policy {
+	return current.Load()
+}
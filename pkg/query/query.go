@@ -0,0 +1,241 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Errors returned while parsing list parameters
+var (
+	ErrInvalidSort   = errors.New("invalid sort field")
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidTime   = errors.New("invalid time")
+)
+
+// sqlTimeLayout is how time values in cursors are compared against DATETIME
+// columns, in the local time zone the database connection uses (loc=Local)
+const sqlTimeLayout = "2006-01-02 15:04:05.000000"
+
+// Sort is a whitelisted sort field and its direction
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// String formats the sort the way it is passed in the sort parameter, e.g. "-created_at"
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// ParseSort parses "field" or "-field" (descending). Only the allowed fields
+// are accepted, which is what makes them safe to use as column names.
+func ParseSort(raw string, allowed []string, def Sort) (Sort, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return def, nil
+	}
+
+	sort := Sort{Field: strings.TrimPrefix(raw, "-"), Desc: strings.HasPrefix(raw, "-")}
+	for _, field := range allowed {
+		if field == sort.Field {
+			return sort, nil
+		}
+	}
+	return Sort{}, ErrInvalidSort
+}
+
+// Options describe the page of a list request
+type Options struct {
+	Page  int
+	Limit int
+	Sort  Sort
+
+	// After switches to keyset pagination, Page is ignored when it is set
+	After *Cursor
+}
+
+// Offset returns the number of rows skipped for page based pagination
+func (o Options) Offset() int {
+	if o.After != nil || o.Page < 1 {
+		return 0
+	}
+	return (o.Page - 1) * o.Limit
+}
+
+// TotalPages returns the number of pages needed for total rows
+func (o Options) TotalPages(total int) int {
+	if o.Limit < 1 {
+		return 0
+	}
+	return (total + o.Limit - 1) / o.Limit
+}
+
+// Cursor points after the last row of a page: its sort value and id
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    int    `json:"id"`
+}
+
+// NewCursor creates the cursor for the row with the given sort value and id
+func NewCursor(sort Sort, value interface{}, id int) *Cursor {
+	return &Cursor{Sort: sort.String(), Value: formatValue(value), ID: id}
+}
+
+// Encode returns the opaque form of the cursor sent to clients
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor created by Encode. The cursor must have been
+// created for the same sort, otherwise it would point at a different row.
+func DecodeCursor(raw string, sort Sort) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != sort.String() || cursor.ID < 1 {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// ParseTime parses a RFC 3339 time or a YYYY-MM-DD date in the local time zone.
+// Dates used as upper bound (endOfDay) include the whole day.
+func ParseTime(raw string, endOfDay bool) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+	if err != nil {
+		return nil, ErrInvalidTime
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Microsecond)
+	}
+	return &t, nil
+}
+
+// Builder collects the conditions of a list query and renders it for one page
+type Builder struct {
+	conditions []string
+	args       []interface{}
+}
+
+// NewBuilder creates an empty query builder
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// Where adds a condition, all conditions must match
+func (b *Builder) Where(condition string, args ...interface{}) *Builder {
+	b.conditions = append(b.conditions, condition)
+	b.args = append(b.args, args...)
+	return b
+}
+
+// WhereTime adds a condition on a time value, skipped when the time is nil
+func (b *Builder) WhereTime(condition string, t *time.Time) *Builder {
+	if t == nil {
+		return b
+	}
+	return b.Where(condition, *t)
+}
+
+// Search adds a substring match of term against any of the columns, skipped when term is empty
+func (b *Builder) Search(term string, columns ...string) *Builder {
+	if term == "" || len(columns) == 0 {
+		return b
+	}
+
+	pattern := "%" + escapeLike(term) + "%"
+	matches := make([]string, len(columns))
+	args := make([]interface{}, len(columns))
+	for i, column := range columns {
+		matches[i] = column + ` LIKE ? ESCAPE '\\'`
+		args[i] = pattern
+	}
+	return b.Where("("+strings.Join(matches, " OR ")+")", args...)
+}
+
+// Count renders the count query, e.g. base "SELECT COUNT(*) FROM user"
+func (b *Builder) Count(base string) (string, []interface{}) {
+	return base + b.where(nil), b.args
+}
+
+// Select renders the query for one page, e.g. base "SELECT * FROM user".
+// Rows are ordered by the sort field with idColumn as tie breaker.
+func (b *Builder) Select(base, idColumn string, opts Options) (string, []interface{}) {
+	args := append([]interface{}{}, b.args...)
+
+	var keyset []string
+	direction, compare := "ASC", ">"
+	if opts.Sort.Desc {
+		direction, compare = "DESC", "<"
+	}
+	if opts.After != nil {
+		if opts.Sort.Field == idColumn {
+			keyset = append(keyset, fmt.Sprintf("%s %s ?", idColumn, compare))
+			args = append(args, opts.After.ID)
+		} else {
+			keyset = append(keyset, fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))",
+				opts.Sort.Field, compare, opts.Sort.Field, idColumn, compare))
+			args = append(args, opts.After.Value, opts.After.Value, opts.After.ID)
+		}
+	}
+
+	order := fmt.Sprintf("%s %s", opts.Sort.Field, direction)
+	if opts.Sort.Field != idColumn {
+		order += fmt.Sprintf(", %s %s", idColumn, direction)
+	}
+
+	query := base + b.where(keyset) + " ORDER BY " + order + " LIMIT ? OFFSET ?"
+	args = append(args, opts.Limit, opts.Offset())
+	return query, args
+}
+
+func (b *Builder) where(extra []string) string {
+	conditions := append(append([]string{}, b.conditions...), extra...)
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// formatValue turns a sort value into its cursor form
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.Local().Format(sqlTimeLayout)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Local().Format(sqlTimeLayout)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// escapeLike escapes the LIKE wildcards in a search term
+func escapeLike(term string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(term)
+}
@@ -29,10 +29,11 @@ type PaginatedResponse struct {
 
 // Pagination represents pagination metadata
 type Pagination struct {
-	Page       int `json:"page"`
-	Limit      int `json:"limit"`
-	Total      int `json:"total"`
-	TotalPages int `json:"total_pages"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	Total      int    `json:"total"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"` // pass as "after" to fetch the next page
 }
 
 // ValidationError represents validation error
@@ -60,6 +61,15 @@ func NewI18nResponseHelper(manager *i18n.Manager) *I18nResponseHelper {
 
 // Global helper functions that use the global instance
 
+// PaginatedWithI18n creates paginated response with i18n message using global helper
+func PaginatedWithI18n(c *fiber.Ctx, messageKey string, data interface{}, pagination Pagination, templateData map[string]interface{}) error {
+	if GlobalI18nResponseHelper != nil {
+		return GlobalI18nResponseHelper.PaginatedWithI18n(c, messageKey, data, pagination, templateData)
+	}
+	// Fallback to regular response
+	return SendPaginated(c, messageKey, data, pagination)
+}
+
 // SuccessWithI18n creates success response with i18n message using global helper
 func SuccessWithI18n(c *fiber.Ctx, messageKey string, data interface{}, templateData map[string]interface{}) error {
 	if GlobalI18nResponseHelper != nil {
@@ -157,6 +167,23 @@ func CreatedWithI18n(c *fiber.Ctx, messageKey string, data interface{}, template
 	return Created(c, messageKey, data)
 }
 
+// AcceptedWithI18n creates 202 response with i18n message using global helper
+func AcceptedWithI18n(c *fiber.Ctx, messageKey string, data interface{}, templateData map[string]interface{}) error {
+	if GlobalI18nResponseHelper != nil {
+		return GlobalI18nResponseHelper.AcceptedWithI18n(c, messageKey, data, templateData)
+	}
+	// Fallback to regular response
+	return Accepted(c, messageKey, data)
+}
+
+// PaginatedWithI18n creates paginated response with i18n message
+func (h *I18nResponseHelper) PaginatedWithI18n(c *fiber.Ctx, messageKey string, data interface{}, pagination Pagination, templateData map[string]interface{}) error {
+	lang := getLanguageFromContext(c)
+	message := h.i18nManager.TranslateSuccess(lang, messageKey, templateData)
+
+	return SendPaginated(c, message, data, pagination)
+}
+
 // SuccessWithI18n creates success response with i18n message
 func (h *I18nResponseHelper) SuccessWithI18n(c *fiber.Ctx, messageKey string, data interface{}, templateData map[string]interface{}) error {
 	lang := getLanguageFromContext(c)
@@ -202,6 +229,14 @@ func (h *I18nResponseHelper) CreatedWithI18n(c *fiber.Ctx, messageKey string, da
 	})
 }
 
+// AcceptedWithI18n creates 202 response with i18n message, for work that goes on in the background
+func (h *I18nResponseHelper) AcceptedWithI18n(c *fiber.Ctx, messageKey string, data interface{}, templateData map[string]interface{}) error {
+	lang := getLanguageFromContext(c)
+	message := h.i18nManager.TranslateSuccess(lang, messageKey, templateData)
+
+	return Accepted(c, message, data)
+}
+
 // ValidationErrorWithI18n creates validation error response with i18n
 func (h *I18nResponseHelper) ValidationErrorWithI18n(c *fiber.Ctx, errors []ValidationError) error {
 	// Auto-log validation errors
@@ -307,6 +342,17 @@ func Created(c *fiber.Ctx, message string, data interface{}) error {
 	})
 }
 
+// Accepted sends 202 Accepted response
+func Accepted(c *fiber.Ctx, message string, data interface{}) error {
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"data": data,
+		"meta": fiber.Map{
+			"success": true,
+			"message": message,
+		},
+	})
+}
+
 // BadRequest sends 400 Bad Request response
 func BadRequest(c *fiber.Ctx, message string, err string) error {
 	// Auto-log this error
@@ -363,15 +409,23 @@ func InternalServerError(c *fiber.Ctx, message string, err string) error {
 
 // ValidationErrorResponse sends structured validation error response with new format
 func ValidationErrorResponse(c *fiber.Ctx, message string, err error) error {
-	// Get validation errors from the error
-	validationErrors := validator.GetValidationErrors(err)
+	return ValidationErrorResponseWithStatus(c, fiber.StatusBadRequest, message, err)
+}
 
+// ValidationErrorResponseWithStatus sends structured validation error response with a custom status code
+func ValidationErrorResponseWithStatus(c *fiber.Ctx, status int, message string, err error) error {
+	return ValidationErrorListResponse(c, status, message, validator.GetValidationErrors(err))
+}
+
+// ValidationErrorListResponse sends validation errors that were not produced by the validator,
+// e.g. the password policy violations found by a service
+func ValidationErrorListResponse(c *fiber.Ctx, status int, message string, validationErrors []validator.ValidationError) error {
 	// Simplify validation errors to only include field and message
 	simplifiedErrors := make([]map[string]string, len(validationErrors))
 	for i, ve := range validationErrors {
 		simplifiedErrors[i] = map[string]string{
 			"field":   ve.Field,
-			"message": ve.Message,
+			"message": translateValidationMessage(c, ve),
 		}
 	}
 
@@ -381,9 +435,9 @@ func ValidationErrorResponse(c *fiber.Ctx, message string, err error) error {
 		"total_errors":      len(simplifiedErrors),
 	}
 
-	logErrorWithCaller(fiber.StatusBadRequest, "validation_failed", templateData)
+	logErrorWithCaller(status, "validation_failed", templateData)
 
-	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+	return c.Status(status).JSON(fiber.Map{
 		"data": nil,
 		"meta": fiber.Map{
 			"success": false,
@@ -395,3 +449,16 @@ func ValidationErrorResponse(c *fiber.Ctx, message string, err error) error {
 		},
 	})
 }
+
+// translateValidationMessage localizes validation errors that carry a message key
+func translateValidationMessage(c *fiber.Ctx, ve validator.ValidationError) string {
+	if ve.MessageKey == "" || GlobalI18nResponseHelper == nil {
+		return ve.Message
+	}
+
+	message := GlobalI18nResponseHelper.i18nManager.Translate(getLanguageFromContext(c), ve.MessageKey, ve.MessageData)
+	if message == ve.MessageKey {
+		return ve.Message
+	}
+	return message
+}
@@ -1,14 +1,23 @@
 package validator
 
 import (
+	"errors"
 	"fmt"
+	"go-rest-api-template/pkg/password"
 	"reflect"
+	"regexp"
 	"strings"
+	"time"
+	_ "time/tzdata" // the alpine image has no zone database for iana_timezone
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"golang.org/x/text/language"
 )
 
+// phonePattern matches E.164 phone numbers, like +6281234567890
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
 // CustomValidator wraps the validator instance for production use
 type CustomValidator struct {
 	validator *validator.Validate
@@ -27,6 +36,45 @@ func New() *CustomValidator {
 		return name
 	})
 
+	// "password" checks a string against the password policy of the application
+	validate.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return password.Current().Check(fl.Field().String()) == nil
+	})
+
+	// "password_hash" is a bcrypt or argon2id hash, e.g. of users imported from another system
+	validate.RegisterValidation("password_hash", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		return value == "" || password.IsHash(value)
+	})
+
+	// Profile fields. Empty values pass, so a PATCH request can clear the field.
+	// "phone" is an E.164 number, "locale" a BCP 47 language tag and "iana_timezone"
+	// a name of the IANA time zone database.
+	validate.RegisterValidation("phone", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		return value == "" || phonePattern.MatchString(value)
+	})
+	validate.RegisterValidation("locale", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		if value == "" {
+			return true
+		}
+		_, err := language.Parse(value)
+		return err == nil
+	})
+	validate.RegisterValidation("iana_timezone", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		if value == "" {
+			return true
+		}
+		// LoadLocation also accepts "Local" and file paths, which are no zone names
+		if value == "Local" || strings.HasPrefix(value, "/") || strings.Contains(value, "..") {
+			return false
+		}
+		_, err := time.LoadLocation(value)
+		return err == nil
+	})
+
 	return &CustomValidator{
 		validator: validate,
 	}
@@ -47,6 +95,10 @@ type ValidationError struct {
 	Tag     string `json:"tag"`
 	Param   string `json:"param"`
 	Value   string `json:"value,omitempty"`
+
+	// MessageKey and MessageData translate Message where the response is localized
+	MessageKey  string                 `json:"-"`
+	MessageData map[string]interface{} `json:"-"`
 }
 
 // FormatValidationErrors formats validation errors into structured format
@@ -55,6 +107,12 @@ func FormatValidationErrors(err error) []ValidationError {
 
 	if validationErrors, ok := err.(validator.ValidationErrors); ok {
 		for _, err := range validationErrors {
+			// A password gets a message for every rule of the policy it breaks
+			if err.Tag() == "password" {
+				errors = append(errors, passwordValidationErrors(err.Field(), err.Value())...)
+				continue
+			}
+
 			ve := ValidationError{
 				Field: err.Field(),
 				Tag:   err.Tag(),
@@ -106,6 +164,14 @@ func FormatValidationErrors(err error) []ValidationError {
 				ve.Message = fmt.Sprintf("%s must not be equal to %s", err.Field(), err.Param())
 			case "eq":
 				ve.Message = fmt.Sprintf("%s must be equal to %s", err.Field(), err.Param())
+			case "phone":
+				ve.Message = fmt.Sprintf("%s must be a phone number in international format, like +6281234567890", err.Field())
+			case "locale":
+				ve.Message = fmt.Sprintf("%s must be a language tag, like en or id-ID", err.Field())
+			case "iana_timezone":
+				ve.Message = fmt.Sprintf("%s must be a time zone name, like Asia/Jakarta", err.Field())
+			case "password_hash":
+				ve.Message = fmt.Sprintf("%s must be a bcrypt or argon2id hash", err.Field())
 			default:
 				ve.Message = fmt.Sprintf("%s is invalid", err.Field())
 			}
@@ -117,6 +183,33 @@ func FormatValidationErrors(err error) []ValidationError {
 	return errors
 }
 
+// passwordValidationErrors lists the password policy rules a value breaks
+func passwordValidationErrors(field string, value interface{}) []ValidationError {
+	var policyErr *password.PolicyError
+	if !errors.As(password.Current().Check(fmt.Sprintf("%v", value)), &policyErr) {
+		return nil
+	}
+
+	result := make([]ValidationError, len(policyErr.Violations))
+	for i, v := range policyErr.Violations {
+		result[i] = PasswordViolationError(field, v)
+	}
+	return result
+}
+
+// PasswordViolationError describes a broken password rule as a validation error of field.
+// Handlers use it for the violations found by the user service, e.g. password reuse.
+func PasswordViolationError(field string, v password.Violation) ValidationError {
+	return ValidationError{
+		Field:       field,
+		Message:     v.Message(),
+		Tag:         "password",
+		Param:       v.Rule,
+		MessageKey:  "validation.password." + v.Rule,
+		MessageData: map[string]interface{}{"Limit": v.Param},
+	}
+}
+
 // FormatValidationErrorsAsString formats validation errors as a single string
 func FormatValidationErrorsAsString(err error) string {
 	errors := FormatValidationErrors(err)
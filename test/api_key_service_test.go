@@ -0,0 +1,235 @@
+package handler_test
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockApiKeyRepository is an in-memory ApiKeyRepository for testing
+type MockApiKeyRepository struct {
+	keys map[int]*entity.ApiKey
+}
+
+func NewMockApiKeyRepository() *MockApiKeyRepository {
+	return &MockApiKeyRepository{
+		keys: make(map[int]*entity.ApiKey),
+	}
+}
+
+// stored returns a copy of the key as the database would hold it
+func (m *MockApiKeyRepository) stored(key *entity.ApiKey) *entity.ApiKey {
+	copied := *key
+	return &copied
+}
+
+func (m *MockApiKeyRepository) GetByPrefix(ctx context.Context, prefix string) ([]*entity.ApiKey, error) {
+	var keys []*entity.ApiKey
+	for _, key := range m.keys {
+		previousValid := key.PreviousApiKeyPrefix == prefix && key.IsPreviousKeyValid()
+		if (key.ApiKeyPrefix == prefix || previousValid) && key.IsActive() {
+			keys = append(keys, m.stored(key))
+		}
+	}
+	return keys, nil
+}
+
+func (m *MockApiKeyRepository) GetByID(ctx context.Context, id int) (*entity.ApiKey, error) {
+	if key, ok := m.keys[id]; ok {
+		return m.stored(key), nil
+	}
+	return nil, nil
+}
+
+func (m *MockApiKeyRepository) Create(ctx context.Context, apiKey *entity.ApiKey) error {
+	apiKey.ID = len(m.keys) + 1
+	stored := m.stored(apiKey)
+	stored.ApiKey = ""
+	stored.AuthKey = ""
+	m.keys[apiKey.ID] = stored
+	return nil
+}
+
+func (m *MockApiKeyRepository) Update(ctx context.Context, apiKey *entity.ApiKey) error {
+	stored := m.keys[apiKey.ID]
+	stored.Name = apiKey.Name
+	stored.Description = apiKey.Description
+	stored.Status = apiKey.Status
+	stored.H2H = apiKey.H2H
+	stored.Scopes = apiKey.Scopes
+	stored.RateLimitPerSecond = apiKey.RateLimitPerSecond
+	stored.RateLimitBurst = apiKey.RateLimitBurst
+	stored.DailyQuota = apiKey.DailyQuota
+	stored.IPWhitelist = apiKey.IPWhitelist
+	stored.IPDenylist = apiKey.IPDenylist
+	return nil
+}
+
+func (m *MockApiKeyRepository) Rotate(ctx context.Context, apiKey *entity.ApiKey, previousExpiresAt time.Time) error {
+	stored := m.keys[apiKey.ID]
+	stored.PreviousApiKeyPrefix = stored.ApiKeyPrefix
+	stored.PreviousApiKeyHash = stored.ApiKeyHash
+	stored.PreviousAuthKeyEncrypted = stored.AuthKeyEncrypted
+	stored.PreviousKeyExpiresAt = &previousExpiresAt
+	stored.ApiKeyPrefix = apiKey.ApiKeyPrefix
+	stored.ApiKeyHash = apiKey.ApiKeyHash
+	stored.AuthKeyEncrypted = apiKey.AuthKeyEncrypted
+	return nil
+}
+
+func (m *MockApiKeyRepository) Revoke(ctx context.Context, id int, updatedBy *int) error {
+	m.keys[id].Status = "revoked"
+	m.keys[id].PreviousKeyExpiresAt = nil
+	return nil
+}
+
+func (m *MockApiKeyRepository) GetWithPlaintextSecrets(ctx context.Context) ([]*entity.ApiKey, error) {
+	var keys []*entity.ApiKey
+	for _, key := range m.keys {
+		if key.ApiKey != "" || key.AuthKey != "" {
+			keys = append(keys, m.stored(key))
+		}
+	}
+	return keys, nil
+}
+
+func (m *MockApiKeyRepository) UpdateSecrets(ctx context.Context, apiKey *entity.ApiKey) error {
+	stored := m.keys[apiKey.ID]
+	stored.ApiKeyPrefix = apiKey.ApiKeyPrefix
+	stored.ApiKeyHash = apiKey.ApiKeyHash
+	stored.AuthKeyEncrypted = apiKey.AuthKeyEncrypted
+	stored.ApiKey = ""
+	stored.AuthKey = ""
+	return nil
+}
+
+func (m *MockApiKeyRepository) GetAll(ctx context.Context, limit, offset int) ([]*entity.ApiKey, error) {
+	return nil, nil
+}
+
+func (m *MockApiKeyRepository) GetByStatus(ctx context.Context, status string, limit, offset int) ([]*entity.ApiKey, error) {
+	return nil, nil
+}
+
+func (m *MockApiKeyRepository) GetCount(ctx context.Context) (int, error) {
+	return len(m.keys), nil
+}
+
+func (m *MockApiKeyRepository) UpdateLastAccess(ctx context.Context, id int) error {
+	return nil
+}
+
+func newTestApiKeyService(t *testing.T, repo *MockApiKeyRepository) service.ApiKeyService {
+	secrets, err := service.NewApiKeySecretManager("test-api-key-secret")
+	require.NoError(t, err)
+	return service.NewApiKeyService(repo, secrets, newTestAuditService())
+}
+
+func TestApiKeyService_CreateAndValidate(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockApiKeyRepository()
+	svc := newTestApiKeyService(t, repo)
+
+	created := &entity.ApiKey{Name: "partner", H2H: "Y"}
+	require.NoError(t, svc.CreateApiKey(ctx, created))
+	require.NotEmpty(t, created.ApiKey)
+	require.NotEmpty(t, created.AuthKey)
+
+	// Only the prefix, hash and encrypted auth key are stored
+	stored := repo.keys[created.ID]
+	assert.Empty(t, stored.ApiKey)
+	assert.Empty(t, stored.AuthKey)
+	assert.NotEqual(t, created.ApiKey, stored.ApiKeyHash)
+	assert.NotContains(t, stored.AuthKeyEncrypted, created.AuthKey)
+
+	validated, err := svc.ValidateApiKey(ctx, created.ApiKey)
+	require.NoError(t, err)
+	require.NotNil(t, validated)
+	assert.Equal(t, created.ID, validated.ID)
+	assert.Equal(t, created.AuthKey, validated.AuthKey) // decrypted for H2H signing
+
+	// Same prefix, wrong secret
+	validated, err = svc.ValidateApiKey(ctx, created.ApiKey[:service.ApiKeyPrefixLength]+"00000000000000000000000000")
+	require.NoError(t, err)
+	assert.Nil(t, validated)
+}
+
+func TestApiKeyService_HashPlaintextKeys(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockApiKeyRepository()
+	svc := newTestApiKeyService(t, repo)
+
+	// A row inserted before hashing was introduced
+	repo.keys[1] = &entity.ApiKey{ID: 1, Name: "legacy", Status: "active", ApiKey: "dev_api_key_12345678901234567890", AuthKey: "dev_auth_key"}
+
+	converted, err := svc.HashPlaintextKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, converted)
+	assert.Empty(t, repo.keys[1].ApiKey)
+
+	// Clients keep using the same key
+	validated, err := svc.ValidateApiKey(ctx, "dev_api_key_12345678901234567890")
+	require.NoError(t, err)
+	require.NotNil(t, validated)
+	assert.Equal(t, "dev_auth_key", validated.AuthKey)
+}
+
+func TestApiKeyService_RotateWithGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockApiKeyRepository()
+	svc := newTestApiKeyService(t, repo)
+
+	created := &entity.ApiKey{Name: "partner", H2H: "Y"}
+	require.NoError(t, svc.CreateApiKey(ctx, created))
+	oldApiKey, oldAuthKey := created.ApiKey, created.AuthKey
+
+	rotated, err := svc.RotateApiKey(ctx, created.ID, time.Hour, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, oldApiKey, rotated.ApiKey)
+	require.NotNil(t, rotated.PreviousKeyExpiresAt)
+
+	// Both keys work during the grace period, each with its own auth key
+	validated, err := svc.ValidateApiKey(ctx, rotated.ApiKey)
+	require.NoError(t, err)
+	require.NotNil(t, validated)
+	assert.Equal(t, rotated.AuthKey, validated.AuthKey)
+
+	validated, err = svc.ValidateApiKey(ctx, oldApiKey)
+	require.NoError(t, err)
+	require.NotNil(t, validated)
+	assert.Equal(t, oldAuthKey, validated.AuthKey)
+
+	// Once the grace period is over only the new key works
+	expired := time.Now().Add(-time.Minute)
+	repo.keys[created.ID].PreviousKeyExpiresAt = &expired
+	validated, err = svc.ValidateApiKey(ctx, oldApiKey)
+	require.NoError(t, err)
+	assert.Nil(t, validated)
+}
+
+func TestApiKeyService_Revoke(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockApiKeyRepository()
+	svc := newTestApiKeyService(t, repo)
+
+	created := &entity.ApiKey{Name: "partner"}
+	require.NoError(t, svc.CreateApiKey(ctx, created))
+	require.NoError(t, svc.RevokeApiKey(ctx, created.ID, nil))
+
+	validated, err := svc.ValidateApiKey(ctx, created.ApiKey)
+	require.NoError(t, err)
+	assert.Nil(t, validated)
+
+	// A revoked key can neither be rotated nor re-enabled
+	_, err = svc.RotateApiKey(ctx, created.ID, 0, nil)
+	assert.ErrorIs(t, err, service.ErrApiKeyRevoked)
+	err = svc.UpdateApiKey(ctx, &entity.ApiKey{ID: created.ID, Name: "partner", Status: "active"})
+	assert.ErrorIs(t, err, service.ErrApiKeyRevoked)
+
+	assert.ErrorIs(t, svc.RevokeApiKey(ctx, 999, nil), service.ErrApiKeyNotFound)
+}
@@ -0,0 +1,303 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/internal/handler"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/query"
+	"go-rest-api-template/pkg/response"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAuditLogRepository keeps audit logs in memory. API key usage is recorded
+// from goroutines, so it is safe for concurrent use.
+type MockAuditLogRepository struct {
+	mu   sync.Mutex
+	logs []*entity.AuditLog
+}
+
+func NewMockAuditLogRepository() *MockAuditLogRepository {
+	return &MockAuditLogRepository{}
+}
+
+func (m *MockAuditLogRepository) Create(ctx context.Context, log *entity.AuditLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	log.ID = len(m.logs) + 1
+	m.logs = append(m.logs, log)
+	return nil
+}
+
+// GetAll returns the logs matching the filter, newest first
+func (m *MockAuditLogRepository) GetAll(ctx context.Context, filter repository.AuditLogFilter, opts query.Options) ([]*entity.AuditLog, error) {
+	logs := m.filter(filter)
+	sort.Slice(logs, func(i, j int) bool { return logs[i].ID > logs[j].ID })
+
+	if opts.After != nil {
+		for len(logs) > 0 && logs[0].ID >= opts.After.ID {
+			logs = logs[1:]
+		}
+	}
+	if offset := opts.Offset(); offset < len(logs) {
+		logs = logs[offset:]
+	} else {
+		logs = nil
+	}
+	if len(logs) > opts.Limit {
+		logs = logs[:opts.Limit]
+	}
+	return logs, nil
+}
+
+func (m *MockAuditLogRepository) GetCount(ctx context.Context, filter repository.AuditLogFilter) (int, error) {
+	return len(m.filter(filter)), nil
+}
+
+// GetByUser returns the logs by or on the user, oldest first
+func (m *MockAuditLogRepository) GetByUser(ctx context.Context, userID int) ([]*entity.AuditLog, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var logs []*entity.AuditLog
+	for _, log := range m.logs {
+		if m.isActor(log, userID) || m.isEntity(log, userID) {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (m *MockAuditLogRepository) EraseUserData(ctx context.Context, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, log := range m.logs {
+		if m.isActor(log, userID) {
+			log.IP = ""
+		}
+		if m.isEntity(log, userID) {
+			log.Before, log.After, log.Details = nil, nil, nil
+		}
+	}
+	return nil
+}
+
+func (m *MockAuditLogRepository) isActor(log *entity.AuditLog, userID int) bool {
+	return log.ActorUserID != nil && *log.ActorUserID == userID
+}
+
+func (m *MockAuditLogRepository) isEntity(log *entity.AuditLog, userID int) bool {
+	return log.EntityType == constant.AuditEntityUser && log.EntityID != nil && *log.EntityID == userID
+}
+
+func (m *MockAuditLogRepository) filter(filter repository.AuditLogFilter) []*entity.AuditLog {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	logs := make([]*entity.AuditLog, 0, len(m.logs))
+	for _, log := range m.logs {
+		if filter.Action != "" && log.Action != filter.Action {
+			continue
+		}
+		if filter.ActorUserID != 0 && (log.ActorUserID == nil || *log.ActorUserID != filter.ActorUserID) {
+			continue
+		}
+		if filter.EntityID != 0 && (log.EntityID == nil || *log.EntityID != filter.EntityID) {
+			continue
+		}
+		logs = append(logs, log)
+	}
+	return logs
+}
+
+// byAction returns the recorded logs of one action, oldest first
+func (m *MockAuditLogRepository) byAction(action string) []*entity.AuditLog {
+	logs := m.filter(repository.AuditLogFilter{Action: action})
+	sort.Slice(logs, func(i, j int) bool { return logs[i].ID < logs[j].ID })
+	return logs
+}
+
+// newTestAuditService records into a repository nobody looks at
+func newTestAuditService() service.AuditService {
+	return service.NewAuditService(NewMockAuditLogRepository())
+}
+
+func TestAuditService_RecordsChangedFieldsAndActor(t *testing.T) {
+	repo := NewMockAuditLogRepository()
+	auditService := service.NewAuditService(repo)
+	ctx := service.WithAuditActor(context.Background(), service.AuditActor{UserID: 7, ApiKeyID: 3, IP: "203.0.113.9"})
+
+	before := &entity.User{ID: 2, Username: "testuser", Email: "old@example.com", Status: constant.UserStatusActive, PasswordHash: "old-hash"}
+	after := *before
+	after.Email = "new@example.com"
+	after.PasswordHash = "new-hash"
+	after.SetUpdatedBy(7)
+
+	auditService.Record(ctx, service.AuditEntry{
+		Action:     constant.AuditActionUserUpdated,
+		EntityType: constant.AuditEntityUser,
+		EntityID:   2,
+		Before:     before,
+		After:      &after,
+	})
+
+	logs := repo.byAction(constant.AuditActionUserUpdated)
+	require.Len(t, logs, 1)
+	log := logs[0]
+	assert.Equal(t, 7, *log.ActorUserID)
+	assert.Equal(t, 3, *log.ApiKeyID)
+	assert.Equal(t, "203.0.113.9", log.IP)
+	assert.Equal(t, 2, *log.EntityID)
+
+	// Only the changed field is kept, secrets and bookkeeping fields never show up
+	assert.Equal(t, map[string]interface{}{"email": "old@example.com"}, log.Before)
+	assert.Equal(t, map[string]interface{}{"email": "new@example.com"}, log.After)
+}
+
+func TestUserService_AuditsLoginsAndDeletion(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", constant.UserStatusActive)
+	require.NoError(t, repo.users[1].HashPassword("password123"))
+	auditRepo := NewMockAuditLogRepository()
+	refreshRepo := NewMockRefreshTokenRepository()
+	sessionService := service.NewSessionService(NewMockSessionRepository(), refreshRepo, 24)
+	userService := service.NewUserService(repo, newTestJWTService(), service.NewRefreshTokenService(refreshRepo, 24), sessionService, newTestRoleService(repo),
+		NewMockEmailService(), newTestLoginAttemptService(repo), service.NewAuditService(auditRepo), service.UserServiceConfig{})
+
+	_, _, err := userService.Login(ctx, "testuser", "wrong-password", "127.0.0.1")
+	require.Error(t, err)
+	_, _, err = userService.Login(ctx, "nobody", "password123", "127.0.0.1")
+	require.Error(t, err)
+	_, _, err = userService.Login(ctx, "testuser", "password123", "127.0.0.1")
+	require.NoError(t, err)
+
+	failed := auditRepo.byAction(constant.AuditActionLoginFailed)
+	require.Len(t, failed, 2)
+	assert.Equal(t, "invalid_password", failed[0].Details["reason"])
+	assert.Equal(t, 1, *failed[0].EntityID)
+	assert.Equal(t, "unknown_user", failed[1].Details["reason"])
+	assert.Equal(t, "nobody", failed[1].Details["username"])
+	assert.Nil(t, failed[1].EntityID)
+
+	succeeded := auditRepo.byAction(constant.AuditActionLoginSucceeded)
+	require.Len(t, succeeded, 1)
+	assert.Equal(t, 1, *succeeded[0].ActorUserID)
+
+	// The admin deleting through the API is the actor, read from the request locals
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", 9)
+		c.Locals("client_ip", "198.51.100.4")
+		return c.Next()
+	})
+	app.Delete("/users/:id", handler.NewUserHandler(userService).DeleteUser)
+	setupTestGlobalHelpers()
+
+	resp, err := app.Test(httptest.NewRequest("DELETE", "/users/1", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	deleted := auditRepo.byAction(constant.AuditActionUserDeleted)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, 9, *deleted[0].ActorUserID)
+	assert.Equal(t, "198.51.100.4", deleted[0].IP)
+	assert.Equal(t, "testuser", deleted[0].Before["username"])
+	assert.Nil(t, deleted[0].After)
+}
+
+func TestApiKeyService_AuditsUsageOncePerInterval(t *testing.T) {
+	auditRepo := NewMockAuditLogRepository()
+	secrets, err := service.NewApiKeySecretManager("test-api-key-secret")
+	require.NoError(t, err)
+	apiKeyService := service.NewApiKeyService(NewMockApiKeyRepository(), secrets, service.NewAuditService(auditRepo))
+
+	fromIP := func(ip string) context.Context {
+		return service.WithAuditActor(context.Background(), service.AuditActor{ApiKeyID: 5, IP: ip})
+	}
+	require.NoError(t, apiKeyService.LogApiKeyAccess(fromIP("192.0.2.1"), 5))
+	require.NoError(t, apiKeyService.LogApiKeyAccess(fromIP("192.0.2.1"), 5))
+	require.NoError(t, apiKeyService.LogApiKeyAccess(fromIP("192.0.2.2"), 5))
+
+	used := auditRepo.byAction(constant.AuditActionApiKeyUsed)
+	require.Len(t, used, 2)
+	assert.Equal(t, "192.0.2.1", used[0].IP)
+	assert.Equal(t, "192.0.2.2", used[1].IP)
+}
+
+func TestAuditLogHandler_GetAuditLogs(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	auditRepo := NewMockAuditLogRepository()
+	auditService := service.NewAuditService(auditRepo)
+	for i := 0; i < 3; i++ {
+		auditService.Record(context.Background(), service.AuditEntry{
+			Action:      constant.AuditActionLoginSucceeded,
+			EntityType:  constant.AuditEntityUser,
+			EntityID:    1,
+			ActorUserID: 1,
+		})
+	}
+	auditService.Record(context.Background(), service.AuditEntry{
+		Action:     constant.AuditActionLoginFailed,
+		EntityType: constant.AuditEntityUser,
+		Details:    map[string]interface{}{"reason": "unknown_user"},
+	})
+
+	app := fiber.New()
+	app.Get("/admin/audit-logs", handler.NewAuditLogHandler(auditService).GetAuditLogs)
+
+	type page struct {
+		Data []struct {
+			ID      int                    `json:"id"`
+			Details map[string]interface{} `json:"details"`
+		} `json:"data"`
+		Meta struct {
+			Pagination response.Pagination `json:"pagination"`
+		} `json:"meta"`
+	}
+	get := func(t *testing.T, target string) (int, page) {
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+		require.NoError(t, err)
+		var body page
+		if resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		}
+		return resp.StatusCode, body
+	}
+
+	status, body := get(t, "/admin/audit-logs?action=auth.login_failed")
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, body.Data, 1)
+	assert.Equal(t, "unknown_user", body.Data[0].Details["reason"])
+
+	// Newest first, the cursor continues where the page ended
+	status, body = get(t, "/admin/audit-logs?actor_user_id=1&limit=2")
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, body.Data, 2)
+	assert.Equal(t, 3, body.Data[0].ID)
+	assert.Equal(t, 3, body.Meta.Pagination.Total)
+	require.NotEmpty(t, body.Meta.Pagination.NextCursor)
+
+	status, body = get(t, "/admin/audit-logs?actor_user_id=1&limit=2&after="+body.Meta.Pagination.NextCursor)
+	require.Equal(t, http.StatusOK, status)
+	require.Len(t, body.Data, 1)
+	assert.Equal(t, 1, body.Data[0].ID)
+
+	for _, params := range []string{"sort=action", "ip=not-an-ip", "created_from=yesterday"} {
+		status, _ = get(t, "/admin/audit-logs?"+params)
+		assert.Equal(t, http.StatusUnprocessableEntity, status, params)
+	}
+}
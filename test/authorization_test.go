@@ -0,0 +1,190 @@
+package handler_test
+
+import (
+	"context"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/middleware"
+	"go-rest-api-template/internal/service"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRoleRepository is an in-memory RoleRepository for testing
+type MockRoleRepository struct {
+	roles     map[string]*entity.Role
+	userRoles map[int]map[string]bool
+}
+
+func NewMockRoleRepository() *MockRoleRepository {
+	return &MockRoleRepository{
+		roles: map[string]*entity.Role{
+			constant.RoleAdmin: {ID: 1, Name: constant.RoleAdmin, Permissions: []string{
+				constant.PermissionUsersRead, constant.PermissionUsersUpdate, constant.PermissionUsersDelete, constant.PermissionUsersManage,
+			}},
+			constant.RoleUser: {ID: 2, Name: constant.RoleUser, Permissions: []string{
+				constant.PermissionUsersRead, constant.PermissionUsersUpdate, constant.PermissionUsersDelete,
+			}},
+		},
+		userRoles: make(map[int]map[string]bool),
+	}
+}
+
+func (m *MockRoleRepository) GetAll(ctx context.Context) ([]*entity.Role, error) {
+	roles := make([]*entity.Role, 0, len(m.roles))
+	for _, role := range m.roles {
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (m *MockRoleRepository) GetByName(ctx context.Context, name string) (*entity.Role, error) {
+	return m.roles[name], nil
+}
+
+func (m *MockRoleRepository) GetUserRoles(ctx context.Context, userID int) ([]string, error) {
+	roles := []string{}
+	for name := range m.userRoles[userID] {
+		roles = append(roles, name)
+	}
+	return roles, nil
+}
+
+func (m *MockRoleRepository) AssignRole(ctx context.Context, userID, roleID int, createdBy *int) error {
+	if m.userRoles[userID] == nil {
+		m.userRoles[userID] = make(map[string]bool)
+	}
+	for name, role := range m.roles {
+		if role.ID == roleID {
+			m.userRoles[userID][name] = true
+		}
+	}
+	return nil
+}
+
+func (m *MockRoleRepository) RevokeRole(ctx context.Context, userID, roleID int) error {
+	for name, role := range m.roles {
+		if role.ID == roleID {
+			delete(m.userRoles[userID], name)
+		}
+	}
+	return nil
+}
+
+func (m *MockRoleRepository) GetPermissions(ctx context.Context, roles []string) ([]string, error) {
+	permissions := []string{}
+	for _, name := range roles {
+		if role, ok := m.roles[name]; ok {
+			permissions = append(permissions, role.Permissions...)
+		}
+	}
+	return permissions, nil
+}
+
+// newAuthorizeTestApp serves PUT /users/:id behind PermissionMiddleware and Authorize
+// for a caller with the given user id and roles, which the user holds
+func newAuthorizeTestApp(userID int, roles []string) *fiber.App {
+	roleRepo := NewMockRoleRepository()
+	for _, role := range roles {
+		_ = roleRepo.AssignRole(context.Background(), userID, roleRepo.roles[role].ID, nil)
+	}
+	return newAuthorizeTestAppWithRepo(roleRepo, userID, roles)
+}
+
+// newAuthorizeTestAppWithRepo is newAuthorizeTestApp for a token whose roles claim
+// may differ from the roles held in roleRepo
+func newAuthorizeTestAppWithRepo(roleRepo *MockRoleRepository, userID int, roles []string) *fiber.App {
+	roleService := service.NewRoleService(roleRepo, NewMockUserRepository())
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID)
+		c.Locals("jwt_claims", &service.PrivateJWTClaims{UserID: userID, Roles: roles})
+		return c.Next()
+	})
+	app.Use(middleware.PermissionMiddleware(roleService))
+	app.Put("/users/:id", middleware.Authorize(constant.PermissionUsersUpdate, middleware.OwnerOrAdmin("id")), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestAuthorize_OwnerOrAdmin(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	tests := []struct {
+		name   string
+		userID int
+		roles  []string
+		path   string
+		status int
+	}{
+		{"owner", 7, []string{constant.RoleUser}, "/users/7", fiber.StatusOK},
+		{"other user", 7, []string{constant.RoleUser}, "/users/8", fiber.StatusForbidden},
+		{"admin on other user", 1, []string{constant.RoleAdmin}, "/users/8", fiber.StatusOK},
+		{"token without roles", 7, nil, "/users/7", fiber.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := newAuthorizeTestApp(tt.userID, tt.roles).Test(httptest.NewRequest("PUT", tt.path, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
+
+func TestAuthorize_RevokedRoleAppliesImmediately(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	// The token still claims admin, the role was revoked since
+	roleRepo := NewMockRoleRepository()
+	require.NoError(t, roleRepo.AssignRole(context.Background(), 1, roleRepo.roles[constant.RoleUser].ID, nil))
+	app := newAuthorizeTestAppWithRepo(roleRepo, 1, []string{constant.RoleAdmin, constant.RoleUser})
+
+	resp, err := app.Test(httptest.NewRequest("PUT", "/users/8", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("PUT", "/users/1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestRoleService_AssignAndRevoke(t *testing.T) {
+	userRepo := NewMockUserRepository()
+	userRepo.AddTestUser(1, "testuser", "test@example.com", "active")
+	roleService := service.NewRoleService(NewMockRoleRepository(), userRepo)
+	ctx := context.Background()
+
+	require.NoError(t, roleService.AssignRole(ctx, 1, constant.RoleAdmin, nil))
+	roles, err := roleService.GetUserRoles(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{constant.RoleAdmin}, roles)
+
+	require.NoError(t, roleService.RevokeRole(ctx, 1, constant.RoleAdmin))
+	roles, err = roleService.GetUserRoles(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, roles)
+
+	assert.ErrorIs(t, roleService.AssignRole(ctx, 1, "superuser", nil), service.ErrRoleNotFound)
+	assert.ErrorIs(t, roleService.AssignRole(ctx, 42, constant.RoleAdmin, nil), service.ErrUserNotFound)
+}
+
+func TestJWTService_RolesClaim(t *testing.T) {
+	jwtService := newTestJWTService()
+	apiKey := &entity.ApiKey{ID: 1, Name: "test-api-key"}
+	user := &entity.User{ID: 7, Username: "testuser", Email: "test@example.com", Roles: []string{constant.RoleUser}}
+
+	token, err := jwtService.GeneratePrivateToken(apiKey, user, "")
+	require.NoError(t, err)
+
+	claims, _, tokenUser, err := jwtService.ValidatePrivateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, []string{constant.RoleUser}, claims.Roles)
+	assert.True(t, tokenUser.HasRole(constant.RoleUser))
+}
@@ -0,0 +1,162 @@
+package handler_test
+
+import (
+	"context"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/usecase"
+	"go-rest-api-template/internal/handler"
+	"go-rest-api-template/internal/service"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newVerifyingUserService(repo *MockUserRepository, emailService service.EmailService) usecase.UserUsecase {
+	return service.NewUserService(repo, nil, nil, nil, newTestRoleService(repo), emailService, newTestLoginAttemptService(repo), newTestAuditService(), service.UserServiceConfig{
+		RequireEmailVerification: true,
+		VerificationSecret:       "test-secret",
+		VerificationTokenExpiry:  time.Hour,
+	})
+}
+
+func registerPendingUser(t *testing.T, userService usecase.UserUsecase) *entity.User {
+	user := &entity.User{Username: "newuser", Email: "new@example.com"}
+	require.NoError(t, user.HashPassword("password123"))
+	require.NoError(t, userService.Register(context.Background(), user, "en"))
+	return user
+}
+
+func TestEmailVerification_Flow(t *testing.T) {
+	repo := NewMockUserRepository()
+	emailService := NewMockEmailService()
+	userService := newVerifyingUserService(repo, emailService)
+
+	user := registerPendingUser(t, userService)
+	assert.Equal(t, constant.UserStatusPending, user.Status)
+	token := emailService.sentToken(t)
+
+	_, _, err := userService.Login(context.Background(), "newuser", "password123", "127.0.0.1")
+	assert.ErrorIs(t, err, service.ErrEmailNotVerified)
+
+	// A wrong password must not reveal that the account is pending
+	_, _, err = userService.Login(context.Background(), "newuser", "wrongpassword", "127.0.0.1")
+	assert.NotErrorIs(t, err, service.ErrEmailNotVerified)
+
+	require.NoError(t, userService.VerifyEmail(context.Background(), token))
+	assert.Equal(t, constant.UserStatusActive, repo.users[user.ID].Status)
+
+	_, _, err = userService.Login(context.Background(), "newuser", "password123", "127.0.0.1")
+	assert.NoError(t, err)
+
+	// Opening the link again is harmless
+	assert.NoError(t, userService.VerifyEmail(context.Background(), token))
+}
+
+func TestEmailVerification_InvalidTokens(t *testing.T) {
+	repo := NewMockUserRepository()
+	emailService := NewMockEmailService()
+	userService := newVerifyingUserService(repo, emailService)
+
+	user := registerPendingUser(t, userService)
+	token := emailService.sentToken(t)
+
+	assert.ErrorIs(t, userService.VerifyEmail(context.Background(), "garbage"), service.ErrInvalidVerificationToken)
+	assert.ErrorIs(t, userService.VerifyEmail(context.Background(), token+"x"), service.ErrInvalidVerificationToken)
+
+	// Signed by another secret
+	other := service.NewUserService(repo, nil, nil, nil, newTestRoleService(repo), emailService, newTestLoginAttemptService(repo), newTestAuditService(), service.UserServiceConfig{
+		RequireEmailVerification: true,
+		VerificationSecret:       "other-secret",
+		VerificationTokenExpiry:  time.Hour,
+	})
+	assert.ErrorIs(t, other.VerifyEmail(context.Background(), token), service.ErrInvalidVerificationToken)
+
+	// Tokens are bound to the email address they were sent to
+	repo.users[user.ID].Email = "changed@example.com"
+	assert.ErrorIs(t, userService.VerifyEmail(context.Background(), token), service.ErrInvalidVerificationToken)
+	assert.Equal(t, constant.UserStatusPending, repo.users[user.ID].Status)
+}
+
+func TestEmailVerification_EmptySecretAcceptsNoToken(t *testing.T) {
+	repo := NewMockUserRepository()
+	emailService := NewMockEmailService()
+	userService := service.NewUserService(repo, nil, nil, nil, newTestRoleService(repo), emailService, newTestLoginAttemptService(repo), newTestAuditService(), service.UserServiceConfig{
+		RequireEmailVerification: true,
+		VerificationTokenExpiry:  time.Hour,
+	})
+
+	user := registerPendingUser(t, userService)
+
+	assert.ErrorIs(t, userService.VerifyEmail(context.Background(), emailService.sentToken(t)), service.ErrInvalidVerificationToken)
+	assert.Equal(t, constant.UserStatusPending, repo.users[user.ID].Status)
+}
+
+func TestEmailVerification_ExpiredToken(t *testing.T) {
+	repo := NewMockUserRepository()
+	emailService := NewMockEmailService()
+	userService := service.NewUserService(repo, nil, nil, nil, newTestRoleService(repo), emailService, newTestLoginAttemptService(repo), newTestAuditService(), service.UserServiceConfig{
+		RequireEmailVerification: true,
+		VerificationSecret:       "test-secret",
+		VerificationTokenExpiry:  -time.Minute,
+	})
+
+	registerPendingUser(t, userService)
+	token := emailService.sentToken(t)
+
+	assert.ErrorIs(t, userService.VerifyEmail(context.Background(), token), service.ErrInvalidVerificationToken)
+}
+
+func TestEmailVerification_Resend(t *testing.T) {
+	repo := NewMockUserRepository()
+	emailService := NewMockEmailService()
+	userService := newVerifyingUserService(repo, emailService)
+
+	registerPendingUser(t, userService)
+	emailService.sentToken(t)
+
+	require.NoError(t, userService.ResendVerification(context.Background(), "new@example.com", "en"))
+	require.NoError(t, userService.VerifyEmail(context.Background(), emailService.sentToken(t)))
+
+	// Verified and unknown addresses are not told apart and get no email
+	assert.NoError(t, userService.ResendVerification(context.Background(), "new@example.com", "en"))
+	assert.NoError(t, userService.ResendVerification(context.Background(), "nobody@example.com", "en"))
+	assert.Empty(t, emailService.tokens)
+}
+
+func TestEmailVerification_DisabledRegistersActiveUsers(t *testing.T) {
+	repo := NewMockUserRepository()
+	emailService := NewMockEmailService()
+	userService := service.NewUserService(repo, nil, nil, nil, newTestRoleService(repo), emailService, newTestLoginAttemptService(repo), newTestAuditService(), service.UserServiceConfig{})
+
+	user := &entity.User{Username: "newuser", Email: "new@example.com"}
+	require.NoError(t, userService.Register(context.Background(), user, "en"))
+
+	assert.Equal(t, constant.UserStatusActive, user.Status)
+	assert.Empty(t, emailService.tokens)
+}
+
+func TestAuthHandler_VerifyEmail(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	repo := NewMockUserRepository()
+	emailService := NewMockEmailService()
+	userService := newVerifyingUserService(repo, emailService)
+	authHandler := handler.NewAuthHandler(userService, nil, nil, nil, nil, nil, nil, nil)
+
+	registerPendingUser(t, userService)
+	token := emailService.sentToken(t)
+
+	app := fiber.New()
+	app.Post("/auth/verify-email", authHandler.VerifyEmail)
+	app.Post("/auth/resend-verification", authHandler.ResendVerification)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, postJSON(t, app, "/auth/verify-email", `{}`))
+	assert.Equal(t, http.StatusBadRequest, postJSON(t, app, "/auth/verify-email", `{"token":"1.1.invalid"}`))
+	assert.Equal(t, http.StatusOK, postJSON(t, app, "/auth/verify-email", `{"token":"`+token+`"}`))
+	assert.Equal(t, http.StatusOK, postJSON(t, app, "/auth/resend-verification", `{"email":"nobody@example.com"}`))
+}
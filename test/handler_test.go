@@ -2,32 +2,47 @@ package handler_test
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"go-rest-api-template/internal/constant"
 	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/internal/domain/usecase"
 	"go-rest-api-template/internal/handler"
+	"go-rest-api-template/internal/service"
 	"go-rest-api-template/pkg/i18n"
+	"go-rest-api-template/pkg/query"
 	"go-rest-api-template/pkg/response"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // MockUserRepository is a mock implementation of UserRepository for testing
 type MockUserRepository struct {
-	users map[int]*entity.User
+	users           map[int]*entity.User
+	deleted         map[int]*entity.User // soft-deleted users, out of reach of the other lookups
+	passwordHistory map[int][]string     // newest first
 }
 
 func NewMockUserRepository() *MockUserRepository {
 	return &MockUserRepository{
-		users: make(map[int]*entity.User),
+		users:           make(map[int]*entity.User),
+		deleted:         make(map[int]*entity.User),
+		passwordHistory: make(map[int][]string),
 	}
 }
 
 func (m *MockUserRepository) Create(ctx context.Context, user *entity.User) error {
-	user.ID = len(m.users) + 1
+	user.ID = len(m.users) + len(m.deleted) + 1
 	m.users[user.ID] = user
 	return nil
 }
@@ -35,7 +50,7 @@ func (m *MockUserRepository) Create(ctx context.Context, user *entity.User) erro
 func (m *MockUserRepository) GetByID(ctx context.Context, id int) (*entity.User, error) {
 	user, exists := m.users[id]
 	if !exists {
-		return nil, errors.New("user not found")
+		return nil, sql.ErrNoRows
 	}
 	return user, nil
 }
@@ -46,7 +61,7 @@ func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*ent
 			return user, nil
 		}
 	}
-	return nil, errors.New("user not found")
+	return nil, sql.ErrNoRows
 }
 
 func (m *MockUserRepository) GetByUsername(ctx context.Context, username string) (*entity.User, error) {
@@ -55,7 +70,7 @@ func (m *MockUserRepository) GetByUsername(ctx context.Context, username string)
 			return user, nil
 		}
 	}
-	return nil, errors.New("user not found")
+	return nil, sql.ErrNoRows
 }
 
 func (m *MockUserRepository) Update(ctx context.Context, user *entity.User) error {
@@ -66,24 +81,104 @@ func (m *MockUserRepository) Update(ctx context.Context, user *entity.User) erro
 	return nil
 }
 
-func (m *MockUserRepository) Delete(ctx context.Context, id int) error {
-	if _, exists := m.users[id]; !exists {
+func (m *MockUserRepository) Delete(ctx context.Context, id int, deletedBy int) error {
+	user, exists := m.users[id]
+	if !exists {
 		return errors.New("user not found")
 	}
+	now := time.Now()
+	user.DeletedAt = &now
+	user.DeletedBy = &deletedBy
+	m.deleted[id] = user
 	delete(m.users, id)
 	return nil
 }
 
-func (m *MockUserRepository) GetAll(ctx context.Context, limit, offset int) ([]*entity.User, error) {
-	users := make([]*entity.User, 0, len(m.users))
-	for _, user := range m.users {
-		users = append(users, user)
+func (m *MockUserRepository) GetDeletedByID(ctx context.Context, id int) (*entity.User, error) {
+	user, exists := m.deleted[id]
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (m *MockUserRepository) Restore(ctx context.Context, id int, restoredBy int) error {
+	user, exists := m.deleted[id]
+	if !exists {
+		return nil
+	}
+	user.DeletedAt = nil
+	user.DeletedBy = nil
+	user.SetUpdatedBy(restoredBy)
+	m.users[id] = user
+	delete(m.deleted, id)
+	return nil
+}
+
+// GetPurgeable returns the deleted users deleted before deletedBefore, by id
+func (m *MockUserRepository) GetPurgeable(ctx context.Context, deletedBefore time.Time, limit int) ([]int, error) {
+	ids := []int{}
+	for id, user := range m.deleted {
+		if user.DeletedAt.Before(deletedBefore) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids[:min(limit, len(ids))], nil
+}
+
+// Purge forgets the user in both modes, anonymized rows are of no use to the tests
+func (m *MockUserRepository) Purge(ctx context.Context, id int, anonymize bool) error {
+	if _, exists := m.deleted[id]; !exists {
+		return sql.ErrNoRows
+	}
+	delete(m.deleted, id)
+	delete(m.passwordHistory, id)
+	return nil
+}
+
+// GetAll returns the users matching the status and search filters, ordered by id
+func (m *MockUserRepository) GetAll(ctx context.Context, filter repository.UserFilter, opts query.Options) ([]*entity.User, error) {
+	users := m.filter(filter)
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	if opts.After != nil {
+		for len(users) > 0 && users[0].ID <= opts.After.ID {
+			users = users[1:]
+		}
+	}
+	if offset := opts.Offset(); offset < len(users) {
+		users = users[offset:]
+	} else {
+		users = nil
+	}
+	if len(users) > opts.Limit {
+		users = users[:opts.Limit]
 	}
 	return users, nil
 }
 
-func (m *MockUserRepository) GetCount(ctx context.Context) (int, error) {
-	return len(m.users), nil
+func (m *MockUserRepository) GetCount(ctx context.Context, filter repository.UserFilter) (int, error) {
+	return len(m.filter(filter)), nil
+}
+
+func (m *MockUserRepository) filter(filter repository.UserFilter) []*entity.User {
+	source := m.users
+	if filter.Deleted {
+		source = m.deleted
+	}
+
+	users := make([]*entity.User, 0, len(source))
+	for _, user := range source {
+		if filter.Status != "" && user.Status != filter.Status {
+			continue
+		}
+		if filter.Search != "" && !strings.Contains(user.Username, filter.Search) && !strings.Contains(user.Email, filter.Search) {
+			continue
+		}
+		users = append(users, user)
+	}
+	return users
 }
 
 func (m *MockUserRepository) GetByVerificationToken(ctx context.Context, token string) (*entity.User, error) {
@@ -94,6 +189,63 @@ func (m *MockUserRepository) UpdateVerificationToken(ctx context.Context, user *
 	return errors.New("not implemented")
 }
 
+func (m *MockUserRepository) GetByPasswordResetToken(ctx context.Context, tokenHash string) (*entity.User, error) {
+	for _, user := range m.users {
+		if user.PasswordResetToken != nil && *user.PasswordResetToken == tokenHash {
+			return user, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MockUserRepository) UpdateLoginAttempts(ctx context.Context, user *entity.User) error {
+	if _, exists := m.users[user.ID]; !exists {
+		return errors.New("user not found")
+	}
+	m.users[user.ID].FailedLoginAttempts = user.FailedLoginAttempts
+	m.users[user.ID].LockedUntil = user.LockedUntil
+	return nil
+}
+
+func (m *MockUserRepository) IncrementFailedLogins(ctx context.Context, user *entity.User, maxAttempts int, lockout time.Duration) error {
+	stored, exists := m.users[user.ID]
+	if !exists {
+		return errors.New("user not found")
+	}
+	stored.RegisterFailedLogin(maxAttempts, lockout)
+	user.FailedLoginAttempts = stored.FailedLoginAttempts
+	user.LockedUntil = stored.LockedUntil
+	return nil
+}
+
+func (m *MockUserRepository) UpdatePasswordHash(ctx context.Context, user *entity.User) error {
+	if _, exists := m.users[user.ID]; !exists {
+		return errors.New("user not found")
+	}
+	m.users[user.ID].PasswordHash = user.PasswordHash
+	return nil
+}
+
+func (m *MockUserRepository) GetPasswordHistory(ctx context.Context, userID, limit int) ([]string, error) {
+	history := m.passwordHistory[userID]
+	return history[:min(limit, len(history))], nil
+}
+
+func (m *MockUserRepository) AddPasswordHistory(ctx context.Context, userID int, passwordHash string, keep int) error {
+	history := append([]string{passwordHash}, m.passwordHistory[userID]...)
+	m.passwordHistory[userID] = history[:min(keep, len(history))]
+	return nil
+}
+
+func (m *MockUserRepository) UpdatePasswordResetToken(ctx context.Context, user *entity.User) error {
+	if _, exists := m.users[user.ID]; !exists {
+		return errors.New("user not found")
+	}
+	m.users[user.ID].PasswordResetToken = user.PasswordResetToken
+	m.users[user.ID].PasswordResetExpiresAt = user.PasswordResetExpiresAt
+	return nil
+}
+
 // Add test user to mock repository
 func (m *MockUserRepository) AddTestUser(id int, username, email, status string) {
 	m.users[id] = &entity.User{
@@ -104,6 +256,28 @@ func (m *MockUserRepository) AddTestUser(id int, username, email, status string)
 	}
 }
 
+// newTestUserHandler builds a UserHandler backed by the real user service
+func newTestUserHandler(repo *MockUserRepository) *handler.UserHandler {
+	return newTestUserHandlerWithMail(repo, NewMockEmailService())
+}
+
+// newTestUserHandlerWithMail builds a UserHandler that sends emails through emailService
+func newTestUserHandlerWithMail(repo *MockUserRepository, emailService service.EmailService) *handler.UserHandler {
+	return handler.NewUserHandler(newTestUserService(repo, emailService, service.UserServiceConfig{ResetTokenExpiry: time.Hour}))
+}
+
+// newTestUserService builds the user service with working token and session services,
+// which password changes need to log the user out
+func newTestUserService(repo *MockUserRepository, emailService service.EmailService, config service.UserServiceConfig) usecase.UserUsecase {
+	refreshRepo := NewMockRefreshTokenRepository()
+	sessionService := service.NewSessionService(NewMockSessionRepository(), refreshRepo, 24)
+	return service.NewUserService(repo, newTestJWTService(), service.NewRefreshTokenService(refreshRepo, 24), sessionService, newTestRoleService(repo), emailService, newTestLoginAttemptService(repo), newTestAuditService(), config)
+}
+
+func newTestRoleService(repo *MockUserRepository) service.RoleService {
+	return service.NewRoleService(NewMockRoleRepository(), repo)
+}
+
 // createTestResponseHelper creates a response helper for testing with minimal i18n setup
 func createTestResponseHelper() *response.I18nResponseHelper {
 	// Create a simple i18n manager for testing
@@ -150,7 +324,7 @@ func TestUserHandler_GetUserByID(t *testing.T) {
 	// Setup mock repository with test data
 	mockRepo := NewMockUserRepository()
 	mockRepo.AddTestUser(1, "testuser", "test@example.com", "active")
-	userHandler := handler.NewUserHandler(mockRepo)
+	userHandler := newTestUserHandler(mockRepo)
 
 	app := fiber.New()
 	app.Get("/users/:id", userHandler.GetUserByID)
@@ -172,7 +346,7 @@ func TestUserHandler_GetAllUsers(t *testing.T) {
 
 	// Setup mock repository
 	mockRepo := NewMockUserRepository()
-	userHandler := handler.NewUserHandler(mockRepo)
+	userHandler := newTestUserHandler(mockRepo)
 
 	app := fiber.New()
 	app.Get("/users", userHandler.GetAllUsers)
@@ -187,3 +361,278 @@ func TestUserHandler_GetAllUsers(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 }
+
+func TestUserHandler_GetUserByID_NotFound(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	userHandler := newTestUserHandler(NewMockUserRepository())
+
+	app := fiber.New()
+	app.Get("/users/:id", userHandler.GetUserByID)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/users/42", nil))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestUserHandler_UpdateUser(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	mockRepo := NewMockUserRepository()
+	mockRepo.AddTestUser(1, "testuser", "test@example.com", "active")
+	mockRepo.AddTestUser(2, "otheruser", "other@example.com", "active")
+	userHandler := newTestUserHandler(mockRepo)
+
+	// An admin, who may change the email without verification
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("permissions", entity.NewPermissionSet([]string{constant.PermissionUsersUpdate, constant.PermissionUsersManage}))
+		return c.Next()
+	})
+	app.Put("/users/:id", userHandler.UpdateUser)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{"updates email", "/users/1", `{"email":"new@example.com"}`, http.StatusOK},
+		{"missing user", "/users/42", `{"email":"x@example.com"}`, http.StatusNotFound},
+		{"duplicate username", "/users/1", `{"username":"otheruser"}`, http.StatusConflict},
+		{"duplicate email", "/users/1", `{"email":"other@example.com"}`, http.StatusConflict},
+		{"invalid email", "/users/1", `{"email":"not-an-email"}`, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+
+	assert.Equal(t, "new@example.com", mockRepo.users[1].Email)
+	assert.Equal(t, "testuser", mockRepo.users[1].Username)
+}
+
+func TestUserHandler_UpdateUser_ManagedFields(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	mockRepo := NewMockUserRepository()
+	mockRepo.AddTestUser(1, "testuser", "test@example.com", "suspended")
+	userHandler := newTestUserHandler(mockRepo)
+
+	// The owner holds the permissions of the user role, without users:manage
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", 1)
+		c.Locals("permissions", entity.NewPermissionSet([]string{constant.PermissionUsersRead, constant.PermissionUsersUpdate}))
+		return c.Next()
+	})
+	app.Put("/users/:id", userHandler.UpdateUser)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"reactivates own account", `{"status":"active"}`, http.StatusForbidden},
+		{"sets password without the current one", `{"password":"newpassword"}`, http.StatusForbidden},
+		{"changes email without verification", `{"email":"new@example.com"}`, http.StatusForbidden},
+		{"changes username", `{"username":"renamed"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/users/1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+
+	assert.Equal(t, "suspended", mockRepo.users[1].Status)
+	assert.Equal(t, "test@example.com", mockRepo.users[1].Email)
+	assert.Equal(t, "renamed", mockRepo.users[1].Username)
+}
+
+func TestUserHandler_DeleteUser(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	mockRepo := NewMockUserRepository()
+	mockRepo.AddTestUser(1, "testuser", "test@example.com", "active")
+	userHandler := newTestUserHandler(mockRepo)
+
+	app := fiber.New()
+	app.Delete("/users/:id", userHandler.DeleteUser)
+
+	resp, err := app.Test(httptest.NewRequest("DELETE", "/users/1", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("DELETE", "/users/1", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestUserService_DeleteUser_LogsOutEverywhere(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", "active")
+
+	refreshRepo := NewMockRefreshTokenRepository()
+	jwtService := newTestJWTService()
+	sessionService := service.NewSessionService(NewMockSessionRepository(), refreshRepo, 24)
+	userService := service.NewUserService(repo, jwtService, service.NewRefreshTokenService(refreshRepo, 24), sessionService, newTestRoleService(repo),
+		NewMockEmailService(), newTestLoginAttemptService(repo), newTestAuditService(), service.UserServiceConfig{})
+
+	token, err := jwtService.GeneratePrivateToken(&entity.ApiKey{ID: 1, Name: "test-api-key"}, repo.users[1], "")
+	require.NoError(t, err)
+	_, err = sessionService.Start(ctx, 1, 1, "127.0.0.1", "test-agent")
+	require.NoError(t, err)
+
+	// iat has second precision, tokens of the revocation second stay valid
+	time.Sleep(time.Second)
+	require.NoError(t, userService.DeleteUser(ctx, 1))
+
+	_, _, _, err = jwtService.ValidatePrivateToken(token)
+	assert.Error(t, err)
+	sessions, err := sessionService.GetActiveSessions(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestUserHandler_ChangePassword(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	mockRepo := NewMockUserRepository()
+	mockRepo.AddTestUser(1, "testuser", "test@example.com", "active")
+	assert.NoError(t, mockRepo.users[1].HashPassword("oldpassword"))
+	userHandler := newTestUserHandler(mockRepo)
+
+	app := fiber.New()
+	app.Put("/users/:id/password", userHandler.ChangePassword)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"wrong current password", `{"current_password":"wrongpassword","new_password":"newpassword"}`, http.StatusBadRequest},
+		{"too short", `{"current_password":"oldpassword","new_password":"short"}`, http.StatusUnprocessableEntity},
+		{"changes password", `{"current_password":"oldpassword","new_password":"newpassword"}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", "/users/1/password", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			// Hashing and revoking can outlast the default test timeout
+			resp, err := app.Test(req, -1)
+
+			require.NoError(t, err)
+			require.NotNil(t, resp)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+
+	assert.True(t, mockRepo.users[1].CheckPassword("newpassword"))
+}
+
+func TestUserHandler_ForgotPassword_UnknownEmail(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	userHandler := newTestUserHandler(NewMockUserRepository())
+
+	app := fiber.New()
+	app.Post("/users/forgot-password", userHandler.ForgotPassword)
+
+	req := httptest.NewRequest("POST", "/users/forgot-password", strings.NewReader(`{"email":"nobody@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestUserHandler_GetAllUsers_Pagination(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	mockRepo := NewMockUserRepository()
+	mockRepo.AddTestUser(1, "alice", "alice@example.com", "active")
+	mockRepo.AddTestUser(2, "bob", "bob@example.com", "active")
+	mockRepo.AddTestUser(3, "carol", "carol@example.com", "inactive")
+	mockRepo.AddTestUser(4, "dave", "dave@example.com", "active")
+	userHandler := newTestUserHandler(mockRepo)
+
+	app := fiber.New()
+	app.Get("/users", userHandler.GetAllUsers)
+
+	type page struct {
+		Data []struct {
+			ID int `json:"id"`
+		} `json:"data"`
+		Meta struct {
+			Pagination response.Pagination `json:"pagination"`
+		} `json:"meta"`
+	}
+	get := func(t *testing.T, target string) (int, page) {
+		resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+		assert.NoError(t, err)
+		var body page
+		if resp.StatusCode == http.StatusOK {
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		}
+		return resp.StatusCode, body
+	}
+
+	t.Run("page and limit", func(t *testing.T) {
+		status, body := get(t, "/users?page=2&limit=3&sort=id")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Len(t, body.Data, 1)
+		assert.Equal(t, response.Pagination{Page: 2, Limit: 3, Total: 4, TotalPages: 2}, body.Meta.Pagination)
+	})
+
+	t.Run("status filter", func(t *testing.T) {
+		status, body := get(t, "/users?status=active&sort=id")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Len(t, body.Data, 3)
+		assert.Equal(t, 3, body.Meta.Pagination.Total)
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		status, first := get(t, "/users?limit=2&sort=id")
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotEmpty(t, first.Meta.Pagination.NextCursor)
+
+		status, second := get(t, "/users?limit=2&sort=id&after="+first.Meta.Pagination.NextCursor)
+		assert.Equal(t, http.StatusOK, status)
+		if assert.Len(t, second.Data, 2) {
+			assert.Equal(t, 3, second.Data[0].ID)
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, target := range []string{
+			"/users?sort=password_hash",
+			"/users?limit=1000",
+			"/users?status=unknown",
+			"/users?created_from=yesterday",
+			"/users?after=not-a-cursor",
+		} {
+			status, _ := get(t, target)
+			assert.Equal(t, http.StatusUnprocessableEntity, status, target)
+		}
+	})
+}
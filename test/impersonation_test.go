@@ -0,0 +1,276 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/handler"
+	"go-rest-api-template/internal/middleware"
+	"go-rest-api-template/internal/repository"
+	"go-rest-api-template/internal/routes"
+	"go-rest-api-template/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpersonationService_Impersonate(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "support", "support@example.com", constant.UserStatusActive)
+	repo.AddTestUser(2, "alice", "alice@example.com", constant.UserStatusActive)
+	repo.AddTestUser(3, "boss", "boss@example.com", constant.UserStatusActive)
+	repo.AddTestUser(4, "carol", "carol@example.com", "suspended")
+	repo.AddTestUser(5, "root", "root@example.com", constant.UserStatusActive)
+
+	roleService := service.NewRoleService(NewMockRoleRepository(), repo)
+	require.NoError(t, roleService.AssignRole(ctx, 2, constant.RoleUser, nil))
+	require.NoError(t, roleService.AssignRole(ctx, 3, constant.RoleAdmin, nil))
+
+	jwtService := newTestJWTService()
+	auditRepo := NewMockAuditLogRepository()
+	impersonationService := service.NewImpersonationService(repo, roleService, jwtService, service.NewAuditService(auditRepo), service.ImpersonationConfig{
+		TokenExpiry:  15 * time.Minute,
+		AdminUserIDs: []int{5},
+	})
+	apiKey := &entity.ApiKey{ID: 1, Name: "test-api-key"}
+
+	token, err := impersonationService.Impersonate(ctx, 1, 2, apiKey, "ticket 42")
+	require.NoError(t, err)
+	assert.Equal(t, 15*60, token.ExpiresIn)
+
+	claims, _, user, err := jwtService.ValidatePrivateToken(token.Token)
+	require.NoError(t, err)
+	assert.Equal(t, 2, user.ID)
+	assert.Equal(t, []string{constant.RoleUser}, claims.Roles)
+	assert.Empty(t, claims.SessionID)
+	require.NotNil(t, claims.Act)
+	assert.Equal(t, service.ImpersonationActor{UserID: 1, Username: "support"}, *claims.Act)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), claims.ExpiresAt.Time, 5*time.Second)
+
+	issued := auditRepo.byAction(constant.AuditActionImpersonated)
+	require.Len(t, issued, 1)
+	assert.Equal(t, 1, *issued[0].ActorUserID)
+	assert.Equal(t, 2, *issued[0].EntityID)
+	assert.Equal(t, "ticket 42", issued[0].Details["reason"])
+
+	// Admins, by role or config, inactive users and the admin themselves are out of reach
+	for _, userID := range []int{1, 3, 4, 5} {
+		_, err = impersonationService.Impersonate(ctx, 1, userID, apiKey, "")
+		assert.ErrorIs(t, err, service.ErrImpersonationNotAllowed, "user %d", userID)
+	}
+	_, err = impersonationService.Impersonate(ctx, 1, 99, apiKey, "")
+	assert.ErrorIs(t, err, service.ErrUserNotFound)
+}
+
+func TestImpersonation_TokenIsAuditedAndRestricted(t *testing.T) {
+	setupTestGlobalHelpers()
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "support", "support@example.com", constant.UserStatusActive)
+	repo.AddTestUser(2, "alice", "alice@example.com", constant.UserStatusActive)
+
+	jwtService := newTestJWTService()
+	auditRepo := NewMockAuditLogRepository()
+	auditService := service.NewAuditService(auditRepo)
+	impersonationService := service.NewImpersonationService(repo, service.NewRoleService(NewMockRoleRepository(), repo), jwtService, auditService, service.ImpersonationConfig{
+		TokenExpiry: 15 * time.Minute,
+	})
+	impersonationHandler := handler.NewImpersonationHandler(impersonationService)
+
+	// The admin obtains the token
+	admin := fiber.New()
+	admin.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", 1)
+		c.Locals("api_key_id", 1)
+		c.Locals("api_key_name", "test-api-key")
+		return c.Next()
+	})
+	admin.Post("/admin/users/:id/impersonate", impersonationHandler.Impersonate)
+
+	status, data := requestJSONData(t, admin, "POST", "/admin/users/2/impersonate", `{"reason":"ticket 42"}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "alice", data["user"].(map[string]interface{})["username"])
+	impersonationToken := data["token"].(string)
+
+	status, _ = requestJSONData(t, admin, "POST", "/admin/users/1/impersonate", "")
+	assert.Equal(t, http.StatusForbidden, status)
+	status, _ = requestJSONData(t, admin, "POST", "/admin/users/2/impersonate", `{"reason":"`+strings.Repeat("x", 501)+`"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+
+	// ...and uses it on the private endpoints
+	app := fiber.New()
+	app.Use(middleware.PrivateMiddleware(&MockApiKeyService{}, jwtService, nil), middleware.ImpersonationAuditMiddleware(auditService))
+	app.Get("/me", func(c *fiber.Ctx) error {
+		impersonatorID, _ := middleware.NewContextHelper().GetImpersonatorID(c)
+		auditService.Record(c.Context(), service.AuditEntry{Action: constant.AuditActionProfileUpdated, EntityType: constant.AuditEntityUser, EntityID: 2})
+		return c.JSON(fiber.Map{"data": fiber.Map{"impersonator_id": impersonatorID}})
+	})
+	app.Post("/auth/mfa/disable", middleware.DenyImpersonation(), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	request := func(method, path, token string) (int, map[string]interface{}) {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-API-Key", "test-key")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req, 5000)
+		require.NoError(t, err)
+		var envelope struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&envelope)
+		return resp.StatusCode, envelope.Data
+	}
+
+	status, data = request("GET", "/me", impersonationToken)
+	require.Equal(t, http.StatusOK, status)
+	assert.EqualValues(t, 1, data["impersonator_id"])
+	status, _ = request("POST", "/auth/mfa/disable", impersonationToken)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	// Everything done with the token names the admin
+	requests := auditRepo.byAction(constant.AuditActionImpersonatedReq)
+	require.Len(t, requests, 2)
+	assert.Equal(t, 1, *requests[0].ActorUserID)
+	assert.Equal(t, 2, *requests[0].EntityID)
+	assert.Equal(t, "/me", requests[0].Details["path"])
+	assert.Equal(t, http.StatusOK, requests[0].Details["status"])
+	assert.Equal(t, "POST", requests[1].Details["method"])
+	assert.Equal(t, http.StatusForbidden, requests[1].Details["status"])
+
+	updated := auditRepo.byAction(constant.AuditActionProfileUpdated)
+	require.Len(t, updated, 1)
+	assert.Equal(t, 2, *updated[0].ActorUserID)
+	assert.Equal(t, 1, updated[0].Details["impersonator_id"])
+
+	// The user's own token is neither restricted nor audited as impersonation
+	ownToken, err := jwtService.GeneratePrivateToken(&entity.ApiKey{ID: 1, Name: "test-api-key"}, repo.users[2], "")
+	require.NoError(t, err)
+	status, data = request("GET", "/me", ownToken)
+	require.Equal(t, http.StatusOK, status)
+	assert.EqualValues(t, 0, data["impersonator_id"])
+	status, _ = request("POST", "/auth/mfa/disable", ownToken)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Len(t, auditRepo.byAction(constant.AuditActionImpersonatedReq), 2)
+}
+
+func TestImpersonation_CannotLogoutAllDevices(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	jwtService := newTestJWTService()
+	sessionRepo := NewMockSessionRepository()
+	sessionService := service.NewSessionService(sessionRepo, NewMockRefreshTokenRepository(), 24)
+	authHandler := handler.NewAuthHandler(nil, jwtService, nil, nil, sessionService, nil, nil, nil)
+
+	session, err := sessionService.Start(context.Background(), 2, 1, "203.0.113.7", "test")
+	require.NoError(t, err)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("jwt_claims", &service.PrivateJWTClaims{UserID: 2})
+		c.Locals("impersonator_id", 1)
+		return c.Next()
+	})
+	app.Post("/auth/logout", authHandler.Logout)
+
+	status, _ := requestJSONData(t, app, "POST", "/auth/logout", `{"all_devices":true}`)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	// The real session of the user is still alive
+	_, err = sessionService.Validate(context.Background(), session.SessionToken)
+	assert.NoError(t, err)
+}
+
+// scopedApiKeyService hands out an API key holding every scope, for tests going through the real routes
+type scopedApiKeyService struct {
+	MockApiKeyService
+}
+
+func (m *scopedApiKeyService) ValidateApiKey(ctx context.Context, key string) (*entity.ApiKey, error) {
+	return &entity.ApiKey{ID: 1, Name: "test-api-key", Status: "active", Scopes: []string{"*"}}, nil
+}
+
+func TestImpersonation_RoutesDenyAccountChanges(t *testing.T) {
+	setupTestGlobalHelpers()
+	ctx := context.Background()
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "support", "support@example.com", constant.UserStatusActive)
+	repo.AddTestUser(2, "alice", "alice@example.com", constant.UserStatusActive)
+
+	roleService := service.NewRoleService(NewMockRoleRepository(), repo)
+	require.NoError(t, roleService.AssignRole(ctx, 2, constant.RoleUser, nil))
+
+	jwtService := newTestJWTService()
+	refreshRepo := NewMockRefreshTokenRepository()
+	sessionService := service.NewSessionService(NewMockSessionRepository(), refreshRepo, 24)
+	auditService := service.NewAuditService(NewMockAuditLogRepository())
+	userService := service.NewUserService(repo, jwtService, service.NewRefreshTokenService(refreshRepo, 24), sessionService, roleService,
+		NewMockEmailService(), newTestLoginAttemptService(repo), auditService, service.UserServiceConfig{VerificationSecret: "test-secret"})
+	impersonationService := service.NewImpersonationService(repo, roleService, jwtService, auditService, service.ImpersonationConfig{
+		TokenExpiry: 15 * time.Minute,
+	})
+
+	app := fiber.New()
+	config := &routes.RouteConfig{
+		UserHandler:    handler.NewUserHandler(userService),
+		SessionHandler: handler.NewSessionHandler(sessionService),
+		JWTService:     jwtService,
+		ApiKeyService:  &scopedApiKeyService{},
+		SessionService: sessionService,
+		RateLimitService: service.NewRateLimitService(repository.NewInMemoryRateLimitRepository(), service.RateLimitConfig{
+			DefaultPerSecond: 100,
+			DefaultBurst:     100,
+		}),
+		RoleService:  roleService,
+		AuditService: auditService,
+	}
+	routes.SetupAuthRoutes(app, config)
+	routes.SetupUserRoutes(app, config)
+
+	request := func(method, path, token, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", "test-key")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		return resp.StatusCode
+	}
+
+	apiKey := &entity.ApiKey{ID: 1, Name: "test-api-key"}
+	_, err := sessionService.Start(ctx, 2, 1, "203.0.113.7", "test")
+	require.NoError(t, err)
+	other, err := sessionService.Start(ctx, 2, 1, "203.0.113.8", "test")
+	require.NoError(t, err)
+
+	token, err := impersonationService.Impersonate(ctx, 1, 2, apiKey, "ticket 42")
+	require.NoError(t, err)
+	impersonationToken := token.Token
+
+	assert.Equal(t, http.StatusForbidden, request("PATCH", "/api/v1/private/me", impersonationToken, `{"email":"mallory@example.com"}`))
+	assert.Equal(t, http.StatusForbidden, request("DELETE", "/api/v1/users/me/sessions/"+strconv.Itoa(other.ID), impersonationToken, ""))
+	assert.Equal(t, http.StatusForbidden, request("DELETE", "/api/v1/users/2", impersonationToken, ""))
+
+	// Nothing changed for the user
+	assert.Nil(t, repo.users[2].PendingEmail)
+	sessions, err := sessionService.GetActiveSessions(ctx, 2)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	assert.Nil(t, repo.users[2].DeletedAt)
+
+	// The user's own token still reaches the same routes
+	ownToken, err := jwtService.GeneratePrivateToken(apiKey, repo.users[2], "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, request("PATCH", "/api/v1/private/me", ownToken, `{"full_name":"Alice"}`))
+	assert.Equal(t, http.StatusOK, request("DELETE", "/api/v1/users/me/sessions/"+strconv.Itoa(other.ID), ownToken, ""))
+	assert.Equal(t, http.StatusOK, request("DELETE", "/api/v1/users/2", ownToken, ""))
+}
@@ -0,0 +1,83 @@
+package handler_test
+
+import (
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/middleware"
+	"go-rest-api-template/pkg/ipfilter"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPFilter_Contains(t *testing.T) {
+	list, err := ipfilter.Parse("203.0.113.7, 198.51.100.0/24,2001:db8::/32")
+	require.NoError(t, err)
+
+	assert.True(t, list.Contains("203.0.113.7"))
+	assert.False(t, list.Contains("203.0.113.8"))
+	assert.True(t, list.Contains("198.51.100.42"))
+	assert.True(t, list.Contains("::ffff:198.51.100.42")) // IPv4-mapped IPv6
+	assert.True(t, list.Contains("2001:db8:1::5"))
+	assert.False(t, list.Contains("2001:db9::5"))
+	assert.False(t, list.Contains("not-an-ip"))
+
+	_, err = ipfilter.Parse("10.0.0.1, 10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = ipfilter.Parse("10.0.0.300")
+	assert.Error(t, err)
+}
+
+func TestApiKey_IsIPAllowed(t *testing.T) {
+	whitelist := "10.0.0.0/8, 192.168.1.10"
+	denylist := "10.0.5.0/24"
+	apiKey := &entity.ApiKey{IPWhitelist: &whitelist, IPDenylist: &denylist}
+
+	assert.True(t, apiKey.IsIPAllowed("10.1.2.3"))
+	assert.True(t, apiKey.IsIPAllowed("192.168.1.10"))
+	assert.False(t, apiKey.IsIPAllowed("192.168.1.11"))
+	assert.False(t, apiKey.IsIPAllowed("10.0.5.9")) // denylist wins
+
+	// No lists means every address is allowed
+	assert.True(t, (&entity.ApiKey{}).IsIPAllowed("203.0.113.7"))
+
+	// A whitelist that cannot be parsed allows nobody
+	broken := "10.0.0.0/99"
+	assert.False(t, (&entity.ApiKey{IPWhitelist: &broken}).IsIPAllowed("10.0.0.1"))
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	// app.Test connections come from 0.0.0.0
+	newApp := func(trusted string) *fiber.App {
+		trustedProxies, err := ipfilter.Parse(trusted)
+		require.NoError(t, err)
+		app := fiber.New()
+		app.Use(middleware.ClientIPMiddleware(trustedProxies))
+		app.Get("/", func(c *fiber.Ctx) error {
+			return c.SendString(middleware.GetClientIP(c))
+		})
+		return app
+	}
+	clientIP := func(app *fiber.App, forwardedFor string) string {
+		req := httptest.NewRequest("GET", "/", nil)
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	// Untrusted peers cannot set their address
+	assert.Equal(t, "0.0.0.0", clientIP(newApp(""), "198.51.100.7"))
+
+	// Behind trusted proxies the rightmost untrusted entry is the client, forged entries on the left are ignored
+	app := newApp("0.0.0.0, 10.0.0.0/8")
+	assert.Equal(t, "198.51.100.7", clientIP(app, "1.2.3.4, 198.51.100.7, 10.0.0.5"))
+	assert.Equal(t, "198.51.100.7", clientIP(app, "198.51.100.7"))
+	assert.Equal(t, "0.0.0.0", clientIP(app, ""))
+}
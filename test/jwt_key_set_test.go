@@ -0,0 +1,103 @@
+package handler_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/repository"
+	"go-rest-api-template/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeECKeyPair writes a P-256 private and public key pair as PEM files
+func writeECKeyPair(t *testing.T, dir, name string) (string, string) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	privateDER, err := x509.MarshalECPrivateKey(privateKey)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+
+	privatePath := filepath.Join(dir, name+".pem")
+	publicPath := filepath.Join(dir, name+".pub.pem")
+	require.NoError(t, os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: privateDER}), 0600))
+	require.NoError(t, os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644))
+
+	return privatePath, publicPath
+}
+
+func TestJWTKeySet_RotationKeepsOldTokensValid(t *testing.T) {
+	dir := t.TempDir()
+	oldPrivate, oldPublic := writeECKeyPair(t, dir, "old")
+	newPrivate, _ := writeECKeyPair(t, dir, "new")
+
+	apiKey := &entity.ApiKey{ID: 1, Name: "test-api-key"}
+	user := &entity.User{ID: 7, Username: "testuser", Email: "test@example.com"}
+	revocationRepo := repository.NewInMemoryTokenRevocationRepository()
+
+	// Token signed before rotation
+	oldKeySet, err := service.LoadJWTKeySet("ES256", "old", []service.JWTKeyConfig{
+		{KeyID: "old", PrivateKeyFile: oldPrivate},
+	})
+	require.NoError(t, err)
+	oldService := service.NewJWTService(oldKeySet, 2, 24, &MockApiKeyService{}, revocationRepo)
+	oldToken, err := oldService.GeneratePrivateToken(apiKey, user, "")
+	require.NoError(t, err)
+
+	// After rotation the old key is kept for verification only
+	newKeySet, err := service.LoadJWTKeySet("ES256", "new", []service.JWTKeyConfig{
+		{KeyID: "new", PrivateKeyFile: newPrivate},
+		{KeyID: "old", PublicKeyFile: oldPublic},
+	})
+	require.NoError(t, err)
+	newService := service.NewJWTService(newKeySet, 2, 24, &MockApiKeyService{}, revocationRepo)
+
+	_, _, _, err = newService.ValidatePrivateToken(oldToken)
+	assert.NoError(t, err)
+
+	newToken, err := newService.GeneratePrivateToken(apiKey, user, "")
+	require.NoError(t, err)
+	_, _, _, err = newService.ValidatePrivateToken(newToken)
+	assert.NoError(t, err)
+
+	// The old key set does not know the new key
+	_, _, _, err = oldService.ValidatePrivateToken(newToken)
+	assert.Error(t, err)
+
+	jwks := newService.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, "new", jwks.Keys[0].Kid)
+	assert.Equal(t, "EC", jwks.Keys[0].Kty)
+	assert.Equal(t, "P-256", jwks.Keys[0].Crv)
+	assert.Equal(t, "ES256", jwks.Keys[1].Alg)
+}
+
+func TestJWTKeySet_HMACTokensRejected(t *testing.T) {
+	dir := t.TempDir()
+	privatePath, _ := writeECKeyPair(t, dir, "current")
+
+	keySet, err := service.LoadJWTKeySet("ES256", "current", []service.JWTKeyConfig{
+		{KeyID: "current", PrivateKeyFile: privatePath},
+	})
+	require.NoError(t, err)
+	jwtService := service.NewJWTService(keySet, 2, 24, &MockApiKeyService{}, repository.NewInMemoryTokenRevocationRepository())
+
+	// A token signed with a shared secret must not pass asymmetric verification
+	hmacToken, err := newTestJWTService().GeneratePublicToken(&entity.ApiKey{ID: 1, Name: "test-api-key"})
+	require.NoError(t, err)
+	_, _, err = jwtService.ValidatePublicToken(hmacToken)
+	assert.Error(t, err)
+
+	// HMAC key sets never publish their secret
+	assert.Empty(t, newTestJWTService().JWKS().Keys)
+}
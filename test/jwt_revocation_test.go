@@ -0,0 +1,110 @@
+package handler_test
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/repository"
+	"go-rest-api-template/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockApiKeyService is a minimal ApiKeyService for service level tests
+type MockApiKeyService struct{}
+
+func (m *MockApiKeyService) ValidateApiKey(ctx context.Context, key string) (*entity.ApiKey, error) {
+	return &entity.ApiKey{ID: 1, Name: "test-api-key", Status: "active"}, nil
+}
+
+func (m *MockApiKeyService) GetApiKeyByID(ctx context.Context, id int) (*entity.ApiKey, error) {
+	return &entity.ApiKey{ID: id, Name: "test-api-key", Status: "active"}, nil
+}
+
+func (m *MockApiKeyService) GetAllApiKeys(ctx context.Context, limit, offset int) ([]*entity.ApiKey, error) {
+	return nil, nil
+}
+
+func (m *MockApiKeyService) GetActiveApiKeys(ctx context.Context, limit, offset int) ([]*entity.ApiKey, error) {
+	return nil, nil
+}
+
+func (m *MockApiKeyService) GetApiKeyCount(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *MockApiKeyService) CreateApiKey(ctx context.Context, apiKey *entity.ApiKey) error {
+	return nil
+}
+
+func (m *MockApiKeyService) UpdateApiKey(ctx context.Context, apiKey *entity.ApiKey) error {
+	return nil
+}
+
+func (m *MockApiKeyService) RotateApiKey(ctx context.Context, id int, gracePeriod time.Duration, updatedBy *int) (*entity.ApiKey, error) {
+	return &entity.ApiKey{ID: id, Name: "test-api-key"}, nil
+}
+
+func (m *MockApiKeyService) RevokeApiKey(ctx context.Context, id int, updatedBy *int) error {
+	return nil
+}
+
+func (m *MockApiKeyService) HashPlaintextKeys(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (m *MockApiKeyService) LogApiKeyAccess(ctx context.Context, id int) error {
+	return nil
+}
+
+func newTestJWTService() service.JWTService {
+	return service.NewJWTService(service.NewHMACKeySet("test-secret"), 2, 24, &MockApiKeyService{}, repository.NewInMemoryTokenRevocationRepository())
+}
+
+func TestJWTService_RevokeToken(t *testing.T) {
+	jwtService := newTestJWTService()
+	apiKey := &entity.ApiKey{ID: 1, Name: "test-api-key"}
+	user := &entity.User{ID: 7, Username: "testuser", Email: "test@example.com"}
+
+	token, err := jwtService.GeneratePrivateToken(apiKey, user, "")
+	require.NoError(t, err)
+
+	claims, _, _, err := jwtService.ValidatePrivateToken(token)
+	require.NoError(t, err)
+	assert.NotEmpty(t, claims.ID)
+
+	require.NoError(t, jwtService.RevokeToken(context.Background(), claims))
+
+	_, _, _, err = jwtService.ValidatePrivateToken(token)
+	assert.Error(t, err)
+}
+
+func TestJWTService_RevokeAllUserTokens(t *testing.T) {
+	jwtService := newTestJWTService()
+	apiKey := &entity.ApiKey{ID: 1, Name: "test-api-key"}
+	user := &entity.User{ID: 7, Username: "testuser", Email: "test@example.com"}
+	other := &entity.User{ID: 8, Username: "otheruser", Email: "other@example.com"}
+
+	token, err := jwtService.GeneratePrivateToken(apiKey, user, "")
+	require.NoError(t, err)
+	otherToken, err := jwtService.GeneratePrivateToken(apiKey, other, "")
+	require.NoError(t, err)
+
+	// iat has second precision, tokens of the revocation second stay valid
+	time.Sleep(time.Second)
+	require.NoError(t, jwtService.RevokeAllUserTokens(context.Background(), user.ID))
+
+	_, _, _, err = jwtService.ValidatePrivateToken(token)
+	assert.Error(t, err)
+
+	_, _, _, err = jwtService.ValidatePrivateToken(otherToken)
+	assert.NoError(t, err)
+
+	// A login right after the revocation gets a working token
+	newToken, err := jwtService.GeneratePrivateToken(apiKey, user, "")
+	require.NoError(t, err)
+	_, _, _, err = jwtService.ValidatePrivateToken(newToken)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,154 @@
+package handler_test
+
+import (
+	"context"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/usecase"
+	"go-rest-api-template/internal/handler"
+	"go-rest-api-template/internal/repository"
+	"go-rest-api-template/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLoginAttemptService locks accounts after 3 failed logins and IPs after 10, without delays
+func newTestLoginAttemptService(repo *MockUserRepository) service.LoginAttemptService {
+	return service.NewLoginAttemptService(repo, repository.NewInMemoryRateLimitRepository(), service.LoginAttemptConfig{
+		MaxAttempts:     3,
+		LockoutDuration: time.Minute,
+		MaxIPAttempts:   10,
+		IPWindow:        time.Minute,
+	})
+}
+
+func newLockoutTestUserService(t *testing.T) (usecase.UserUsecase, *MockUserRepository) {
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", constant.UserStatusActive)
+	require.NoError(t, repo.users[1].HashPassword("password123"))
+
+	return service.NewUserService(repo, nil, nil, nil, newTestRoleService(repo), NewMockEmailService(), newTestLoginAttemptService(repo), newTestAuditService(), service.UserServiceConfig{}), repo
+}
+
+func TestLogin_LocksAccountAfterFailedAttempts(t *testing.T) {
+	ctx := context.Background()
+	userService, repo := newLockoutTestUserService(t)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := userService.Login(ctx, "testuser", "wrongpassword", "10.0.0.1")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, service.ErrAccountLocked)
+	}
+
+	// The third failure locks the account
+	_, _, err := userService.Login(ctx, "testuser", "wrongpassword", "10.0.0.1")
+	assert.ErrorIs(t, err, service.ErrAccountLocked)
+	assert.True(t, repo.users[1].IsLocked())
+
+	// Even the right password is refused while locked, from any address
+	_, _, err = userService.Login(ctx, "testuser", "password123", "10.0.0.2")
+	assert.ErrorIs(t, err, service.ErrAccountLocked)
+
+	require.NoError(t, userService.UnlockUser(ctx, 1))
+	user, _, err := userService.Login(ctx, "testuser", "password123", "10.0.0.2")
+	require.NoError(t, err)
+	assert.Equal(t, 0, user.FailedLoginAttempts)
+
+	assert.ErrorIs(t, userService.UnlockUser(ctx, 99), service.ErrUserNotFound)
+}
+
+func TestLogin_LockEndsAfterCooldown(t *testing.T) {
+	ctx := context.Background()
+	userService, repo := newLockoutTestUserService(t)
+
+	past := time.Now().Add(-time.Second)
+	repo.users[1].FailedLoginAttempts = 3
+	repo.users[1].LockedUntil = &past
+
+	// The counter starts over once the lock has ended
+	_, _, err := userService.Login(ctx, "testuser", "wrongpassword", "10.0.0.1")
+	assert.NotErrorIs(t, err, service.ErrAccountLocked)
+	assert.Equal(t, 1, repo.users[1].FailedLoginAttempts)
+
+	_, _, err = userService.Login(ctx, "testuser", "password123", "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 0, repo.users[1].FailedLoginAttempts)
+	assert.Nil(t, repo.users[1].LockedUntil)
+}
+
+func TestLogin_BlocksIPAfterFailedAttempts(t *testing.T) {
+	ctx := context.Background()
+	userService, _ := newLockoutTestUserService(t)
+
+	// Unknown usernames are counted per IP only
+	for i := 0; i < 10; i++ {
+		_, _, err := userService.Login(ctx, "nobody", "password123", "10.0.0.1")
+		assert.NotErrorIs(t, err, service.ErrTooManyLoginAttempts)
+	}
+
+	_, _, err := userService.Login(ctx, "testuser", "password123", "10.0.0.1")
+	assert.ErrorIs(t, err, service.ErrTooManyLoginAttempts)
+
+	_, _, err = userService.Login(ctx, "testuser", "password123", "10.0.0.2")
+	assert.NoError(t, err)
+}
+
+func TestLogin_BannedAccount(t *testing.T) {
+	ctx := context.Background()
+	userService, repo := newLockoutTestUserService(t)
+	repo.users[1].Status = constant.UserStatusBanned
+
+	_, _, err := userService.Login(ctx, "testuser", "password123", "10.0.0.1")
+	assert.ErrorIs(t, err, service.ErrAccountBanned)
+
+	// A wrong password does not reveal the ban
+	_, _, err = userService.Login(ctx, "testuser", "wrongpassword", "10.0.0.1")
+	assert.NotErrorIs(t, err, service.ErrAccountBanned)
+}
+
+func TestLoginAttemptService_ProgressiveDelay(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", constant.UserStatusActive)
+	loginAttempts := service.NewLoginAttemptService(repo, repository.NewInMemoryRateLimitRepository(), service.LoginAttemptConfig{
+		MaxAttempts: 10,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    300 * time.Millisecond,
+	})
+
+	var delays []time.Duration
+	for i := 0; i < 4; i++ {
+		delay, err := loginAttempts.RecordFailure(ctx, repo.users[1], "10.0.0.1")
+		require.NoError(t, err)
+		delays = append(delays, delay)
+	}
+
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}, delays)
+}
+
+func TestUserHandler_UnlockUser(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	userService, repo := newLockoutTestUserService(t)
+	lockedUntil := time.Now().Add(time.Hour)
+	repo.users[1].FailedLoginAttempts = 3
+	repo.users[1].LockedUntil = &lockedUntil
+
+	app := fiber.New()
+	userHandler := handler.NewUserHandler(userService)
+	app.Post("/admin/users/:id/unlock", userHandler.UnlockUser)
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/admin/users/1/unlock", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, repo.users[1].IsLocked())
+
+	resp, err = app.Test(httptest.NewRequest("POST", "/admin/users/99/unlock", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,316 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/handler"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/totp"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockMFARepository keeps enrollments and recovery code hashes in memory
+type MockMFARepository struct {
+	mu            sync.Mutex
+	enrollments   map[int]*entity.UserMFA
+	recoveryCodes map[int]map[string]bool // user id -> code hash -> used
+}
+
+func NewMockMFARepository() *MockMFARepository {
+	return &MockMFARepository{
+		enrollments:   make(map[int]*entity.UserMFA),
+		recoveryCodes: make(map[int]map[string]bool),
+	}
+}
+
+func (m *MockMFARepository) GetByUserID(ctx context.Context, userID int) (*entity.UserMFA, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if mfa, ok := m.enrollments[userID]; ok {
+		copied := *mfa
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *MockMFARepository) Save(ctx context.Context, mfa *entity.UserMFA) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	copied := *mfa
+	m.enrollments[mfa.UserID] = &copied
+	return nil
+}
+
+func (m *MockMFARepository) UpdateLastUsedStep(ctx context.Context, userID int, step int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mfa, ok := m.enrollments[userID]
+	if !ok || mfa.LastUsedStep >= step {
+		return false, nil
+	}
+	mfa.LastUsedStep = step
+	return true, nil
+}
+
+func (m *MockMFARepository) Delete(ctx context.Context, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.enrollments, userID)
+	delete(m.recoveryCodes, userID)
+	return nil
+}
+
+func (m *MockMFARepository) ReplaceRecoveryCodes(ctx context.Context, userID int, codeHashes []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recoveryCodes[userID] = make(map[string]bool)
+	for _, codeHash := range codeHashes {
+		m.recoveryCodes[userID][codeHash] = false
+	}
+	return nil
+}
+
+func (m *MockMFARepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	used, ok := m.recoveryCodes[userID][codeHash]
+	if !ok || used {
+		return false, nil
+	}
+	m.recoveryCodes[userID][codeHash] = true
+	return true, nil
+}
+
+func newTestMFAService(t *testing.T, repo *MockMFARepository) service.MFAService {
+	mfaService, err := service.NewMFAService(repo, "test-secret", "Test App")
+	require.NoError(t, err)
+	return mfaService
+}
+
+// enableMFA enrolls and confirms 2FA for the user, returning the secret and the recovery codes
+func enableMFA(t *testing.T, mfaService service.MFAService, user *entity.User) (string, []string) {
+	enrollment, err := mfaService.Enroll(context.Background(), user)
+	require.NoError(t, err)
+
+	// Confirm with the code of the previous step, so the current one is still unused
+	code, err := totp.Code(enrollment.Secret, totp.Step(time.Now())-1)
+	require.NoError(t, err)
+	codes, err := mfaService.Confirm(context.Background(), user.ID, code)
+	require.NoError(t, err)
+	return enrollment.Secret, codes
+}
+
+func currentCode(t *testing.T, secret string) string {
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestTOTP_RFC6238Vectors(t *testing.T) {
+	// Test vectors of RFC 6238 appendix B (SHA1), truncated to six digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // "12345678901234567890"
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for unix, expected := range vectors {
+		code, err := totp.Code(secret, totp.Step(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code, "time %d", unix)
+	}
+
+	// One step of clock drift is tolerated, two are not
+	now := time.Unix(1111111109, 0)
+	_, ok := totp.Validate(secret, "081804", now.Add(totp.Period))
+	assert.True(t, ok)
+	_, ok = totp.Validate(secret, "081804", now.Add(2*totp.Period))
+	assert.False(t, ok)
+
+	assert.Equal(t, "otpauth://totp/Test%20App:user@example.com?algorithm=SHA1&digits=6&issuer=Test+App&period=30&secret=ABC",
+		totp.URI("Test App", "user@example.com", "ABC"))
+}
+
+func TestMFAService_Flow(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockMFARepository()
+	mfaService := newTestMFAService(t, repo)
+	user := &entity.User{ID: 1, Username: "testuser", Email: "test@example.com"}
+
+	enrollment, err := mfaService.Enroll(ctx, user)
+	require.NoError(t, err)
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	// The secret is stored encrypted and not used before it was confirmed
+	assert.NotEqual(t, enrollment.Secret, repo.enrollments[1].Secret)
+	enabled, err := mfaService.IsEnabled(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	assert.ErrorIs(t, mfaService.Verify(ctx, 1, currentCode(t, enrollment.Secret)), service.ErrMFANotEnabled)
+
+	_, err = mfaService.Confirm(ctx, 1, "000000")
+	assert.ErrorIs(t, err, service.ErrInvalidMFACode)
+
+	code := currentCode(t, enrollment.Secret)
+	codes, err := mfaService.Confirm(ctx, 1, code)
+	require.NoError(t, err)
+	assert.Len(t, codes, service.RecoveryCodeCount)
+
+	enabled, err = mfaService.IsEnabled(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	_, err = mfaService.Enroll(ctx, user)
+	assert.ErrorIs(t, err, service.ErrMFAAlreadyEnabled)
+
+	// The code used to confirm cannot be replayed
+	assert.ErrorIs(t, mfaService.Verify(ctx, 1, code), service.ErrInvalidMFACode)
+
+	// Recovery codes work once, whatever their case and separator
+	recovery := strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))
+	assert.NoError(t, mfaService.Verify(ctx, 1, recovery))
+	assert.ErrorIs(t, mfaService.Verify(ctx, 1, codes[0]), service.ErrInvalidMFACode)
+
+	assert.ErrorIs(t, mfaService.Disable(ctx, 1, "wrong-code"), service.ErrInvalidMFACode)
+	require.NoError(t, mfaService.Disable(ctx, 1, codes[1]))
+	enabled, err = mfaService.IsEnabled(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+}
+
+func TestJWTService_MFAPendingToken(t *testing.T) {
+	jwtService := newTestJWTService()
+	apiKey := &entity.ApiKey{ID: 1, Name: "test-api-key"}
+	user := &entity.User{ID: 7, Username: "testuser", Email: "test@example.com"}
+
+	token, err := jwtService.GenerateMFAPendingToken(apiKey, user)
+	require.NoError(t, err)
+
+	claims, err := jwtService.ValidateMFAPendingToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, 7, claims.UserID)
+	assert.Equal(t, 1, claims.ApiKeyID)
+
+	// The pending token opens neither public nor private endpoints
+	_, _, err = jwtService.ValidatePublicToken(token)
+	assert.Error(t, err)
+	_, _, _, err = jwtService.ValidatePrivateToken(token)
+	assert.Error(t, err)
+
+	// and other tokens are not accepted as pending tokens
+	privateToken, err := jwtService.GeneratePrivateToken(apiKey, user, "")
+	require.NoError(t, err)
+	_, err = jwtService.ValidateMFAPendingToken(privateToken)
+	assert.Error(t, err)
+}
+
+// newTwoStepLoginApp serves login and mfa/verify for testuser (id 1, password123) with 2FA enabled
+func newTwoStepLoginApp(t *testing.T) (*fiber.App, *MockUserRepository, service.JWTService, string) {
+	userRepo := NewMockUserRepository()
+	userRepo.AddTestUser(1, "testuser", "test@example.com", constant.UserStatusActive)
+	require.NoError(t, userRepo.users[1].HashPassword("password123"))
+
+	refreshRepo := NewMockRefreshTokenRepository()
+	jwtService := newTestJWTService()
+	refreshService := service.NewRefreshTokenService(refreshRepo, 24)
+	sessionService := service.NewSessionService(NewMockSessionRepository(), refreshRepo, 24)
+	roleService := service.NewRoleService(NewMockRoleRepository(), userRepo)
+	userService := service.NewUserService(userRepo, jwtService, refreshService, sessionService, roleService, NewMockEmailService(), newTestLoginAttemptService(userRepo), newTestAuditService(), service.UserServiceConfig{})
+	mfaService := newTestMFAService(t, NewMockMFARepository())
+	authHandler := handler.NewAuthHandler(userService, jwtService, nil, refreshService, sessionService, roleService, mfaService, newTestLoginAttemptService(userRepo))
+
+	secret, _ := enableMFA(t, mfaService, userRepo.users[1])
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("api_key_id", 1)
+		c.Locals("api_key_name", "test-api-key")
+		return c.Next()
+	})
+	app.Post("/auth/login", authHandler.Login)
+	app.Post("/auth/mfa/verify", authHandler.VerifyMFA)
+	return app, userRepo, jwtService, secret
+}
+
+// loginForMFAToken sends the password step and returns the MFA token
+func loginForMFAToken(t *testing.T, app *fiber.App) string {
+	status, data := postJSONData(t, app, "/auth/login", `{"username":"testuser","password":"password123"}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Nil(t, data["token"])
+	mfaToken, _ := data["mfa_token"].(string)
+	require.NotEmpty(t, mfaToken)
+	return mfaToken
+}
+
+func TestAuthHandler_TwoStepLogin(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	app, _, jwtService, secret := newTwoStepLoginApp(t)
+
+	// The password only earns an MFA token
+	mfaToken := loginForMFAToken(t, app)
+
+	status, _ := postJSONData(t, app, "/auth/mfa/verify", `{"mfa_token":"`+mfaToken+`","code":"000000"}`)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, _ = postJSONData(t, app, "/auth/mfa/verify", `{"mfa_token":"invalid","code":"`+currentCode(t, secret)+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, data := postJSONData(t, app, "/auth/mfa/verify", `{"mfa_token":"`+mfaToken+`","code":"`+currentCode(t, secret)+`"}`)
+	require.Equal(t, http.StatusOK, status)
+	privateToken, _ := data["token"].(string)
+	_, _, user, err := jwtService.ValidatePrivateToken(privateToken)
+	require.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+
+	// The MFA token is single use
+	_, err = jwtService.ValidateMFAPendingToken(mfaToken)
+	assert.Error(t, err)
+}
+
+func TestAuthHandler_VerifyMFA_LocksAfterWrongCodes(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	app, userRepo, _, secret := newTwoStepLoginApp(t)
+	mfaToken := loginForMFAToken(t, app)
+
+	// newTestLoginAttemptService locks after 3 failures
+	for i := 0; i < 2; i++ {
+		status, _ := postJSONData(t, app, "/auth/mfa/verify", `{"mfa_token":"`+mfaToken+`","code":"000000"}`)
+		assert.Equal(t, http.StatusUnauthorized, status)
+	}
+	status, _ := postJSONData(t, app, "/auth/mfa/verify", `{"mfa_token":"`+mfaToken+`","code":"000000"}`)
+	assert.Equal(t, http.StatusLocked, status)
+	assert.True(t, userRepo.users[1].IsLocked())
+
+	// The token is dropped with the lock, even the right code gets no private token
+	status, _ = postJSONData(t, app, "/auth/mfa/verify", `{"mfa_token":"`+mfaToken+`","code":"`+currentCode(t, secret)+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, status)
+}
+
+// postJSONData posts a JSON body and returns the status and the data of the response envelope
+func postJSONData(t *testing.T, app *fiber.App, path, body string) (int, map[string]interface{}) {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, 5000)
+	require.NoError(t, err)
+
+	var envelope struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&envelope)
+	return resp.StatusCode, envelope.Data
+}
@@ -0,0 +1,102 @@
+package handler_test
+
+import (
+	"context"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/password"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// usePasswordHasher installs a hasher for the duration of a test
+func usePasswordHasher(t *testing.T, hasher password.Hasher) {
+	password.SetHasher(hasher)
+	t.Cleanup(func() { password.SetHasher(&password.BcryptHasher{Cost: bcrypt.DefaultCost}) })
+}
+
+// newFastArgon2idHasher keeps argon2id cheap enough for tests
+func newFastArgon2idHasher(t *testing.T, iterations uint32) *password.Argon2idHasher {
+	hasher, err := password.NewArgon2idHasher(64, iterations, 1)
+	require.NoError(t, err)
+	return hasher
+}
+
+func TestPasswordHasher_Argon2id(t *testing.T) {
+	hasher := newFastArgon2idHasher(t, 1)
+
+	hash, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"))
+	assert.True(t, password.Verify(hash, "correct horse"))
+	assert.False(t, password.Verify(hash, "wrong horse"))
+
+	// Salted, so the same password never gives the same hash
+	other, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+
+	assert.False(t, hasher.NeedsRehash(hash))
+	assert.True(t, newFastArgon2idHasher(t, 2).NeedsRehash(hash))
+	assert.False(t, password.Verify("$argon2id$v=19$broken", "correct horse"))
+
+	_, err = password.NewArgon2idHasher(0, 1, 1)
+	assert.Error(t, err)
+}
+
+func TestPasswordHasher_Bcrypt(t *testing.T) {
+	hasher, err := password.NewBcryptHasher(bcrypt.MinCost)
+	require.NoError(t, err)
+
+	hash, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, password.Verify(hash, "correct horse"))
+	assert.False(t, hasher.NeedsRehash(hash))
+
+	// Another cost or another algorithm both ask for a new hash
+	assert.True(t, (&password.BcryptHasher{Cost: bcrypt.MinCost + 1}).NeedsRehash(hash))
+	argonHash, err := newFastArgon2idHasher(t, 1).Hash("correct horse")
+	require.NoError(t, err)
+	assert.True(t, hasher.NeedsRehash(argonHash))
+	assert.True(t, newFastArgon2idHasher(t, 1).NeedsRehash(hash))
+
+	_, err = password.NewBcryptHasher(bcrypt.MaxCost + 1)
+	assert.Error(t, err)
+}
+
+func TestLogin_RehashesOutdatedPasswords(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", constant.UserStatusActive)
+	userService := service.NewUserService(repo, nil, nil, nil, newTestRoleService(repo), NewMockEmailService(), newTestLoginAttemptService(repo), newTestAuditService(), service.UserServiceConfig{})
+
+	usePasswordHasher(t, &password.BcryptHasher{Cost: bcrypt.MinCost})
+	require.NoError(t, repo.users[1].HashPassword("password123"))
+	bcryptHash := repo.users[1].PasswordHash
+
+	// A failed login leaves the hash alone
+	_, _, err := userService.Login(ctx, "testuser", "wrong-password", "127.0.0.1")
+	require.Error(t, err)
+	assert.Equal(t, bcryptHash, repo.users[1].PasswordHash)
+
+	usePasswordHasher(t, newFastArgon2idHasher(t, 1))
+	_, _, err = userService.Login(ctx, "testuser", "password123", "127.0.0.1")
+	require.NoError(t, err)
+	argonHash := repo.users[1].PasswordHash
+	assert.True(t, strings.HasPrefix(argonHash, "$argon2id$"))
+
+	// Up-to-date hashes are kept, and the new one still logs in
+	_, _, err = userService.Login(ctx, "testuser", "password123", "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, argonHash, repo.users[1].PasswordHash)
+
+	// Stronger parameters of the same algorithm are picked up too
+	usePasswordHasher(t, newFastArgon2idHasher(t, 2))
+	_, _, err = userService.Login(ctx, "testuser", "password123", "127.0.0.1")
+	require.NoError(t, err)
+	assert.Contains(t, repo.users[1].PasswordHash, "t=2")
+}
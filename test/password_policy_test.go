@@ -0,0 +1,237 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/model"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/i18n"
+	"go-rest-api-template/pkg/password"
+	"go-rest-api-template/pkg/response"
+	"go-rest-api-template/pkg/validator"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// usePasswordPolicy installs a policy for the duration of a test
+func usePasswordPolicy(t *testing.T, policy *password.Policy) {
+	password.SetPolicy(policy)
+	t.Cleanup(func() { password.SetPolicy(password.DefaultPolicy()) })
+}
+
+func violatedRules(err error) []string {
+	policyErr, ok := err.(*password.PolicyError)
+	if !ok {
+		return nil
+	}
+	rules := make([]string, len(policyErr.Violations))
+	for i, v := range policyErr.Violations {
+		rules[i] = v.Rule
+	}
+	return rules
+}
+
+func TestPasswordPolicy_Rules(t *testing.T) {
+	denylist := filepath.Join(t.TempDir(), "common.txt")
+	require.NoError(t, os.WriteFile(denylist, []byte("# comment\nPassword1!\n\n"), 0o600))
+
+	policy := &password.Policy{
+		MinLength:        10,
+		MaxLength:        20,
+		RequireUppercase: true,
+		RequireLowercase: true,
+		RequireDigit:     true,
+		RequireSymbol:    true,
+	}
+	require.NoError(t, policy.LoadDenylist(denylist))
+
+	assert.NoError(t, policy.Check("Correct-Horse-1"))
+	assert.Equal(t, []string{password.RuleMinLength, password.RuleUppercase, password.RuleDigit, password.RuleSymbol}, violatedRules(policy.Check("short")))
+	assert.Equal(t, []string{password.RuleMaxLength, password.RuleLowercase}, violatedRules(policy.Check("ABCDEFGHIJKLMNOPQRSTUVWXYZ-1")))
+
+	// The denylist is matched case-insensitively
+	policy.MinLength = 8
+	assert.Equal(t, []string{password.RuleCommon}, violatedRules(policy.Check("pASSWORD1!")))
+
+	// Length is counted in characters, not bytes
+	assert.NoError(t, policy.Check("Pässwörd-1"))
+
+	assert.Error(t, policy.LoadDenylist(filepath.Join(t.TempDir(), "missing.txt")))
+}
+
+func TestPasswordPolicy_ValidatorTag(t *testing.T) {
+	usePasswordPolicy(t, &password.Policy{MinLength: 12, RequireDigit: true})
+
+	err := validator.ValidateStruct(&model.ResetPasswordRequest{Token: "token", NewPassword: "short"})
+	require.Error(t, err)
+
+	// Every broken rule gets its own message
+	errs := validator.GetValidationErrors(err)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "new_password", errs[0].Field)
+	assert.Equal(t, "password must be at least 12 characters long", errs[0].Message)
+	assert.Equal(t, "validation.password."+password.RuleDigit, errs[1].MessageKey)
+
+	assert.NoError(t, validator.ValidateStruct(&model.ResetPasswordRequest{Token: "token", NewPassword: "long enough 1"}))
+}
+
+func TestChangePassword_RejectsRecentPasswords(t *testing.T) {
+	usePasswordPolicy(t, &password.Policy{MinLength: 8, HistorySize: 2})
+
+	ctx := context.Background()
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", "active")
+	require.NoError(t, repo.users[1].HashPassword("password-a"))
+	userService := newTestUserService(repo, NewMockEmailService(), service.UserServiceConfig{})
+
+	// The current password counts even without recorded history
+	err := userService.ChangePassword(ctx, 1, "password-a", "password-a")
+	assert.Equal(t, []string{password.RuleReused}, violatedRules(err))
+
+	require.NoError(t, userService.ChangePassword(ctx, 1, "password-a", "password-b"))
+	require.NoError(t, userService.ChangePassword(ctx, 1, "password-b", "password-c"))
+
+	err = userService.ChangePassword(ctx, 1, "password-c", "password-b")
+	assert.Equal(t, []string{password.RuleReused}, violatedRules(err))
+
+	// Only the last two passwords count: the current one and one previous
+	require.NoError(t, userService.ChangePassword(ctx, 1, "password-c", "password-a"))
+	assert.Len(t, repo.passwordHistory[1], 1)
+
+	// The policy applies to the service too, not only to the request DTOs
+	err = userService.ChangePassword(ctx, 1, "password-a", "short")
+	assert.Equal(t, []string{password.RuleMinLength}, violatedRules(err))
+}
+
+func TestUserHandler_UpdateUser_PasswordFollowsPolicy(t *testing.T) {
+	setupTestGlobalHelpers()
+	usePasswordPolicy(t, &password.Policy{MinLength: 8, HistorySize: 2})
+
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", "active")
+	require.NoError(t, repo.users[1].HashPassword("password-a"))
+	userHandler := newTestUserHandler(repo)
+
+	// An admin sets the password without knowing the current one
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("permissions", entity.NewPermissionSet([]string{constant.PermissionUsersUpdate, constant.PermissionUsersManage}))
+		return c.Next()
+	})
+	app.Put("/users/:id", userHandler.UpdateUser)
+
+	put := func(body string) int {
+		req := httptest.NewRequest("PUT", "/users/1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusUnprocessableEntity, put(`{"password":"password-a"}`))
+	assert.Equal(t, http.StatusOK, put(`{"password":"password-b","username":"renamed"}`))
+	assert.True(t, repo.users[1].CheckPassword("password-b"))
+	assert.Equal(t, "renamed", repo.users[1].Username)
+	assert.Len(t, repo.passwordHistory[1], 1)
+
+	// The replaced password went to the history
+	assert.Equal(t, http.StatusUnprocessableEntity, put(`{"password":"password-a"}`))
+}
+
+func TestUserHandler_UpdateUser_RefusedUpdateKeepsPassword(t *testing.T) {
+	setupTestGlobalHelpers()
+	usePasswordPolicy(t, &password.Policy{MinLength: 8, HistorySize: 2})
+
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", "active")
+	repo.AddTestUser(2, "taken", "taken@example.com", "active")
+	require.NoError(t, repo.users[1].HashPassword("password-a"))
+	userHandler := newTestUserHandler(repo)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("permissions", entity.NewPermissionSet([]string{constant.PermissionUsersUpdate, constant.PermissionUsersManage}))
+		return c.Next()
+	})
+	app.Put("/users/:id", userHandler.UpdateUser)
+
+	req := httptest.NewRequest("PUT", "/users/1", strings.NewReader(`{"password":"password-b","username":"taken"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	// Nothing of the update is applied, the old password still works
+	assert.Equal(t, "testuser", repo.users[1].Username)
+	assert.True(t, repo.users[1].CheckPassword("password-a"))
+	assert.False(t, repo.users[1].CheckPassword("password-b"))
+	assert.Empty(t, repo.passwordHistory[1])
+}
+
+func TestUserHandler_ChangePasswordLocalizedPolicyErrors(t *testing.T) {
+	usePasswordPolicy(t, &password.Policy{MinLength: 8, HistorySize: 3})
+
+	manager, err := i18n.NewManager(i18n.Config{
+		DefaultLanguage: "en",
+		LocalesPath:     "../locales",
+		SupportedLangs:  []string{"en", "id"},
+		Modules:         []string{"common", "user"},
+	})
+	require.NoError(t, err)
+	previous := response.GlobalI18nResponseHelper
+	response.GlobalI18nResponseHelper = response.NewI18nResponseHelper(manager)
+	t.Cleanup(func() { response.GlobalI18nResponseHelper = previous })
+
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", "active")
+	require.NoError(t, repo.users[1].HashPassword("oldpassword"))
+	userHandler := newTestUserHandler(repo)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("language", "id")
+		return c.Next()
+	})
+	app.Put("/users/:id/password", userHandler.ChangePassword)
+
+	changePassword := func(body string) (int, []map[string]string) {
+		req := httptest.NewRequest("PUT", "/users/1/password", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, 5000)
+		require.NoError(t, err)
+
+		var result struct {
+			Meta struct {
+				Errors struct {
+					ValidationErrors []map[string]string `json:"validation_errors"`
+				} `json:"errors"`
+			} `json:"meta"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return resp.StatusCode, result.Meta.Errors.ValidationErrors
+	}
+
+	status, errs := changePassword(`{"current_password":"oldpassword","new_password":"short"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "Kata sandi minimal 8 karakter", errs[0]["message"])
+
+	// Reuse is found by the service and reported the same way
+	status, _ = changePassword(`{"current_password":"oldpassword","new_password":"newpassword"}`)
+	require.Equal(t, http.StatusOK, status)
+	status, errs = changePassword(`{"current_password":"newpassword","new_password":"oldpassword"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "new_password", errs[0]["field"])
+	assert.Equal(t, "Kata sandi harus berbeda dari 3 kata sandi terakhir Anda", errs[0]["message"])
+}
@@ -0,0 +1,158 @@
+package handler_test
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/mailer"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockEmailService captures the tokens that would have been mailed
+type MockEmailService struct {
+	tokens chan string
+}
+
+func NewMockEmailService() *MockEmailService {
+	return &MockEmailService{tokens: make(chan string, 10)}
+}
+
+func (m *MockEmailService) SendPasswordReset(ctx context.Context, user *entity.User, token string, expiry time.Duration, lang string) error {
+	m.tokens <- token
+	return nil
+}
+
+func (m *MockEmailService) SendEmailVerification(ctx context.Context, user *entity.User, token string, expiry time.Duration, lang string) error {
+	m.tokens <- token
+	return nil
+}
+
+func (m *MockEmailService) SendEmailChangeVerification(ctx context.Context, user *entity.User, newEmail, token string, expiry time.Duration, lang string) error {
+	m.tokens <- token
+	return nil
+}
+
+func (m *MockEmailService) SendInvitation(ctx context.Context, user *entity.User, token string, expiry time.Duration, lang string) error {
+	m.tokens <- token
+	return nil
+}
+
+// sentToken waits for an email, which is sent in the background
+func (m *MockEmailService) sentToken(t *testing.T) string {
+	select {
+	case token := <-m.tokens:
+		return token
+	case <-time.After(time.Second):
+		t.Fatal("no email sent")
+		return ""
+	}
+}
+
+func setupPasswordResetApp(repo *MockUserRepository, emailService service.EmailService) *fiber.App {
+	userHandler := newTestUserHandlerWithMail(repo, emailService)
+
+	app := fiber.New()
+	app.Post("/auth/forgot-password", userHandler.ForgotPassword)
+	app.Post("/auth/reset-password", userHandler.ResetPassword)
+	return app
+}
+
+func postJSON(t *testing.T, app *fiber.App, path, body string) int {
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestPasswordReset_Flow(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", "active")
+	require.NoError(t, repo.users[1].HashPassword("oldpassword"))
+	emailService := NewMockEmailService()
+	app := setupPasswordResetApp(repo, emailService)
+
+	assert.Equal(t, http.StatusOK, postJSON(t, app, "/auth/forgot-password", `{"email":"test@example.com"}`))
+	token := emailService.sentToken(t)
+
+	// Only the hash of the token is stored
+	require.NotNil(t, repo.users[1].PasswordResetToken)
+	assert.NotEqual(t, token, *repo.users[1].PasswordResetToken)
+
+	body := `{"token":"` + token + `","new_password":"newpassword"}`
+	assert.Equal(t, http.StatusOK, postJSON(t, app, "/auth/reset-password", body))
+	assert.True(t, repo.users[1].CheckPassword("newpassword"))
+	assert.Nil(t, repo.users[1].PasswordResetToken)
+
+	// Tokens are single use
+	assert.Equal(t, http.StatusBadRequest, postJSON(t, app, "/auth/reset-password", `{"token":"`+token+`","new_password":"otherpassword"}`))
+	assert.True(t, repo.users[1].CheckPassword("newpassword"))
+}
+
+func TestPasswordReset_ExpiredToken(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", "active")
+	emailService := NewMockEmailService()
+	app := setupPasswordResetApp(repo, emailService)
+
+	assert.Equal(t, http.StatusOK, postJSON(t, app, "/auth/forgot-password", `{"email":"test@example.com"}`))
+	token := emailService.sentToken(t)
+
+	expired := time.Now().Add(-time.Minute)
+	repo.users[1].PasswordResetExpiresAt = &expired
+
+	assert.Equal(t, http.StatusBadRequest, postJSON(t, app, "/auth/reset-password", `{"token":"`+token+`","new_password":"newpassword"}`))
+	assert.Equal(t, http.StatusBadRequest, postJSON(t, app, "/auth/reset-password", `{"token":"unknown","new_password":"newpassword"}`))
+}
+
+func TestPasswordReset_ChangePasswordInvalidatesToken(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", "active")
+	require.NoError(t, repo.users[1].HashPassword("oldpassword"))
+	emailService := NewMockEmailService()
+	userService := newTestUserService(repo, emailService, service.UserServiceConfig{ResetTokenExpiry: time.Hour})
+
+	require.NoError(t, userService.ForgotPassword(context.Background(), "test@example.com", "en"))
+	token := emailService.sentToken(t)
+
+	require.NoError(t, userService.ChangePassword(context.Background(), 1, "oldpassword", "newpassword"))
+	assert.ErrorIs(t, userService.ResetPassword(context.Background(), token, "otherpassword"), service.ErrInvalidResetToken)
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m := mailer.NewFileMailer(dir, "noreply@example.com")
+
+	err := m.Send(context.Background(), &mailer.Message{To: "test@example.com", Subject: "Reset your password", Body: "line 1\nline 2"})
+	require.NoError(t, err)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "To: test@example.com\r\n")
+	assert.Contains(t, string(data), "line 1\r\nline 2")
+
+	// Line breaks in headers would allow injecting extra headers
+	err = m.Send(context.Background(), &mailer.Message{To: "test@example.com\r\nBcc: other@example.com", Subject: "x", Body: "x"})
+	assert.ErrorIs(t, err, mailer.ErrInvalidHeader)
+}
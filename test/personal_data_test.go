@@ -0,0 +1,168 @@
+package handler_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/handler"
+	"go-rest-api-template/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupPersonalDataApp serves the personal data endpoints for user 1 signed in from 203.0.113.7
+func setupPersonalDataApp(repo *MockUserRepository, sessionRepo *MockSessionRepository, apiKeyRepo *MockApiKeyRepository, auditRepo *MockAuditLogRepository) *fiber.App {
+	auditService := service.NewAuditService(auditRepo)
+	personalDataService := service.NewPersonalDataService(repo, auditService,
+		service.NewProfileDataContributor(repo),
+		service.NewSessionDataContributor(sessionRepo),
+		service.NewApiKeyActivityContributor(sessionRepo, apiKeyRepo),
+		service.NewAuditDataContributor(auditRepo),
+	)
+	personalDataHandler := handler.NewPersonalDataHandler(personalDataService)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", 1)
+		c.Locals("client_ip", "203.0.113.7")
+		return c.Next()
+	})
+	app.Get("/me/export", personalDataHandler.ExportMyData)
+	app.Post("/me/erase", personalDataHandler.EraseMyAccount)
+	return app
+}
+
+func TestPersonalData_Export(t *testing.T) {
+	setupTestGlobalHelpers()
+	ctx := context.Background()
+
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "alice", "alice@example.com", constant.UserStatusActive)
+	repo.AddTestUser(2, "bob", "bob@example.com", constant.UserStatusActive)
+
+	apiKeyRepo := NewMockApiKeyRepository()
+	require.NoError(t, apiKeyRepo.Create(ctx, &entity.ApiKey{Name: "Mobile App"}))
+	sessionRepo := NewMockSessionRepository()
+	signedIn := time.Now().Add(-time.Hour)
+	for _, userID := range []int{1, 1, 2} {
+		require.NoError(t, sessionRepo.Create(ctx, &entity.Session{UserID: userID, ApiKeyID: 1, IPAddress: "203.0.113.7", CreatedAt: signedIn, ExpiresAt: time.Now().Add(time.Hour)}))
+	}
+
+	auditRepo := NewMockAuditLogRepository()
+	auditService := service.NewAuditService(auditRepo)
+	auditService.Record(ctx, service.AuditEntry{Action: constant.AuditActionLoginSucceeded, EntityType: constant.AuditEntityUser, EntityID: 1, ActorUserID: 1})
+	auditService.Record(ctx, service.AuditEntry{Action: constant.AuditActionLoginSucceeded, EntityType: constant.AuditEntityUser, EntityID: 2, ActorUserID: 2})
+
+	app := setupPersonalDataApp(repo, sessionRepo, apiKeyRepo, auditRepo)
+	resp, err := app.Test(httptest.NewRequest("GET", "/me/export", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Disposition"), "personal-data-1.json")
+
+	var export struct {
+		FormatVersion int `json:"format_version"`
+		UserID        int `json:"user_id"`
+		Data          struct {
+			Profile        map[string]interface{}   `json:"profile"`
+			Sessions       []map[string]interface{} `json:"sessions"`
+			ApiKeyActivity []service.ApiKeyActivity `json:"api_key_activity"`
+			AuditLog       []entity.AuditLog        `json:"audit_log"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&export))
+	assert.Equal(t, 1, export.FormatVersion)
+	assert.Equal(t, 1, export.UserID)
+	assert.Equal(t, "alice", export.Data.Profile["username"])
+	assert.NotContains(t, export.Data.Profile, "password_hash")
+	assert.Len(t, export.Data.Sessions, 2)
+	require.Len(t, export.Data.ApiKeyActivity, 1)
+	assert.Equal(t, "Mobile App", export.Data.ApiKeyActivity[0].ApiKeyName)
+	assert.Equal(t, 2, export.Data.ApiKeyActivity[0].Sessions)
+	require.Len(t, export.Data.AuditLog, 1)
+	assert.Equal(t, 1, *export.Data.AuditLog[0].ActorUserID)
+}
+
+func TestPersonalData_EraseAccount(t *testing.T) {
+	setupTestGlobalHelpers()
+	ctx := context.Background()
+
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "alice", "alice@example.com", constant.UserStatusActive)
+	require.NoError(t, repo.users[1].HashPassword("password123"))
+	repo.passwordHistory[1] = []string{"old-hash"}
+
+	auditRepo := NewMockAuditLogRepository()
+	auditService := service.NewAuditService(auditRepo)
+	actor := service.WithAuditActor(ctx, service.AuditActor{UserID: 1, IP: "203.0.113.7"})
+	auditService.Record(actor, service.AuditEntry{Action: constant.AuditActionProfileUpdated, EntityType: constant.AuditEntityUser, EntityID: 1,
+		Before: map[string]interface{}{"full_name": "Alice"}, After: map[string]interface{}{"full_name": "Alice A."}})
+
+	app := setupPersonalDataApp(repo, NewMockSessionRepository(), NewMockApiKeyRepository(), auditRepo)
+
+	status, _ := requestJSONData(t, app, "POST", "/me/erase", `{"password":"wrong-password"}`)
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = requestJSONData(t, app, "POST", "/me/erase", `{}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	require.Contains(t, repo.users, 1)
+
+	status, _ = requestJSONData(t, app, "POST", "/me/erase", `{"password":"password123"}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.NotContains(t, repo.users, 1)
+	assert.NotContains(t, repo.deleted, 1)
+	assert.NotContains(t, repo.passwordHistory, 1)
+
+	// The audit entries stay, pointing at the anonymized user, without personal data
+	updated := auditRepo.byAction(constant.AuditActionProfileUpdated)
+	require.Len(t, updated, 1)
+	assert.Equal(t, 1, *updated[0].ActorUserID)
+	assert.Empty(t, updated[0].IP)
+	assert.Nil(t, updated[0].Before)
+	assert.Nil(t, updated[0].After)
+
+	erased := auditRepo.byAction(constant.AuditActionUserErased)
+	require.Len(t, erased, 1)
+	assert.Equal(t, 1, *erased[0].EntityID)
+	assert.Empty(t, erased[0].IP)
+
+	status, _ = requestJSONData(t, app, "POST", "/me/erase", `{"password":"password123"}`)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+// anonymizedUserRepository reports every user as anonymized by the time it is purged
+type anonymizedUserRepository struct {
+	*MockUserRepository
+}
+
+func (r *anonymizedUserRepository) Purge(ctx context.Context, id int, anonymize bool) error {
+	return sql.ErrNoRows
+}
+
+func TestPersonalData_EraseAlreadyAnonymized(t *testing.T) {
+	ctx := context.Background()
+
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "alice", "alice@example.com", constant.UserStatusActive)
+
+	auditRepo := NewMockAuditLogRepository()
+	auditService := service.NewAuditService(auditRepo)
+	auditService.Record(service.WithAuditActor(ctx, service.AuditActor{UserID: 1, IP: "203.0.113.7"}),
+		service.AuditEntry{Action: constant.AuditActionProfileUpdated, EntityType: constant.AuditEntityUser, EntityID: 1})
+
+	personalDataService := service.NewPersonalDataService(&anonymizedUserRepository{repo}, auditService, service.NewAuditDataContributor(auditRepo))
+
+	assert.ErrorIs(t, personalDataService.Erase(ctx, 1), service.ErrUserNotFound)
+
+	// The erasers only run once the user row was anonymized
+	updated := auditRepo.byAction(constant.AuditActionProfileUpdated)
+	require.Len(t, updated, 1)
+	assert.Equal(t, "203.0.113.7", updated[0].IP)
+	assert.Empty(t, auditRepo.byAction(constant.AuditActionUserErased))
+}
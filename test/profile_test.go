@@ -0,0 +1,129 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/usecase"
+	"go-rest-api-template/internal/handler"
+	"go-rest-api-template/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupProfileApp serves /me for user 1, with the service the verification links come from
+func setupProfileApp(repo *MockUserRepository, emailService *MockEmailService) (*fiber.App, usecase.UserUsecase) {
+	userService := service.NewUserService(repo, nil, nil, nil, newTestRoleService(repo), emailService, newTestLoginAttemptService(repo), newTestAuditService(), service.UserServiceConfig{
+		VerificationSecret:      "test-verification-secret",
+		VerificationTokenExpiry: time.Hour,
+	})
+	userHandler := handler.NewUserHandler(userService)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", 1)
+		return c.Next()
+	})
+	app.Get("/me", userHandler.GetProfile)
+	app.Patch("/me", userHandler.UpdateProfile)
+	return app, userService
+}
+
+// requestJSONData sends a request with a JSON body and returns the status and the data of the response envelope
+func requestJSONData(t *testing.T, app *fiber.App, method, path, body string) (int, map[string]interface{}) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, 5000)
+	require.NoError(t, err)
+
+	var envelope struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&envelope)
+	return resp.StatusCode, envelope.Data
+}
+
+func TestProfile_GetAndUpdate(t *testing.T) {
+	setupTestGlobalHelpers()
+
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", constant.UserStatusActive)
+	repo.users[1].FullName = "Test User"
+	app, _ := setupProfileApp(repo, NewMockEmailService())
+
+	status, data := requestJSONData(t, app, "GET", "/me", "")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "testuser", data["username"])
+	assert.Equal(t, "Test User", data["full_name"])
+
+	status, data = requestJSONData(t, app, "PATCH", "/me", `{"full_name":" John Doe ","phone":"+6281234567890","locale":"id-ID","timezone":"Asia/Jakarta"}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "John Doe", data["full_name"])
+	assert.Equal(t, "+6281234567890", repo.users[1].Phone)
+	assert.Equal(t, "id-ID", repo.users[1].Locale)
+	assert.Equal(t, "Asia/Jakarta", repo.users[1].Timezone)
+
+	// Omitted fields stay, empty ones are cleared
+	status, _ = requestJSONData(t, app, "PATCH", "/me", `{"phone":""}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Empty(t, repo.users[1].Phone)
+	assert.Equal(t, "John Doe", repo.users[1].FullName)
+
+	for _, body := range []string{
+		`{"phone":"0812-3456-7890"}`,
+		`{"locale":"not a locale"}`,
+		`{"timezone":"Mars/Olympus_Mons"}`,
+		`{"timezone":"Local"}`,
+		`{"email":""}`,
+	} {
+		status, _ = requestJSONData(t, app, "PATCH", "/me", body)
+		assert.Equal(t, http.StatusUnprocessableEntity, status, body)
+	}
+}
+
+func TestProfile_EmailChangeNeedsVerification(t *testing.T) {
+	setupTestGlobalHelpers()
+	ctx := context.Background()
+
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "testuser", "test@example.com", constant.UserStatusActive)
+	repo.AddTestUser(2, "otheruser", "other@example.com", constant.UserStatusActive)
+	emailService := NewMockEmailService()
+	app, userService := setupProfileApp(repo, emailService)
+
+	status, _ := requestJSONData(t, app, "PATCH", "/me", `{"email":"other@example.com"}`)
+	assert.Equal(t, http.StatusConflict, status)
+
+	// The current address stays until the new one is verified
+	status, data := requestJSONData(t, app, "PATCH", "/me", `{"email":"new@example.com"}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "test@example.com", data["email"])
+	assert.Equal(t, "new@example.com", data["pending_email"])
+	token := emailService.sentToken(t)
+
+	require.NoError(t, userService.VerifyEmail(ctx, token))
+	assert.Equal(t, "new@example.com", repo.users[1].Email)
+	assert.Nil(t, repo.users[1].PendingEmail)
+
+	// The address can be taken by someone else while the change is pending
+	status, _ = requestJSONData(t, app, "PATCH", "/me", `{"email":"taken@example.com"}`)
+	require.Equal(t, http.StatusOK, status)
+	token = emailService.sentToken(t)
+	repo.users[2].Email = "taken@example.com"
+	assert.ErrorIs(t, userService.VerifyEmail(ctx, token), service.ErrEmailExists)
+	assert.Equal(t, "new@example.com", repo.users[1].Email)
+
+	// Asking for the current address again cancels the pending change
+	status, data = requestJSONData(t, app, "PATCH", "/me", `{"email":"new@example.com"}`)
+	require.Equal(t, http.StatusOK, status)
+	assert.Nil(t, data["pending_email"])
+	assert.ErrorIs(t, userService.VerifyEmail(ctx, token), service.ErrInvalidVerificationToken)
+}
@@ -0,0 +1,60 @@
+package handler_test
+
+import (
+	"go-rest-api-template/pkg/query"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBuilder_Select(t *testing.T) {
+	builder := query.NewBuilder().
+		Where("deleted_at IS NULL").
+		Where("status = ?", "active").
+		Search("50%_off", "username", "email")
+
+	stmt, args := builder.Select("SELECT * FROM user", "id", query.Options{
+		Page:  3,
+		Limit: 10,
+		Sort:  query.Sort{Field: "username"},
+	})
+	assert.Equal(t, `SELECT * FROM user WHERE deleted_at IS NULL AND status = ? AND (username LIKE ? ESCAPE '\\' OR email LIKE ? ESCAPE '\\') ORDER BY username ASC, id ASC LIMIT ? OFFSET ?`, stmt)
+	assert.Equal(t, []interface{}{"active", `%50\%\_off%`, `%50\%\_off%`, 10, 20}, args)
+
+	stmt, args = builder.Count("SELECT COUNT(*) FROM user")
+	assert.Equal(t, `SELECT COUNT(*) FROM user WHERE deleted_at IS NULL AND status = ? AND (username LIKE ? ESCAPE '\\' OR email LIKE ? ESCAPE '\\')`, stmt)
+	assert.Len(t, args, 3)
+}
+
+func TestQueryBuilder_Cursor(t *testing.T) {
+	sort := query.Sort{Field: "email", Desc: true}
+	raw := query.NewCursor(sort, "bob@example.com", 7).Encode()
+
+	cursor, err := query.DecodeCursor(raw, sort)
+	require.NoError(t, err)
+
+	stmt, args := query.NewBuilder().Select("SELECT * FROM user", "id", query.Options{Page: 5, Limit: 10, Sort: sort, After: cursor})
+	assert.Equal(t, "SELECT * FROM user WHERE (email < ? OR (email = ? AND id < ?)) ORDER BY email DESC, id DESC LIMIT ? OFFSET ?", stmt)
+	assert.Equal(t, []interface{}{"bob@example.com", "bob@example.com", 7, 10, 0}, args)
+
+	// A cursor only works for the sort it was created with
+	_, err = query.DecodeCursor(raw, query.Sort{Field: "email"})
+	assert.ErrorIs(t, err, query.ErrInvalidCursor)
+}
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"id", "created_at"}
+	def := query.Sort{Field: "created_at", Desc: true}
+
+	sort, err := query.ParseSort("", allowed, def)
+	require.NoError(t, err)
+	assert.Equal(t, def, sort)
+
+	sort, err = query.ParseSort("-id", allowed, def)
+	require.NoError(t, err)
+	assert.Equal(t, query.Sort{Field: "id", Desc: true}, sort)
+
+	_, err = query.ParseSort("password_hash", allowed, def)
+	assert.ErrorIs(t, err, query.ErrInvalidSort)
+}
@@ -0,0 +1,90 @@
+package handler_test
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/middleware"
+	"go-rest-api-template/internal/repository"
+	"go-rest-api-template/internal/service"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRateLimitService() service.RateLimitService {
+	return service.NewRateLimitService(repository.NewInMemoryRateLimitRepository(), service.RateLimitConfig{
+		DefaultPerSecond: 1,
+		DefaultBurst:     3,
+		IPPerMinute:      1,
+		IPBurst:          2,
+	})
+}
+
+func TestRateLimitService_AllowApiKey(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestRateLimitService()
+	apiKey := &entity.ApiKey{ID: 1}
+
+	// The default burst is available at once, then the key has to wait for a refill
+	for i := 0; i < 3; i++ {
+		decision, err := svc.AllowApiKey(ctx, apiKey)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 3, decision.Limit)
+		assert.Equal(t, 2-i, decision.Remaining)
+	}
+
+	decision, err := svc.AllowApiKey(ctx, apiKey)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.False(t, decision.QuotaExceeded)
+	assert.Greater(t, decision.RetryAfter.Seconds(), 0.0)
+
+	// Limits stored on a key replace the defaults, and buckets are per key
+	decision, err = svc.AllowApiKey(ctx, &entity.ApiKey{ID: 2, RateLimitPerSecond: 100, RateLimitBurst: 50})
+	require.NoError(t, err)
+	assert.True(t, decision.Allowed)
+	assert.Equal(t, 50, decision.Limit)
+}
+
+func TestRateLimitService_DailyQuota(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestRateLimitService()
+	apiKey := &entity.ApiKey{ID: 1, RateLimitPerSecond: 100, RateLimitBurst: 100, DailyQuota: 2}
+
+	for i := 0; i < 2; i++ {
+		decision, err := svc.AllowApiKey(ctx, apiKey)
+		require.NoError(t, err)
+		assert.True(t, decision.Allowed)
+		assert.Equal(t, 1-i, decision.QuotaRemaining)
+	}
+
+	decision, err := svc.AllowApiKey(ctx, apiKey)
+	require.NoError(t, err)
+	assert.False(t, decision.Allowed)
+	assert.True(t, decision.QuotaExceeded)
+	assert.Equal(t, 0, decision.QuotaRemaining)
+}
+
+func TestIPRateLimitMiddleware(t *testing.T) {
+	app := fiber.New()
+	app.Post("/login", middleware.IPRateLimitMiddleware(newTestRateLimitService(), "login"), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "2", resp.Header.Get("X-RateLimit-Limit"))
+	}
+
+	resp, err := app.Test(httptest.NewRequest("POST", "/login", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("X-RateLimit-Remaining"))
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+}
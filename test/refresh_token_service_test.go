@@ -0,0 +1,114 @@
+package handler_test
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRefreshTokenRepository is an in-memory RefreshTokenRepository for testing
+type MockRefreshTokenRepository struct {
+	tokens map[int]*entity.RefreshToken
+}
+
+func NewMockRefreshTokenRepository() *MockRefreshTokenRepository {
+	return &MockRefreshTokenRepository{
+		tokens: make(map[int]*entity.RefreshToken),
+	}
+}
+
+func (m *MockRefreshTokenRepository) Create(ctx context.Context, token *entity.RefreshToken) error {
+	token.ID = len(m.tokens) + 1
+	m.tokens[token.ID] = token
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	for _, token := range m.tokens {
+		if token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockRefreshTokenRepository) MarkRotated(ctx context.Context, id int, replacedBy int) (bool, error) {
+	token := m.tokens[id]
+	if token == nil || token.RotatedAt != nil || token.RevokedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	token.RotatedAt = &now
+	token.ReplacedBy = &replacedBy
+	return true, nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeByUserID(ctx context.Context, userID int) error {
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) RevokeBySessionToken(ctx context.Context, sessionToken string) error {
+	now := time.Now()
+	for _, token := range m.tokens {
+		if token.SessionToken == sessionToken && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func TestRefreshTokenService_RotateAndReuse(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewRefreshTokenService(NewMockRefreshTokenRepository(), 24)
+
+	first, err := svc.Issue(ctx, 1, 10, "")
+	require.NoError(t, err)
+
+	// First rotation succeeds and returns a new token
+	_, second, err := svc.Rotate(ctx, first, 10)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	// Reusing the spent token revokes the family
+	_, _, err = svc.Rotate(ctx, first, 10)
+	assert.ErrorIs(t, err, service.ErrRefreshTokenReused)
+
+	// The latest token in the family is revoked as well
+	_, _, err = svc.Rotate(ctx, second, 10)
+	assert.ErrorIs(t, err, service.ErrRefreshTokenReused)
+}
+
+func TestRefreshTokenService_BoundToApiKey(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewRefreshTokenService(NewMockRefreshTokenRepository(), 24)
+
+	token, err := svc.Issue(ctx, 1, 10, "")
+	require.NoError(t, err)
+
+	_, _, err = svc.Rotate(ctx, token, 11)
+	assert.ErrorIs(t, err, service.ErrRefreshTokenInvalid)
+
+	_, _, err = svc.Rotate(ctx, "unknown", 10)
+	assert.ErrorIs(t, err, service.ErrRefreshTokenInvalid)
+}
@@ -0,0 +1,54 @@
+package handler_test
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/repository"
+	"go-rest-api-template/internal/service"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSignedRequest(authKey, nonce string, timestamp time.Time, body []byte) service.SignedRequest {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return service.SignedRequest{
+		Method:    "POST",
+		Path:      "/api/v1/public/auth/login",
+		Timestamp: ts,
+		Nonce:     nonce,
+		Signature: service.SignRequest(authKey, "POST", "/api/v1/public/auth/login", ts, nonce, body),
+		Body:      body,
+	}
+}
+
+func TestRequestSignatureService_Verify(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewRequestSignatureService(repository.NewInMemoryRequestNonceRepository(), 300)
+	apiKey := &entity.ApiKey{ID: 1, AuthKey: "partner-secret", H2H: "Y"}
+	body := []byte(`{"username":"partner"}`)
+
+	req := newSignedRequest(apiKey.AuthKey, "nonce-1", time.Now(), body)
+	assert.NoError(t, svc.Verify(ctx, apiKey, req))
+
+	// Replaying the same request is rejected
+	assert.ErrorIs(t, svc.Verify(ctx, apiKey, req), service.ErrNonceReused)
+
+	// Tampered body
+	req = newSignedRequest(apiKey.AuthKey, "nonce-2", time.Now(), body)
+	req.Body = []byte(`{"username":"admin"}`)
+	assert.ErrorIs(t, svc.Verify(ctx, apiKey, req), service.ErrSignatureInvalid)
+
+	// Signed with the wrong key
+	req = newSignedRequest("other-secret", "nonce-3", time.Now(), body)
+	assert.ErrorIs(t, svc.Verify(ctx, apiKey, req), service.ErrSignatureInvalid)
+
+	// Stale timestamp
+	req = newSignedRequest(apiKey.AuthKey, "nonce-4", time.Now().Add(-10*time.Minute), body)
+	assert.ErrorIs(t, svc.Verify(ctx, apiKey, req), service.ErrSignatureExpired)
+
+	// Missing headers
+	assert.ErrorIs(t, svc.Verify(ctx, apiKey, service.SignedRequest{Method: "GET"}), service.ErrSignatureRequired)
+}
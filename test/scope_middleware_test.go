@@ -0,0 +1,50 @@
+package handler_test
+
+import (
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/middleware"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newScopeTestApp serves GET /users behind RequireScopes with the given key in the context
+func newScopeTestApp(apiKey *entity.ApiKey, scopes ...string) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		if apiKey != nil {
+			c.Locals("api_key", apiKey)
+		}
+		return c.Next()
+	})
+	app.Get("/users", middleware.RequireScopes(scopes...), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestRequireScopes(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey *entity.ApiKey
+		scopes []string
+		status int
+	}{
+		{"granted scope", &entity.ApiKey{Scopes: []string{"users:read"}}, []string{"users:read"}, fiber.StatusOK},
+		{"wildcard scope", &entity.ApiKey{Scopes: []string{"*"}}, []string{"users:read", "users:write"}, fiber.StatusOK},
+		{"missing one of the scopes", &entity.ApiKey{Scopes: []string{"users:read"}}, []string{"users:read", "users:write"}, fiber.StatusForbidden},
+		{"key without scopes", &entity.ApiKey{}, []string{"users:read"}, fiber.StatusForbidden},
+		{"no api key", nil, []string{"users:read"}, fiber.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := newScopeTestApp(tt.apiKey, tt.scopes...).Test(httptest.NewRequest("GET", "/users", nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.status, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package handler_test
+
+import (
+	"context"
+	"go-rest-api-template/internal/domain/entity"
+	"go-rest-api-template/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MockSessionRepository is an in-memory SessionRepository for testing
+type MockSessionRepository struct {
+	sessions map[int]*entity.Session
+}
+
+func NewMockSessionRepository() *MockSessionRepository {
+	return &MockSessionRepository{
+		sessions: make(map[int]*entity.Session),
+	}
+}
+
+func (m *MockSessionRepository) Create(ctx context.Context, session *entity.Session) error {
+	session.ID = len(m.sessions) + 1
+	m.sessions[session.ID] = session
+	return nil
+}
+
+func (m *MockSessionRepository) GetByID(ctx context.Context, id int) (*entity.Session, error) {
+	return m.sessions[id], nil
+}
+
+func (m *MockSessionRepository) GetBySessionToken(ctx context.Context, sessionToken string) (*entity.Session, error) {
+	for _, session := range m.sessions {
+		if session.SessionToken == sessionToken {
+			return session, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MockSessionRepository) GetActiveByUserID(ctx context.Context, userID int) ([]*entity.Session, error) {
+	var sessions []*entity.Session
+	for _, session := range m.sessions {
+		if session.UserID == userID && session.IsActive() {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *MockSessionRepository) GetByUserID(ctx context.Context, userID int) ([]*entity.Session, error) {
+	var sessions []*entity.Session
+	for _, session := range m.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *MockSessionRepository) Touch(ctx context.Context, id int) error {
+	now := time.Now()
+	m.sessions[id].LastSeenAt = &now
+	return nil
+}
+
+func (m *MockSessionRepository) Extend(ctx context.Context, id int, expiresAt time.Time) error {
+	m.sessions[id].ExpiresAt = expiresAt
+	return nil
+}
+
+func (m *MockSessionRepository) Terminate(ctx context.Context, id int) error {
+	now := time.Now()
+	m.sessions[id].TerminatedAt = &now
+	return nil
+}
+
+func (m *MockSessionRepository) TerminateByUserID(ctx context.Context, userID int) error {
+	now := time.Now()
+	for _, session := range m.sessions {
+		if session.UserID == userID && session.TerminatedAt == nil {
+			session.TerminatedAt = &now
+		}
+	}
+	return nil
+}
+
+func TestSessionService_TerminateRevokesRefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	refreshRepo := NewMockRefreshTokenRepository()
+	sessionService := service.NewSessionService(NewMockSessionRepository(), refreshRepo, 24)
+	refreshService := service.NewRefreshTokenService(refreshRepo, 24)
+
+	session, err := sessionService.Start(ctx, 1, 10, "127.0.0.1", "test-agent")
+	require.NoError(t, err)
+
+	refreshToken, err := refreshService.Issue(ctx, 1, 10, session.SessionToken)
+	require.NoError(t, err)
+
+	// Sessions of other users cannot be terminated
+	err = sessionService.Terminate(ctx, 2, session.ID)
+	assert.ErrorIs(t, err, service.ErrSessionNotFound)
+
+	require.NoError(t, sessionService.Terminate(ctx, 1, session.ID))
+
+	_, err = sessionService.Validate(ctx, session.SessionToken)
+	assert.ErrorIs(t, err, service.ErrSessionTerminated)
+
+	// The refresh token of the terminated session can no longer be used
+	_, _, err = refreshService.Rotate(ctx, refreshToken, 10)
+	assert.Error(t, err)
+}
@@ -0,0 +1,256 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/domain/repository"
+	"go-rest-api-template/internal/handler"
+	"go-rest-api-template/internal/service"
+	"go-rest-api-template/pkg/password"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestUserBulkService(repo *MockUserRepository, roleRepo *MockRoleRepository, emailService service.EmailService) service.UserBulkService {
+	return service.NewUserBulkService(repo, service.NewRoleService(roleRepo, repo), emailService, newTestAuditService(), service.UserBulkConfig{
+		InvitationExpiry: 72 * time.Hour,
+		JobRetention:     time.Hour,
+	})
+}
+
+func TestUserBulkService_ImportHashedPasswords(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "existing", "existing@example.com", constant.UserStatusActive)
+	roleRepo := NewMockRoleRepository()
+	bulkService := newTestUserBulkService(repo, roleRepo, NewMockEmailService())
+
+	hash, err := password.NewBcryptHasher(4)
+	require.NoError(t, err)
+	aliceHash, err := hash.Hash("alice-password")
+	require.NoError(t, err)
+
+	file := "username,email,full_name,password_hash\n" +
+		"alice,alice@example.com,Alice,\"" + aliceHash + "\"\n" +
+		"bob,not-an-email,Bob,\"" + aliceHash + "\"\n" +
+		"carol,carol@example.com,Carol,\n" +
+		"ALICE,alice2@example.com,Alice Again,\"" + aliceHash + "\"\n" +
+		"dave,existing@example.com,Dave,\"" + aliceHash + "\"\n" +
+		"erin,erin@example.com,Erin,plaintext\n"
+	opts := service.UserImportOptions{Format: constant.UserFileFormatCSV, Passwords: constant.UserImportPasswordsHashed, DryRun: true}
+
+	report, err := bulkService.Import(ctx, strings.NewReader(file), opts)
+	require.NoError(t, err)
+	assert.Equal(t, 6, report.Rows)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 5, report.Failed)
+	assert.Len(t, repo.users, 1, "a dry run creates nobody")
+
+	// Lines count from the header
+	fields := make(map[int]string)
+	for _, rowErr := range report.Errors {
+		require.NotEmpty(t, rowErr.Errors)
+		fields[rowErr.Line] = rowErr.Errors[0].Field + " " + rowErr.Errors[0].Tag
+	}
+	assert.Equal(t, map[int]string{
+		3: "email email",
+		4: "password_hash required",
+		5: "username unique",
+		6: "email unique",
+		7: "password_hash password_hash",
+	}, fields)
+
+	opts.DryRun = false
+	report, err = bulkService.Import(ctx, strings.NewReader(file), opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+
+	alice, err := repo.GetByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", alice.FullName)
+	assert.Equal(t, map[string]bool{constant.RoleUser: true}, roleRepo.userRoles[alice.ID], "imported users get the default role")
+	assert.Equal(t, constant.UserStatusActive, alice.Status)
+	assert.True(t, alice.CheckPassword("alice-password"))
+}
+
+func TestUserBulkService_ImportInvitations(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockUserRepository()
+	emailService := NewMockEmailService()
+	bulkService := newTestUserBulkService(repo, NewMockRoleRepository(), emailService)
+
+	file := `{"username":"alice","email":"alice@example.com","locale":"id"}
+
+{"username":"bob",
+{"username":"carol","email":"carol@example.com","timezone":"Mars/Olympus"}
+`
+	report, err := bulkService.Import(ctx, strings.NewReader(file), service.UserImportOptions{
+		Format:    constant.UserFileFormatNDJSON,
+		Passwords: constant.UserImportPasswordsInvite,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Rows)
+	assert.Equal(t, 1, report.Created)
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, 3, report.Errors[0].Line)
+	assert.Equal(t, "format", report.Errors[0].Errors[0].Tag)
+	assert.Equal(t, "timezone", report.Errors[1].Errors[0].Field)
+
+	// The invitation is a password reset token, the user has no password before
+	alice, err := repo.GetByUsername(ctx, "alice")
+	require.NoError(t, err)
+	assert.False(t, alice.CheckPassword(""))
+	token := emailService.sentToken(t)
+
+	userService := newTestUserService(repo, emailService, service.UserServiceConfig{})
+	require.NoError(t, userService.ResetPassword(ctx, token, "alice-chose-this"))
+	assert.True(t, repo.users[alice.ID].CheckPassword("alice-chose-this"))
+}
+
+func TestUserBulkService_ExportCanBeImported(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "alice", "alice@example.com", constant.UserStatusActive)
+	repo.AddTestUser(2, "bob", "bob@example.com", "suspended")
+	repo.AddTestUser(3, "carol", "carol@example.com", constant.UserStatusActive)
+	repo.users[1].FullName = "Alice, the first"
+	bulkService := newTestUserBulkService(repo, NewMockRoleRepository(), NewMockEmailService())
+
+	var csvFile bytes.Buffer
+	exported, err := bulkService.Export(ctx, &csvFile, constant.UserFileFormatCSV, repository.UserFilter{Status: constant.UserStatusActive})
+	require.NoError(t, err)
+	assert.Equal(t, 2, exported)
+	lines := strings.Split(strings.TrimSpace(csvFile.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "id,username,email,full_name,phone,locale,timezone,status,created_at", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], `1,alice,alice@example.com,"Alice, the first",`))
+
+	var ndjsonFile bytes.Buffer
+	_, err = bulkService.Export(ctx, &ndjsonFile, constant.UserFileFormatNDJSON, repository.UserFilter{})
+	require.NoError(t, err)
+	var first service.UserExportRow
+	require.NoError(t, json.Unmarshal([]byte(strings.SplitN(ndjsonFile.String(), "\n", 2)[0]), &first))
+	assert.Equal(t, "alice", first.Username)
+
+	// Another installation takes the export as it is
+	target := NewMockUserRepository()
+	report, err := newTestUserBulkService(target, NewMockRoleRepository(), NewMockEmailService()).Import(ctx, &csvFile, service.UserImportOptions{
+		Format:    constant.UserFileFormatCSV,
+		Passwords: constant.UserImportPasswordsInvite,
+		DryRun:    true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Empty(t, report.Errors)
+}
+
+func TestUserBulkHandler_ImportAndExportJobs(t *testing.T) {
+	setupTestGlobalHelpers()
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "admin", "admin@example.com", constant.UserStatusActive)
+	bulkHandler := handler.NewUserBulkHandler(newTestUserBulkService(repo, NewMockRoleRepository(), NewMockEmailService()))
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", 1)
+		return c.Next()
+	})
+	app.Post("/admin/users/import", bulkHandler.ImportUsers)
+	app.Post("/admin/users/export", bulkHandler.ExportUsers)
+	app.Get("/admin/users/jobs/:id", bulkHandler.GetJob)
+	app.Get("/admin/users/jobs/:id/download", bulkHandler.DownloadExport)
+
+	upload := func(filename, content string, fields map[string]string) *http.Response {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		for name, value := range fields {
+			require.NoError(t, form.WriteField(name, value))
+		}
+		if filename != "" {
+			part, err := form.CreateFormFile("file", filename)
+			require.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest("POST", "/admin/users/import", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		resp, err := app.Test(req, 5000)
+		require.NoError(t, err)
+		return resp
+	}
+	// waitForJob polls the job until it finished
+	waitForJob := func(id string) map[string]interface{} {
+		for i := 0; i < 100; i++ {
+			status, job := requestJSONData(t, app, "GET", "/admin/users/jobs/"+id, "")
+			require.Equal(t, http.StatusOK, status)
+			if job["status"] != constant.UserJobStatusRunning {
+				return job
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("job did not finish")
+		return nil
+	}
+
+	resp := upload("users.csv", "username,email\nalice,alice@example.com\nbob,bad\n", map[string]string{"passwords": "invite"})
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	var accepted struct {
+		Data struct {
+			ID     string `json:"id"`
+			Status string `json:"status"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&accepted))
+	assert.Equal(t, constant.UserJobStatusRunning, accepted.Data.Status)
+
+	job := waitForJob(accepted.Data.ID)
+	assert.Equal(t, constant.UserJobStatusCompleted, job["status"])
+	assert.EqualValues(t, 1, job["created_by"])
+	report := job["report"].(map[string]interface{})
+	assert.EqualValues(t, 1, report["created"])
+	assert.EqualValues(t, 1, report["failed"])
+	alice, err := repo.GetByUsername(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 1, *alice.CreatedBy)
+
+	// Choosing how passwords are handled is mandatory, the format comes from the file name
+	assert.Equal(t, http.StatusUnprocessableEntity, upload("users.csv", "username,email\n", nil).StatusCode)
+	assert.Equal(t, http.StatusUnprocessableEntity, upload("users.xlsx", "", map[string]string{"passwords": "hashed"}).StatusCode)
+	assert.Equal(t, http.StatusBadRequest, upload("", "", map[string]string{"passwords": "hashed"}).StatusCode)
+
+	status, data := requestJSONData(t, app, "POST", "/admin/users/export?format=ndjson&search=alice", "")
+	require.Equal(t, http.StatusAccepted, status)
+	exportID := data["id"].(string)
+
+	// Import jobs have nothing to download
+	status, _ = requestJSONData(t, app, "GET", "/admin/users/jobs/"+accepted.Data.ID+"/download", "")
+	assert.Equal(t, http.StatusConflict, status)
+
+	job = waitForJob(exportID)
+	assert.Equal(t, constant.UserJobStatusCompleted, job["status"])
+	assert.EqualValues(t, 1, job["exported"])
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/admin/users/jobs/"+exportID+"/download", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Disposition"), ".ndjson")
+	content, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"username":"alice"`)
+
+	status, _ = requestJSONData(t, app, "GET", "/admin/users/jobs/unknown", "")
+	assert.Equal(t, http.StatusNotFound, status)
+}
@@ -0,0 +1,103 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-api-template/internal/constant"
+	"go-rest-api-template/internal/handler"
+	"go-rest-api-template/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserHandler_ListAndRestoreDeletedUsers(t *testing.T) {
+	setupTestGlobalHelpers()
+	ctx := context.Background()
+
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "alice", "alice@example.com", constant.UserStatusActive)
+	repo.AddTestUser(2, "bob", "bob@example.com", constant.UserStatusActive)
+	repo.AddTestUser(3, "carol", "carol@example.com", constant.UserStatusActive)
+	userService := newTestUserService(repo, NewMockEmailService(), service.UserServiceConfig{})
+	userHandler := handler.NewUserHandler(userService)
+	require.NoError(t, userService.DeleteUser(ctx, 1))
+	require.NoError(t, userService.DeleteUser(ctx, 2))
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", 3)
+		return c.Next()
+	})
+	app.Get("/admin/users/deleted", userHandler.GetDeletedUsers)
+	app.Post("/admin/users/:id/restore", userHandler.RestoreUser)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/admin/users/deleted?sort=id", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var body struct {
+		Data []struct {
+			ID        int        `json:"id"`
+			DeletedAt *time.Time `json:"deleted_at"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.Data, 2)
+	assert.Equal(t, 1, body.Data[0].ID)
+	assert.NotNil(t, body.Data[0].DeletedAt)
+
+	status, data := requestJSONData(t, app, "POST", "/admin/users/1/restore", "")
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "alice", data["username"])
+	assert.Nil(t, data["deleted_at"])
+	require.Contains(t, repo.users, 1)
+	assert.Equal(t, 3, *repo.users[1].UpdatedBy)
+
+	// Only deleted users can be restored
+	status, _ = requestJSONData(t, app, "POST", "/admin/users/1/restore", "")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// A new account took the username in the meantime
+	repo.AddTestUser(4, "bob", "bob.new@example.com", constant.UserStatusActive)
+	status, _ = requestJSONData(t, app, "POST", "/admin/users/2/restore", "")
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, repo.deleted, 2)
+}
+
+func TestUserRetentionService_PurgesAfterRetentionPeriod(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockUserRepository()
+	repo.AddTestUser(1, "alice", "alice@example.com", constant.UserStatusActive)
+	repo.AddTestUser(2, "bob", "bob@example.com", constant.UserStatusActive)
+	repo.passwordHistory[1] = []string{"old-hash"}
+	auditRepo := NewMockAuditLogRepository()
+	userService := newTestUserService(repo, NewMockEmailService(), service.UserServiceConfig{})
+	require.NoError(t, userService.DeleteUser(ctx, 1))
+	require.NoError(t, userService.DeleteUser(ctx, 2))
+
+	longAgo := time.Now().Add(-48 * time.Hour)
+	repo.deleted[1].DeletedAt = &longAgo
+
+	// Without a retention period nothing is purged
+	purged, err := service.NewUserRetentionService(repo, service.NewAuditService(auditRepo), service.UserRetentionConfig{}).PurgeDeleted(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	retention := service.NewUserRetentionService(repo, service.NewAuditService(auditRepo), service.UserRetentionConfig{PurgeAfter: 24 * time.Hour})
+	purged, err = retention.PurgeDeleted(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.NotContains(t, repo.deleted, 1)
+	assert.NotContains(t, repo.passwordHistory, 1)
+	assert.Contains(t, repo.deleted, 2)
+
+	logs := auditRepo.byAction(constant.AuditActionUserPurged)
+	require.Len(t, logs, 1)
+	assert.Equal(t, 1, *logs[0].EntityID)
+	assert.Equal(t, constant.UserPurgeModeAnonymize, logs[0].Details["mode"])
+}